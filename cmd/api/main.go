@@ -12,9 +12,14 @@ import (
 	"wavefy-be/docs"
 	"wavefy-be/internal/app"
 	"wavefy-be/internal/cache"
+	"wavefy-be/internal/captcha"
 	"wavefy-be/internal/db"
 	"wavefy-be/internal/mail"
+	"wavefy-be/internal/mfa"
+	"wavefy-be/internal/oauth"
+	"wavefy-be/internal/password"
 	"wavefy-be/internal/storage"
+	"wavefy-be/internal/token"
 )
 
 func main() {
@@ -32,13 +37,25 @@ func main() {
 		}
 	}()
 
-	redisClient, err := cache.NewRedisClient(cfg.Redis)
-	if err != nil {
-		panic(err)
+	var stores *token.Stores
+	switch cfg.Auth.TokenBackend {
+	case "memory":
+		stores = token.NewMemoryStores(cfg.Auth)
+	case "redis":
+		if cfg.Redis.Addr == "" {
+			panic("missing required env: REDIS_ADDR")
+		}
+		redisClient, err := cache.NewRedisClient(cfg.Redis)
+		if err != nil {
+			panic(err)
+		}
+		defer func() {
+			_ = redisClient.Close()
+		}()
+		stores = token.NewRedisStores(redisClient, cfg.Auth)
+	default:
+		panic("invalid AUTH_TOKEN_BACKEND: " + cfg.Auth.TokenBackend)
 	}
-	defer func() {
-		_ = redisClient.Close()
-	}()
 
 	docs.SwaggerInfo.Host = "localhost:" + cfg.Port
 	docs.SwaggerInfo.BasePath = "/api"
@@ -53,7 +70,39 @@ func main() {
 		panic(err)
 	}
 
-	server := app.NewHTTP(conn, redisClient, cfg.Auth, cfg.Google, mailer, r2Client, cfg.R2)
+	keys, err := token.LoadKeyRing(cfg.Auth)
+	if err != nil {
+		panic(err)
+	}
+
+	policy, err := password.NewPolicy(cfg.Password)
+	if err != nil {
+		panic(err)
+	}
+
+	hasher := password.NewHasher(cfg.Password)
+
+	if cfg.AppEnv != "development" && mfa.IsPlaceholderSecret(cfg.Auth.MFASecret) {
+		panic("AUTH_MFA_SECRET is an example value; generate one with: openssl rand -base64 32")
+	}
+
+	providers := oauth.NewRegistryFromConfig(cfg.Google, cfg.OAuth)
+
+	captchaVerifier, err := captcha.NewVerifierFromConfig(cfg.Captcha)
+	if err != nil {
+		panic(err)
+	}
+
+	if err := app.SyncAccountBlocks(ctx, conn, stores); err != nil {
+		panic(err)
+	}
+	go app.RunAccountPurge(ctx, conn, stores, cfg.Auth, r2Client, cfg.R2)
+	go app.RunDataExportCleanup(ctx, cfg.Auth, r2Client, cfg.R2)
+
+	server, err := app.NewHTTP(conn, stores, cfg.Auth, keys, policy, hasher, providers, captchaVerifier, mailer, r2Client, cfg.R2)
+	if err != nil {
+		panic(err)
+	}
 	if err := server.Run(":" + cfg.Port); err != nil {
 		panic(err)
 	}
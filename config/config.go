@@ -3,14 +3,17 @@ package config
 import "time"
 
 type Config struct {
-	Port   string
-	AppEnv string
-	DB     DBConfig
-	Auth   AuthConfig
-	Redis  RedisConfig
-	Mail   MailConfig
-	Google GoogleOAuthConfig
-	R2     R2Config
+	Port     string
+	AppEnv   string
+	DB       DBConfig
+	Auth     AuthConfig
+	Password PasswordConfig
+	Redis    RedisConfig
+	Mail     MailConfig
+	Google   GoogleOAuthConfig
+	OAuth    OAuthConfig
+	Captcha  CaptchaConfig
+	R2       R2Config
 }
 
 type DBConfig struct {
@@ -28,6 +31,9 @@ type DBConfig struct {
 
 type AuthConfig struct {
 	JWTSecret           string
+	JWTKeysDir          string
+	JWTActiveKID        string
+	JWTLegacyHS256Until time.Time
 	AccessTokenTTL      time.Duration
 	AccessTokenIss      string
 	RefreshTokenTTL     time.Duration
@@ -36,6 +42,61 @@ type AuthConfig struct {
 	PasswordResetSecret string
 	VerifyEmailTTL      time.Duration
 	VerifyEmailSecret   string
+	VerifyCooldown      time.Duration
+	VerifyDailyCap      int
+	EmailChangeTTL      time.Duration
+	EmailRevertTTL      time.Duration
+	DeviceReportTTL     time.Duration
+	MagicLinkTTL        time.Duration
+	MagicLinkSecret     string
+	ReauthTTL           time.Duration
+	TokenBackend        string
+	MFASecret           string
+	MFAChallengeTTL     time.Duration
+	MFAIssuer           string
+	WebAuthnRPID        string
+	WebAuthnRPName      string
+	WebAuthnRPOrigins   []string
+	WebAuthnTimeout     time.Duration
+	LoginRateLimit      RateLimitConfig
+	CaptchaAfter        int
+	CaptchaWindow       time.Duration
+	OAuthCodeTTL        time.Duration
+	OAuthAccessTTL      time.Duration
+	OAuthRefreshTTL     time.Duration
+	DeletionGrace       time.Duration
+	PurgeInterval       time.Duration
+	ExportLinkTTL       time.Duration
+	ExportCooldown      time.Duration
+	ImpersonateTTL      time.Duration
+}
+
+// RateLimitConfig bounds login requests in a sliding window per client IP,
+// per email and per IP and email pair. A limit of zero disables that key. A
+// blocked key waits Backoff, doubling on each further block up to MaxBackoff.
+type RateLimitConfig struct {
+	Window       time.Duration
+	IPLimit      int
+	EmailLimit   int
+	IPEmailLimit int
+	Backoff      time.Duration
+	MaxBackoff   time.Duration
+}
+
+type PasswordConfig struct {
+	MinLength        int
+	MaxLength        int
+	RequireUpper     bool
+	RequireLower     bool
+	RequireDigit     bool
+	RequireSymbol    bool
+	BreachedListPath string
+
+	HashAlgorithm     string
+	Argon2Memory      uint32
+	Argon2Iterations  uint32
+	Argon2Parallelism uint8
+	BcryptCost        int
 }
 
 type RedisConfig struct {
@@ -59,6 +120,32 @@ type GoogleOAuthConfig struct {
 	OAuthStateTTL time.Duration
 }
 
+// OAuthProviderConfig holds the client credentials of one identity
+// provider. Issuer is only used by the generic OIDC provider.
+type OAuthProviderConfig struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Issuer       string
+}
+
+type OAuthConfig struct {
+	Apple    OAuthProviderConfig
+	Facebook OAuthProviderConfig
+	GitHub   OAuthProviderConfig
+	OIDCName string
+	OIDC     OAuthProviderConfig
+}
+
+// CaptchaConfig selects the CAPTCHA provider. An empty Provider disables
+// challenges. MinScore only applies to reCAPTCHA v3.
+type CaptchaConfig struct {
+	Provider string
+	SiteKey  string
+	Secret   string
+	MinScore float64
+}
+
 type R2Config struct {
 	AccountID       string
 	Bucket          string
@@ -30,7 +30,10 @@ func Load() Config {
 			ConnMaxIdleTime: getenvDuration("DB_CONN_MAX_IDLE_TIME", 5*time.Minute),
 		},
 		Auth: AuthConfig{
-			JWTSecret:           getenvRequired("AUTH_JWT_SECRET"),
+			JWTSecret:           getenv("AUTH_JWT_SECRET", ""),
+			JWTKeysDir:          getenv("AUTH_JWT_KEYS_DIR", ""),
+			JWTActiveKID:        getenv("AUTH_JWT_ACTIVE_KID", ""),
+			JWTLegacyHS256Until: getenvTime("AUTH_JWT_LEGACY_HS256_UNTIL"),
 			AccessTokenTTL:      getenvDuration("AUTH_ACCESS_TOKEN_TTL", 24*time.Hour),
 			AccessTokenIss:      getenvRequired("AUTH_ACCESS_TOKEN_ISSUER"),
 			RefreshTokenTTL:     getenvDuration("AUTH_REFRESH_TOKEN_TTL", 7*24*time.Hour),
@@ -39,9 +42,58 @@ func Load() Config {
 			PasswordResetSecret: getenvRequired("AUTH_PASSWORD_RESET_SECRET"),
 			VerifyEmailTTL:      getenvDuration("AUTH_VERIFY_EMAIL_TTL", 24*time.Hour),
 			VerifyEmailSecret:   getenvRequired("AUTH_VERIFY_EMAIL_SECRET"),
+			VerifyCooldown:      getenvDuration("AUTH_VERIFY_RESEND_COOLDOWN", time.Minute),
+			VerifyDailyCap:      getenvInt("AUTH_VERIFY_RESEND_DAILY_CAP", 5),
+			EmailChangeTTL:      getenvDuration("AUTH_EMAIL_CHANGE_TTL", time.Hour),
+			EmailRevertTTL:      getenvDuration("AUTH_EMAIL_REVERT_TTL", 7*24*time.Hour),
+			DeviceReportTTL:     getenvDuration("AUTH_DEVICE_REPORT_TTL", 7*24*time.Hour),
+			MagicLinkTTL:        getenvDuration("AUTH_MAGIC_LINK_TTL", 10*time.Minute),
+			MagicLinkSecret:     getenv("AUTH_MAGIC_LINK_SECRET", ""),
+			ReauthTTL:           getenvDuration("AUTH_REAUTH_TTL", 10*time.Minute),
+			TokenBackend:        getenv("AUTH_TOKEN_BACKEND", "redis"),
+			MFASecret:           getenv("AUTH_MFA_SECRET", ""),
+			MFAChallengeTTL:     getenvDuration("AUTH_MFA_CHALLENGE_TTL", 5*time.Minute),
+			MFAIssuer:           getenv("AUTH_MFA_ISSUER", "Wavefy"),
+			WebAuthnRPID:        getenv("WEBAUTHN_RP_ID", ""),
+			WebAuthnRPName:      getenv("WEBAUTHN_RP_NAME", "Wavefy"),
+			WebAuthnRPOrigins:   getenvList("WEBAUTHN_RP_ORIGINS"),
+			WebAuthnTimeout:     getenvDuration("WEBAUTHN_TIMEOUT", 5*time.Minute),
+			LoginRateLimit: RateLimitConfig{
+				Window:       getenvDuration("LOGIN_RATE_LIMIT_WINDOW", time.Minute),
+				IPLimit:      getenvInt("LOGIN_RATE_LIMIT_IP", 20),
+				EmailLimit:   getenvInt("LOGIN_RATE_LIMIT_EMAIL", 10),
+				IPEmailLimit: getenvInt("LOGIN_RATE_LIMIT_IP_EMAIL", 5),
+				Backoff:      getenvDuration("LOGIN_RATE_LIMIT_BACKOFF", 30*time.Second),
+				MaxBackoff:   getenvDuration("LOGIN_RATE_LIMIT_MAX_BACKOFF", 15*time.Minute),
+			},
+			CaptchaAfter:    getenvInt("AUTH_CAPTCHA_AFTER_FAILURES", 3),
+			CaptchaWindow:   getenvDuration("AUTH_CAPTCHA_WINDOW", 10*time.Minute),
+			OAuthCodeTTL:    getenvDuration("AUTH_OAUTH_CODE_TTL", 5*time.Minute),
+			OAuthAccessTTL:  getenvDuration("AUTH_OAUTH_ACCESS_TTL", time.Hour),
+			OAuthRefreshTTL: getenvDuration("AUTH_OAUTH_REFRESH_TTL", 30*24*time.Hour),
+			DeletionGrace:   getenvDuration("ACCOUNT_DELETION_GRACE", 30*24*time.Hour),
+			PurgeInterval:   getenvDuration("ACCOUNT_PURGE_INTERVAL", time.Hour),
+			ExportLinkTTL:   getenvDuration("DATA_EXPORT_LINK_TTL", time.Hour),
+			ExportCooldown:  getenvDuration("DATA_EXPORT_COOLDOWN", 24*time.Hour),
+			ImpersonateTTL:  getenvDuration("AUTH_IMPERSONATION_TTL", 15*time.Minute),
+		},
+		Password: PasswordConfig{
+			MinLength:        getenvInt("PASSWORD_MIN_LENGTH", 8),
+			MaxLength:        getenvInt("PASSWORD_MAX_LENGTH", 72),
+			RequireUpper:     getenvBool("PASSWORD_REQUIRE_UPPER", true),
+			RequireLower:     getenvBool("PASSWORD_REQUIRE_LOWER", true),
+			RequireDigit:     getenvBool("PASSWORD_REQUIRE_DIGIT", true),
+			RequireSymbol:    getenvBool("PASSWORD_REQUIRE_SYMBOL", false),
+			BreachedListPath: getenv("PASSWORD_BREACHED_LIST", ""),
+
+			HashAlgorithm:     getenv("PASSWORD_HASH_ALGORITHM", "argon2id"),
+			Argon2Memory:      uint32(getenvInt("PASSWORD_ARGON2_MEMORY_KIB", 64*1024)),
+			Argon2Iterations:  uint32(getenvInt("PASSWORD_ARGON2_ITERATIONS", 3)),
+			Argon2Parallelism: uint8(getenvInt("PASSWORD_ARGON2_PARALLELISM", 2)),
+			BcryptCost:        getenvInt("PASSWORD_BCRYPT_COST", 10),
 		},
 		Redis: RedisConfig{
-			Addr:     getenvRequired("REDIS_ADDR"),
+			Addr:     getenv("REDIS_ADDR", ""),
 			Password: getenv("REDIS_PASSWORD", ""),
 			DB:       getenvInt("REDIS_DB", 0),
 		},
@@ -58,6 +110,36 @@ func Load() Config {
 			RedirectURL:   getenv("GOOGLE_REDIRECT_URL", ""),
 			OAuthStateTTL: getenvDuration("GOOGLE_OAUTH_STATE_TTL", 10*time.Minute),
 		},
+		OAuth: OAuthConfig{
+			Apple: OAuthProviderConfig{
+				ClientID:     getenv("APPLE_CLIENT_ID", ""),
+				ClientSecret: getenv("APPLE_CLIENT_SECRET", ""),
+				RedirectURL:  getenv("APPLE_REDIRECT_URL", ""),
+			},
+			Facebook: OAuthProviderConfig{
+				ClientID:     getenv("FACEBOOK_APP_ID", ""),
+				ClientSecret: getenv("FACEBOOK_APP_SECRET", ""),
+				RedirectURL:  getenv("FACEBOOK_REDIRECT_URL", ""),
+			},
+			GitHub: OAuthProviderConfig{
+				ClientID:     getenv("GITHUB_CLIENT_ID", ""),
+				ClientSecret: getenv("GITHUB_CLIENT_SECRET", ""),
+				RedirectURL:  getenv("GITHUB_REDIRECT_URL", ""),
+			},
+			OIDCName: getenv("OIDC_PROVIDER_NAME", ""),
+			OIDC: OAuthProviderConfig{
+				ClientID:     getenv("OIDC_CLIENT_ID", ""),
+				ClientSecret: getenv("OIDC_CLIENT_SECRET", ""),
+				RedirectURL:  getenv("OIDC_REDIRECT_URL", ""),
+				Issuer:       getenv("OIDC_ISSUER", ""),
+			},
+		},
+		Captcha: CaptchaConfig{
+			Provider: strings.ToLower(getenv("CAPTCHA_PROVIDER", "")),
+			SiteKey:  getenv("CAPTCHA_SITE_KEY", ""),
+			Secret:   getenv("CAPTCHA_SECRET", ""),
+			MinScore: getenvFloat("CAPTCHA_MIN_SCORE", 0.5),
+		},
 		R2: R2Config{
 			AccountID:       getenvRequired("R2_ACCOUNT_ID"),
 			Bucket:          getenvRequired("R2_BUCKET"),
@@ -87,6 +169,36 @@ func getenvInt(key string, fallback int) int {
 	return fallback
 }
 
+func getenvFloat(key string, fallback float64) float64 {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+			return parsed
+		}
+	}
+
+	return fallback
+}
+
+func getenvBool(key string, fallback bool) bool {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+	}
+
+	return fallback
+}
+
+func getenvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(getenv(key, ""), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
 func getenvDuration(key string, fallback time.Duration) time.Duration {
 	if value, ok := os.LookupEnv(key); ok && value != "" {
 		if parsed, err := parseHumanDuration(value); err == nil {
@@ -97,6 +209,21 @@ func getenvDuration(key string, fallback time.Duration) time.Duration {
 	return fallback
 }
 
+// getenvTime parses an RFC 3339 timestamp. Unlike the other helpers it does
+// not fall back on a malformed value, since the settings it reads are
+// deadlines that must not be silently dropped.
+func getenvTime(key string) time.Time {
+	value := getenv(key, "")
+	if value == "" {
+		return time.Time{}
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		panic("invalid env " + key + ": " + err.Error())
+	}
+	return parsed
+}
+
 func parseHumanDuration(value string) (time.Duration, error) {
 	normalized := strings.TrimSpace(strings.ToLower(value))
 	if normalized == "" {
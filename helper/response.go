@@ -8,11 +8,12 @@ import (
 )
 
 type Response struct {
-	Status string      `json:"status"`
-	Code   int         `json:"code"`
-	Time   string      `json:"time"`
-	Data   interface{} `json:"data,omitempty"`
-	Error  string      `json:"error,omitempty"`
+	Status  string      `json:"status"`
+	Code    int         `json:"code"`
+	Time    string      `json:"time"`
+	Data    interface{} `json:"data,omitempty"`
+	Error   string      `json:"error,omitempty"`
+	Details interface{} `json:"details,omitempty"`
 }
 
 func RespondOK(c *gin.Context, data interface{}) {
@@ -32,3 +33,19 @@ func RespondError(c *gin.Context, status int, message string) {
 		Error:  message,
 	})
 }
+
+// RespondErrorDetails is RespondError with a machine readable payload.
+func RespondErrorDetails(c *gin.Context, status int, message string, details interface{}) {
+	c.JSON(status, Response{
+		Status:  "error",
+		Code:    status,
+		Time:    time.Now().UTC().Format(time.RFC3339),
+		Error:   message,
+		Details: details,
+	})
+}
+
+// RespondValidationError answers 400 with field level details.
+func RespondValidationError(c *gin.Context, message string, details interface{}) {
+	RespondErrorDetails(c, http.StatusBadRequest, message, details)
+}
@@ -0,0 +1,41 @@
+package app
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"wavefy-be/internal/repository"
+	"wavefy-be/internal/service"
+	"wavefy-be/internal/token"
+)
+
+const accountBlockSyncInterval = time.Minute
+
+// SyncAccountBlocks copies suspensions and bans from the database into
+// stores.Statuses, then keeps doing so every minute in the background until
+// ctx is done. Call it before serving so a restart never lifts a block.
+func SyncAccountBlocks(ctx context.Context, db *gorm.DB, stores *token.Stores) error {
+	userRepo := repository.NewUserRepository(db)
+	if _, err := service.SyncAccountBlocks(ctx, userRepo, stores.Statuses); err != nil {
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(accountBlockSyncInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if _, err := service.SyncAccountBlocks(ctx, userRepo, stores.Statuses); err != nil {
+				log.Printf("account blocks: %v", err)
+			}
+		}
+	}()
+	return nil
+}
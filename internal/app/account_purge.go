@@ -0,0 +1,49 @@
+package app
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"gorm.io/gorm"
+
+	"wavefy-be/config"
+	"wavefy-be/internal/repository"
+	"wavefy-be/internal/service"
+	"wavefy-be/internal/token"
+)
+
+// RunAccountPurge hard-deletes due accounts every cfg.PurgeInterval until ctx
+// is done; a zero interval disables it. Run it in its own goroutine.
+func RunAccountPurge(ctx context.Context, db *gorm.DB, stores *token.Stores, cfg config.AuthConfig, r2Client *s3.Client, r2Cfg config.R2Config) {
+	if cfg.PurgeInterval <= 0 {
+		return
+	}
+
+	purgeService := service.NewAccountPurgeService(
+		repository.NewUserRepository(db),
+		repository.NewTrackRepository(db),
+		service.NewUploadService(r2Client, r2Cfg),
+		stores.Refresh,
+		stores.Revocations,
+		stores.LoginAttempts,
+	)
+
+	ticker := time.NewTicker(cfg.PurgeInterval)
+	defer ticker.Stop()
+	for {
+		purged, err := purgeService.PurgeDue(ctx)
+		if err != nil {
+			log.Printf("account purge: %v", err)
+		} else if purged > 0 {
+			log.Printf("account purge: purged %d accounts", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package app
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"wavefy-be/internal/handler"
+	"wavefy-be/internal/middleware"
+	"wavefy-be/internal/service"
+)
+
+func registerAPIKeyRoutes(rg *gin.RouterGroup, apiKeyService service.APIKeyService) {
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
+
+	// Keys are managed from a signed-in session only, so a leaked key
+	// cannot mint more keys.
+	sessionOnly := middleware.RequireSession()
+
+	rg.GET("/api-keys", sessionOnly, apiKeyHandler.List)
+	rg.POST("/api-keys", sessionOnly, apiKeyHandler.Create)
+	rg.DELETE("/api-keys/:id", sessionOnly, apiKeyHandler.Revoke)
+}
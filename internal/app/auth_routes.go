@@ -1,38 +1,94 @@
 package app
 
 import (
-	"time"
-
 	"github.com/gin-gonic/gin"
-	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 
 	"wavefy-be/config"
+	"wavefy-be/internal/captcha"
 	"wavefy-be/internal/handler"
 	"wavefy-be/internal/mail"
 	"wavefy-be/internal/middleware"
+	"wavefy-be/internal/model"
+	"wavefy-be/internal/oauth"
+	"wavefy-be/internal/password"
 	"wavefy-be/internal/repository"
 	"wavefy-be/internal/service"
 	"wavefy-be/internal/token"
 )
 
-func registerAuthRoutes(rg *gin.RouterGroup, db *gorm.DB, redisClient *redis.Client, cfg config.AuthConfig, googleCfg config.GoogleOAuthConfig, mailer *mail.Service) {
+func registerAuthRoutes(rg *gin.RouterGroup, db *gorm.DB, stores *token.Stores, cfg config.AuthConfig, keys *token.KeyRing, policy *password.Policy, hasher password.Hasher, providers *oauth.Registry, captchaVerifier captcha.Verifier, mailer *mail.Service) error {
 	userRepo := repository.NewUserRepository(db)
 	roleRepo := repository.NewRoleRepository(db)
-	userService := service.NewUserService(userRepo, roleRepo)
-	refreshStore := token.NewRefreshTokenStore(redisClient, cfg.RefreshTokenSecret, cfg.RefreshTokenTTL)
-	resetStore := token.NewPasswordResetTokenStore(redisClient, cfg.PasswordResetSecret, cfg.PasswordResetTTL)
-	verifyStore := token.NewVerifyEmailTokenStore(redisClient, cfg.VerifyEmailSecret, cfg.VerifyEmailTTL)
-	loginStore := token.NewLoginAttemptStore(redisClient, 10*time.Minute, 15*time.Minute, 10)
-	authService := service.NewAuthService(userService, userRepo, roleRepo, refreshStore, resetStore, verifyStore, loginStore, mailer, cfg, googleCfg)
+	resetStore := token.NewSignedTokenStore(stores.Tokens, token.PurposePasswordReset, cfg.PasswordResetSecret, token.TokenPolicy{TTL: cfg.PasswordResetTTL, MaxUses: 1, MaxAttempts: 5})
+	verifyStore := token.NewSignedTokenStore(stores.Tokens, token.PurposeVerifyEmail, cfg.VerifyEmailSecret, token.TokenPolicy{TTL: cfg.VerifyEmailTTL, MaxUses: 1, MaxAttempts: 5})
+	magicStore := token.NewSignedTokenStore(stores.Tokens, token.PurposeMagicLink, cfg.MagicLinkSecret, token.TokenPolicy{TTL: cfg.MagicLinkTTL, MaxUses: 1, MaxAttempts: 5})
+	emailChangeStore := token.NewSignedTokenStore(stores.Tokens, token.PurposeEmailChange, cfg.VerifyEmailSecret, token.TokenPolicy{TTL: cfg.EmailChangeTTL, MaxUses: 1, MaxAttempts: 5})
+	emailRevertStore := token.NewSignedTokenStore(stores.Tokens, token.PurposeEmailRevert, cfg.VerifyEmailSecret, token.TokenPolicy{TTL: cfg.EmailRevertTTL, MaxUses: 1, MaxAttempts: 5})
+	deviceReportStore := token.NewSignedTokenStore(stores.Tokens, token.PurposeDeviceReport, cfg.PasswordResetSecret, token.TokenPolicy{TTL: cfg.DeviceReportTTL, MaxUses: 1, MaxAttempts: 5})
+	reauthStore := token.NewSignedTokenStore(stores.Tokens, token.PurposeReauth, cfg.PasswordResetSecret, token.TokenPolicy{TTL: cfg.ReauthTTL, MaxUses: 1, MaxAttempts: 5})
+	recoveryRepo := repository.NewRecoveryCodeRepository(db)
+	passkeyRepo := repository.NewWebAuthnCredentialRepository(db)
+	identityRepo := repository.NewIdentityRepository(db)
+	eventRepo := repository.NewAuthEventRepository(db)
+	deviceRepo := repository.NewKnownDeviceRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	grantRepo := repository.NewOAuthGrantRepository(db)
+	userService := service.NewUserService(userRepo, roleRepo, stores.Refresh, stores.Revocations, stores.Statuses, apiKeyRepo, grantRepo, policy, hasher, cfg.DeletionGrace)
+	authService, err := service.NewAuthService(userService, userRepo, roleRepo, stores.Refresh, resetStore, verifyStore, magicStore, emailChangeStore, emailRevertStore, deviceReportStore, reauthStore, stores.LoginAttempts, stores.ClientAttempts, stores.VerifyResend, stores.Revocations, stores.MFAChallenges, recoveryRepo, passkeyRepo, stores.WebAuthnSessions, identityRepo, providers, captchaVerifier, eventRepo, deviceRepo, apiKeyRepo, grantRepo, mailer, cfg, keys, policy, hasher)
+	if err != nil {
+		return err
+	}
 	authHandler := handler.NewAuthHandler(authService, cfg)
+	loginRateLimit := middleware.LoginRateLimit(stores.LoginRateLimit, cfg.LoginRateLimit)
 
 	rg.POST("/auth/register", authHandler.Register)
-	rg.POST("/auth/login", middleware.LoginRateLimit(redisClient), authHandler.Login)
-	rg.POST("/auth/google", middleware.LoginRateLimit(redisClient), authHandler.GoogleLogin)
+	rg.POST("/auth/login", loginRateLimit, authHandler.Login)
+	rg.POST("/auth/google", loginRateLimit, authHandler.GoogleLogin)
+	rg.GET("/auth/providers", authHandler.ListProviders)
+	rg.POST("/auth/oauth/:provider", loginRateLimit, authHandler.ProviderLogin)
 	rg.POST("/auth/refresh", authHandler.Refresh)
 	rg.POST("/auth/logout", authHandler.Logout)
 	rg.POST("/auth/forgot-password", authHandler.ForgotPassword)
 	rg.POST("/auth/reset-password", authHandler.ResetPassword)
 	rg.POST("/auth/verify-email", authHandler.VerifyEmail)
+	rg.POST("/auth/verify-email/resend", authHandler.ResendVerifyEmail)
+	rg.POST("/auth/email/confirm", authHandler.ConfirmEmailChange)
+	rg.POST("/auth/email/revert", authHandler.RevertEmailChange)
+	rg.POST("/auth/devices/report", authHandler.ReportDevice)
+	rg.POST("/auth/magic-link", loginRateLimit, authHandler.RequestMagicLink)
+	rg.POST("/auth/magic-link/consume", loginRateLimit, authHandler.ConsumeMagicLink)
+	rg.POST("/auth/mfa/verify", loginRateLimit, authHandler.VerifyMFA)
+	rg.POST("/auth/passkeys/login/begin", authHandler.BeginPasskeyLogin)
+	rg.POST("/auth/passkeys/login/finish", loginRateLimit, authHandler.FinishPasskeyLogin)
+
+	authed := rg.Group("")
+	authed.Use(
+		middleware.JWTAuth(cfg, keys, stores.Revocations, stores.Statuses, nil),
+		middleware.AuditImpersonation(service.NewImpersonationAuditor(eventRepo)),
+		middleware.RequireSession(),
+	)
+	authed.POST("/auth/logout-all", authHandler.LogoutAll)
+	authed.GET("/auth/sessions", authHandler.ListSessions)
+	authed.DELETE("/auth/sessions/:id", authHandler.RevokeSession)
+	authed.POST("/auth/mfa/totp/setup", authHandler.SetupTOTP)
+	authed.POST("/auth/mfa/totp/confirm", authHandler.ConfirmTOTP)
+	authed.POST("/auth/mfa/totp/disable", authHandler.DisableTOTP)
+	authed.POST("/auth/mfa/recovery-codes", authHandler.RegenerateRecoveryCodes)
+	authed.POST("/auth/passkeys/register/begin", authHandler.BeginPasskeyRegistration)
+	authed.POST("/auth/passkeys/register/finish", authHandler.FinishPasskeyRegistration)
+	authed.GET("/auth/passkeys", authHandler.ListPasskeys)
+	authed.DELETE("/auth/passkeys/:id", authHandler.DeletePasskey)
+	authed.GET("/auth/identities", authHandler.ListIdentities)
+	authed.POST("/auth/identities/:provider", authHandler.LinkIdentity)
+	authed.DELETE("/auth/identities/:id", authHandler.UnlinkIdentity)
+	authed.POST("/auth/password", authHandler.SetPassword)
+	authed.POST("/auth/reauth", authHandler.RequestReauthentication)
+	authed.POST("/auth/email", authHandler.RequestEmailChange)
+	authed.POST("/auth/account/deletion", authHandler.RequestAccountDeletion)
+	authed.DELETE("/auth/account/deletion", authHandler.CancelAccountDeletion)
+	authed.GET("/auth/activity", authHandler.ListActivity)
+	authed.GET("/auth/events", middleware.RequirePermission(model.PermissionAuditRead), authHandler.ListAuthEvents)
+	authed.POST("/auth/impersonate", middleware.RequirePermission(model.PermissionImpersonate), authHandler.Impersonate)
+	return nil
 }
@@ -0,0 +1,41 @@
+package app
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"wavefy-be/config"
+	"wavefy-be/internal/service"
+)
+
+const dataExportCleanupInterval = 10 * time.Minute
+
+// RunDataExportCleanup deletes data exports from R2 once their download link
+// has expired, every ten minutes until ctx is done. Run it in its own
+// goroutine.
+func RunDataExportCleanup(ctx context.Context, cfg config.AuthConfig, r2Client *s3.Client, r2Cfg config.R2Config) {
+	if r2Client == nil || r2Cfg.Bucket == "" {
+		return
+	}
+	uploadService := service.NewUploadService(r2Client, r2Cfg)
+
+	ticker := time.NewTicker(dataExportCleanupInterval)
+	defer ticker.Stop()
+	for {
+		deleted, err := service.DeleteExpiredExports(ctx, uploadService, cfg.ExportLinkTTL)
+		if err != nil {
+			log.Printf("data export cleanup: %v", err)
+		} else if deleted > 0 {
+			log.Printf("data export cleanup: deleted %d exports", deleted)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package app
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"wavefy-be/config"
+	"wavefy-be/internal/handler"
+	"wavefy-be/internal/mail"
+	"wavefy-be/internal/middleware"
+	"wavefy-be/internal/repository"
+	"wavefy-be/internal/service"
+	"wavefy-be/internal/token"
+)
+
+// registerDataExportRoutes lets a signed-in user request a copy of their data.
+func registerDataExportRoutes(protected *gin.RouterGroup, db *gorm.DB, stores *token.Stores, cfg config.AuthConfig, mailer *mail.Service, r2Client *s3.Client, r2Cfg config.R2Config) {
+	exportService := service.NewDataExportService(
+		repository.NewUserRepository(db),
+		repository.NewRoleRepository(db),
+		repository.NewTrackRepository(db),
+		repository.NewAuthEventRepository(db),
+		repository.NewIdentityRepository(db),
+		repository.NewWebAuthnCredentialRepository(db),
+		repository.NewKnownDeviceRepository(db),
+		repository.NewAPIKeyRepository(db),
+		repository.NewOAuthGrantRepository(db),
+		stores.Refresh,
+		stores.DataExports,
+		service.NewUploadService(r2Client, r2Cfg),
+		mailer,
+		cfg,
+	)
+	exportHandler := handler.NewDataExportHandler(exportService)
+
+	protected.POST("/auth/account/export", middleware.RequireSession(), exportHandler.RequestExport)
+}
@@ -6,43 +6,60 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
-	"github.com/redis/go-redis/v9"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 	"gorm.io/gorm"
 
 	"wavefy-be/config"
+	"wavefy-be/internal/captcha"
 	"wavefy-be/internal/handler"
 	"wavefy-be/internal/mail"
 	"wavefy-be/internal/middleware"
+	"wavefy-be/internal/oauth"
+	"wavefy-be/internal/password"
+	"wavefy-be/internal/repository"
+	"wavefy-be/internal/service"
+	"wavefy-be/internal/token"
 )
 
 // NewHTTP khởi tạo router.
-func NewHTTP(db *gorm.DB, redisClient *redis.Client, authCfg config.AuthConfig, googleCfg config.GoogleOAuthConfig, mailer *mail.Service, r2Client *s3.Client, r2Cfg config.R2Config) *gin.Engine {
+func NewHTTP(db *gorm.DB, stores *token.Stores, authCfg config.AuthConfig, keys *token.KeyRing, policy *password.Policy, hasher password.Hasher, providers *oauth.Registry, captchaVerifier captcha.Verifier, mailer *mail.Service, r2Client *s3.Client, r2Cfg config.R2Config) (*gin.Engine, error) {
 	r := gin.New()
 	r.Use(gin.Logger(), gin.Recovery())
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:3000", "http://127.0.0.1:3000"},
 		AllowMethods:     []string{"GET", "POST", "PATCH", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Device-Name", "X-Captcha-Token", "X-API-Key"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
-	r.Use(middleware.GlobalRateLimit(redisClient))
+	r.Use(middleware.GlobalRateLimit(stores.Requests))
 
 	h := handler.New(db)
 	api := r.Group("/api")
 	api.GET("/health", h.Health)
 	api.GET("/db/ping", h.DBPing)
-	registerAuthRoutes(api, db, redisClient, authCfg, googleCfg, mailer)
+	if err := registerAuthRoutes(api, db, stores, authCfg, keys, policy, hasher, providers, captchaVerifier, mailer); err != nil {
+		return nil, err
+	}
+
+	apiKeyService := service.NewAPIKeyService(repository.NewAPIKeyRepository(db), repository.NewUserRepository(db), repository.NewRoleRepository(db))
+	impersonationAuditor := service.NewImpersonationAuditor(repository.NewAuthEventRepository(db))
 
 	protected := api.Group("")
-	protected.Use(middleware.JWTAuth(authCfg))
-	registerUserRoutes(protected, db)
+	protected.Use(middleware.JWTAuth(authCfg, keys, stores.Revocations, stores.Statuses, apiKeyService), middleware.AuditImpersonation(impersonationAuditor))
+	registerUserRoutes(protected, db, stores, policy, hasher, authCfg.DeletionGrace)
 	registerTrackRoutes(protected, db, r2Client, r2Cfg)
+	registerRoleRoutes(protected, db, stores.Revocations)
+	registerAPIKeyRoutes(protected, apiKeyService)
+	registerOAuthRoutes(api, protected, db, stores, authCfg, keys)
+	registerDataExportRoutes(protected, db, stores, authCfg, mailer, r2Client, r2Cfg)
+
+	jwksHandler := handler.NewJWKSHandler(keys)
+	r.GET("/.well-known/jwks.json", jwksHandler.JWKS)
 
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	return r
+	return r, nil
 }
@@ -0,0 +1,52 @@
+package app
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"wavefy-be/config"
+	"wavefy-be/internal/handler"
+	"wavefy-be/internal/middleware"
+	"wavefy-be/internal/model"
+	"wavefy-be/internal/repository"
+	"wavefy-be/internal/service"
+	"wavefy-be/internal/token"
+)
+
+// registerOAuthRoutes serves Wavefy as an OAuth2 authorization server. The
+// token, revocation and introspection endpoints authenticate the partner app
+// and sit on rg; consent and client management need a signed-in session.
+func registerOAuthRoutes(rg, protected *gin.RouterGroup, db *gorm.DB, stores *token.Stores, cfg config.AuthConfig, keys *token.KeyRing) {
+	// Codes are kept until they expire rather than deleted on use, so the
+	// token endpoint can tell a replayed code from an unknown one.
+	codeStore := token.NewSignedTokenStore(stores.Tokens, token.PurposeOAuthCode, cfg.RefreshTokenSecret, token.TokenPolicy{TTL: cfg.OAuthCodeTTL, MaxAttempts: 5})
+	refreshStore := token.NewSignedTokenStore(stores.Tokens, token.PurposeOAuthRefresh, cfg.RefreshTokenSecret, token.TokenPolicy{TTL: cfg.OAuthRefreshTTL, MaxUses: 1, MaxAttempts: 5})
+
+	oauthService := service.NewOAuthService(
+		repository.NewOAuthClientRepository(db),
+		repository.NewOAuthGrantRepository(db),
+		repository.NewUserRepository(db),
+		repository.NewRoleRepository(db),
+		codeStore,
+		refreshStore,
+		stores.Revocations,
+		cfg,
+		keys,
+	)
+	oauthHandler := handler.NewOAuthHandler(oauthService)
+
+	rg.POST("/oauth/token", oauthHandler.Token)
+	rg.POST("/oauth/revoke", oauthHandler.Revoke)
+	rg.POST("/oauth/introspect", oauthHandler.Introspect)
+
+	sessionOnly := middleware.RequireSession()
+	canManage := middleware.RequirePermission(model.PermissionOAuthManage)
+
+	protected.GET("/oauth/authorize", sessionOnly, oauthHandler.Authorize)
+	protected.POST("/oauth/authorize", sessionOnly, oauthHandler.Decide)
+	protected.GET("/oauth/grants", sessionOnly, oauthHandler.ListGrants)
+	protected.DELETE("/oauth/grants/:client_id", sessionOnly, oauthHandler.RevokeGrant)
+	protected.GET("/oauth/clients", sessionOnly, canManage, oauthHandler.ListClients)
+	protected.POST("/oauth/clients", sessionOnly, canManage, oauthHandler.CreateClient)
+	protected.DELETE("/oauth/clients/:id", sessionOnly, canManage, oauthHandler.DeleteClient)
+}
@@ -0,0 +1,29 @@
+package app
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"wavefy-be/internal/handler"
+	"wavefy-be/internal/middleware"
+	"wavefy-be/internal/model"
+	"wavefy-be/internal/repository"
+	"wavefy-be/internal/service"
+	"wavefy-be/internal/token"
+)
+
+func registerRoleRoutes(rg *gin.RouterGroup, db *gorm.DB, revocations token.AccessTokenRevocationStore) {
+	roleRepo := repository.NewRoleRepository(db)
+	permissionRepo := repository.NewPermissionRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	roleService := service.NewRoleService(roleRepo, permissionRepo, userRepo, revocations)
+	roleHandler := handler.NewRoleHandler(roleService)
+
+	canManage := middleware.RequirePermission(model.PermissionRolesManage)
+
+	rg.GET("/roles", canManage, roleHandler.List)
+	rg.POST("/roles", canManage, roleHandler.Create)
+	rg.PUT("/roles/:id/permissions", canManage, roleHandler.SetPermissions)
+	rg.GET("/permissions", canManage, roleHandler.ListPermissions)
+	rg.PUT("/users/:id/role", canManage, roleHandler.AssignRole)
+}
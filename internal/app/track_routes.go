@@ -7,6 +7,8 @@ import (
 
 	"wavefy-be/config"
 	"wavefy-be/internal/handler"
+	"wavefy-be/internal/middleware"
+	"wavefy-be/internal/model"
 	"wavefy-be/internal/repository"
 	"wavefy-be/internal/service"
 )
@@ -18,17 +20,24 @@ func registerTrackRoutes(rg *gin.RouterGroup, db *gorm.DB, r2Client *s3.Client,
 	uploadService := service.NewUploadService(r2Client, r2Cfg)
 	trackHandler := handler.NewTrackHandler(trackService, uploadService)
 
-	rg.GET("/tracks", trackHandler.List)
-	rg.POST("/tracks", trackHandler.Create)
-	rg.GET("/tracks/:id", trackHandler.Get)
-	rg.PATCH("/tracks/:id", trackHandler.Update)
-	rg.DELETE("/tracks/:id", trackHandler.Delete)
+	canWrite := middleware.RequirePermission(model.PermissionTracksWrite)
+	// Partner apps acting through OAuth and API keys are further limited to
+	// their scopes, so a key without tracks:write cannot edit or delete the
+	// owner's tracks either.
+	readScope := middleware.RequireScope(model.OAuthScopeTracksRead)
+	writeScope := middleware.RequireScope(model.OAuthScopeTracksWrite)
 
-	rg.POST("/tracks/audio/presign", trackHandler.PresignPut)
-	rg.POST("/tracks/audio/presign-get", trackHandler.PresignGet)
-	rg.POST("/tracks/audio/delete", trackHandler.DeleteObject)
+	rg.GET("/tracks", readScope, trackHandler.List)
+	rg.POST("/tracks", writeScope, canWrite, trackHandler.Create)
+	rg.GET("/tracks/:id", readScope, trackHandler.Get)
+	rg.PATCH("/tracks/:id", writeScope, trackHandler.Update)
+	rg.DELETE("/tracks/:id", writeScope, trackHandler.Delete)
 
-	rg.POST("/tracks/image/presign", trackHandler.PresignImagePut)
-	rg.POST("/tracks/image/presign-get", trackHandler.PresignImageGet)
-	rg.POST("/tracks/image/delete", trackHandler.DeleteImageObject)
+	rg.POST("/tracks/audio/presign", writeScope, canWrite, trackHandler.PresignPut)
+	rg.POST("/tracks/audio/presign-get", readScope, trackHandler.PresignGet)
+	rg.POST("/tracks/audio/delete", writeScope, trackHandler.DeleteObject)
+
+	rg.POST("/tracks/image/presign", writeScope, canWrite, trackHandler.PresignImagePut)
+	rg.POST("/tracks/image/presign-get", readScope, trackHandler.PresignImageGet)
+	rg.POST("/tracks/image/delete", writeScope, trackHandler.DeleteImageObject)
 }
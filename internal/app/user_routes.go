@@ -1,23 +1,38 @@
 package app
 
 import (
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
 	"wavefy-be/internal/handler"
+	"wavefy-be/internal/middleware"
+	"wavefy-be/internal/model"
+	"wavefy-be/internal/password"
 	"wavefy-be/internal/repository"
 	"wavefy-be/internal/service"
+	"wavefy-be/internal/token"
 )
 
-func registerUserRoutes(rg *gin.RouterGroup, db *gorm.DB) {
+func registerUserRoutes(rg *gin.RouterGroup, db *gorm.DB, stores *token.Stores, policy *password.Policy, hasher password.Hasher, deletionGrace time.Duration) {
 	userRepo := repository.NewUserRepository(db)
 	roleRepo := repository.NewRoleRepository(db)
-	userService := service.NewUserService(userRepo, roleRepo)
+	userService := service.NewUserService(userRepo, roleRepo, stores.Refresh, stores.Revocations, stores.Statuses, repository.NewAPIKeyRepository(db), repository.NewOAuthGrantRepository(db), policy, hasher, deletionGrace)
 	userHandler := handler.NewUserHandler(userService)
 
-	rg.GET("/users", userHandler.List)
-	rg.POST("/users", userHandler.Create)
-	rg.GET("/users/:id", userHandler.Get)
-	rg.PATCH("/users/:id", userHandler.Update)
-	rg.DELETE("/users/:id", userHandler.Delete)
+	canRead := middleware.RequirePermission(model.PermissionUsersRead)
+	canManage := middleware.RequirePermission(model.PermissionUsersManage)
+	// Partner apps hold no users:* permission, so the only user route open
+	// to them is reading a profile with the profile:read scope. API keys
+	// need the scope as well.
+	profileScope := middleware.RequireScope(model.OAuthScopeProfileRead)
+
+	rg.GET("/users", canRead, userHandler.List)
+	rg.POST("/users", canManage, userHandler.Create)
+	rg.GET("/users/:id", profileScope, userHandler.Get)
+	rg.PATCH("/users/:id", middleware.RequireSession(), userHandler.Update)
+	rg.DELETE("/users/:id", middleware.DenyImpersonation(), canManage, userHandler.Delete)
+	rg.POST("/users/:id/suspension", canManage, userHandler.Suspend)
+	rg.DELETE("/users/:id/suspension", canManage, userHandler.Reinstate)
 }
@@ -0,0 +1,36 @@
+package captcha
+
+import (
+	"context"
+	"crypto/subtle"
+)
+
+// FakeVerifier accepts a single fixed token. It stands in for a real
+// provider in local development and tests; never configure it in
+// production.
+type FakeVerifier struct {
+	token string
+}
+
+// NewFakeVerifier accepts token, or "pass" when token is empty.
+func NewFakeVerifier(token string) *FakeVerifier {
+	if token == "" {
+		token = "pass"
+	}
+	return &FakeVerifier{token: token}
+}
+
+func (v *FakeVerifier) Provider() string {
+	return ProviderFake
+}
+
+func (v *FakeVerifier) SiteKey() string {
+	return ""
+}
+
+func (v *FakeVerifier) Verify(ctx context.Context, token, remoteIP string) error {
+	if subtle.ConstantTimeCompare([]byte(token), []byte(v.token)) != 1 {
+		return ErrInvalidToken
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package captcha
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"wavefy-be/config"
+)
+
+const (
+	ProviderTurnstile = "turnstile"
+	ProviderHCaptcha  = "hcaptcha"
+	ProviderReCAPTCHA = "recaptcha"
+	ProviderFake      = "fake"
+)
+
+var ErrInvalidToken = errors.New("invalid captcha token")
+
+// Verifier checks a token the client got from solving a challenge. It
+// returns ErrInvalidToken when the provider rejects it and another error
+// when the provider could not be asked.
+type Verifier interface {
+	Provider() string
+	SiteKey() string
+	Verify(ctx context.Context, token, remoteIP string) error
+}
+
+// NewVerifierFromConfig returns the configured verifier, or nil when no
+// provider is set.
+func NewVerifierFromConfig(cfg config.CaptchaConfig) (Verifier, error) {
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case ProviderFake:
+		return NewFakeVerifier(cfg.Secret), nil
+	}
+	if cfg.Secret == "" {
+		return nil, fmt.Errorf("captcha: CAPTCHA_SECRET is required for %s", cfg.Provider)
+	}
+	switch cfg.Provider {
+	case ProviderTurnstile:
+		return NewTurnstileVerifier(cfg.SiteKey, cfg.Secret), nil
+	case ProviderHCaptcha:
+		return NewHCaptchaVerifier(cfg.SiteKey, cfg.Secret), nil
+	case ProviderReCAPTCHA:
+		return NewReCAPTCHAVerifier(cfg.SiteKey, cfg.Secret, cfg.MinScore), nil
+	default:
+		return nil, fmt.Errorf("captcha: unknown provider %q", cfg.Provider)
+	}
+}
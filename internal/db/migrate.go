@@ -10,15 +10,29 @@ import (
 )
 
 func Migrate(db *gorm.DB) error {
-	if err := db.AutoMigrate(&model.Role{}, &model.User{}, &model.Track{}); err != nil {
+	if err := db.AutoMigrate(&model.Permission{}, &model.Role{}, &model.User{}, &model.Track{}, &model.RecoveryCode{}, &model.WebAuthnCredential{}, &model.Identity{}, &model.AuthEvent{}, &model.KnownDevice{}, &model.APIKey{}, &model.OAuthClient{}, &model.OAuthGrant{}); err != nil {
+		return err
+	}
+	if err := backfillUserStatus(db); err != nil {
 		return err
 	}
 	if err := seedRoles(db); err != nil {
 		return err
 	}
+	if err := seedPermissions(db); err != nil {
+		return err
+	}
 	return nil
 }
 
+// backfillUserStatus marks verified accounts created before the status column
+// existed as active; the column default left them pending verification.
+func backfillUserStatus(db *gorm.DB) error {
+	return db.Model(&model.User{}).
+		Where("is_active = ? AND status = ?", true, model.UserStatusPending).
+		Update("status", model.UserStatusActive).Error
+}
+
 func seedRoles(db *gorm.DB) error {
 	roles := []model.Role{
 		{
@@ -49,3 +63,67 @@ func seedRoles(db *gorm.DB) error {
 
 	return nil
 }
+
+var defaultPermissions = []model.Permission{
+	{Name: model.PermissionUsersRead, Description: "Read any user profile"},
+	{Name: model.PermissionUsersManage, Description: "Create, update and delete users and assign roles"},
+	{Name: model.PermissionRolesManage, Description: "Create roles and grant permissions"},
+	{Name: model.PermissionTracksWrite, Description: "Upload and publish own tracks"},
+	{Name: model.PermissionTracksManage, Description: "Modify and delete any track"},
+	{Name: model.PermissionAuditRead, Description: "Read the authentication audit log"},
+	{Name: model.PermissionOAuthManage, Description: "Register and remove OAuth partner apps"},
+	{Name: model.PermissionImpersonate, Description: "Act as another user for support"},
+}
+
+// defaultRolePermissions is granted to a built-in role while it has no
+// permissions, so grants changed through the API are not overwritten. ADMIN
+// always holds every permission.
+var defaultRolePermissions = map[string][]string{
+	"USER": {model.PermissionTracksWrite},
+}
+
+func seedPermissions(db *gorm.DB) error {
+	all := make([]model.Permission, 0, len(defaultPermissions))
+	for _, permission := range defaultPermissions {
+		var existing model.Permission
+		err := db.Where("name = ?", permission.Name).First(&existing).Error
+		if err == nil {
+			all = append(all, existing)
+			continue
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		permission.ID = uuid.New()
+		if err := db.Create(&permission).Error; err != nil {
+			return err
+		}
+		all = append(all, permission)
+	}
+
+	var admin model.Role
+	if err := db.Where("name = ?", "ADMIN").First(&admin).Error; err != nil {
+		return err
+	}
+	if err := db.Model(&admin).Association("Permissions").Append(all); err != nil {
+		return err
+	}
+
+	for roleName, names := range defaultRolePermissions {
+		var role model.Role
+		if err := db.Where("name = ?", roleName).First(&role).Error; err != nil {
+			return err
+		}
+		if db.Model(&role).Association("Permissions").Count() > 0 {
+			continue
+		}
+		var grants []model.Permission
+		if err := db.Where("name IN ?", names).Find(&grants).Error; err != nil {
+			return err
+		}
+		if err := db.Model(&role).Association("Permissions").Append(grants); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,5 +1,7 @@
 package dto
 
+import "encoding/json"
+
 type RegisterRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
@@ -19,6 +21,43 @@ type ForgotPasswordRequest struct {
 	Email string `json:"email" binding:"required,email"`
 }
 
+type MagicLinkRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type MagicLinkConsumeRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ResendVerifyEmailRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// EmailNotVerifiedResponse is the error detail of a login on an account whose
+// email is not verified yet.
+type EmailNotVerifiedResponse struct {
+	Code       string `json:"code"`
+	Email      string `json:"email"`
+	ResendPath string `json:"resend_path"`
+}
+
+// AccountStatusResponse is the error detail of a sign-in, refresh or request
+// of a suspended or banned account.
+type AccountStatusResponse struct {
+	Code   string `json:"code"`
+	Reason string `json:"reason"`
+	Until  string `json:"until,omitempty"`
+}
+
+// CaptchaRequiredResponse is the error detail of a request that needs a
+// solved CAPTCHA, sent back in the Header it names.
+type CaptchaRequiredResponse struct {
+	Code     string `json:"code"`
+	Provider string `json:"provider"`
+	SiteKey  string `json:"site_key"`
+	Header   string `json:"header"`
+}
+
 type ResetPasswordRequest struct {
 	Token    string `json:"token" binding:"required"`
 	Password string `json:"password" binding:"required"`
@@ -38,3 +77,122 @@ type AuthResponse struct {
 	ExpiresAt   string       `json:"expires_at"`
 	User        UserResponse `json:"user"`
 }
+
+type SessionResponse struct {
+	ID         string `json:"id"`
+	DeviceName string `json:"device_name"`
+	UserAgent  string `json:"user_agent"`
+	IP         string `json:"ip"`
+	CreatedAt  string `json:"created_at"`
+	LastUsedAt string `json:"last_used_at"`
+}
+
+type MFAVerifyRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type MFAChallengeResponse struct {
+	MFARequired    bool     `json:"mfa_required"`
+	ChallengeToken string   `json:"challenge_token"`
+	ExpiresAt      string   `json:"expires_at"`
+	Methods        []string `json:"methods"`
+}
+
+type TOTPSetupResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type PasskeyFinishRequest struct {
+	SessionID  string          `json:"session_id" binding:"required"`
+	Name       string          `json:"name"`
+	Credential json.RawMessage `json:"credential" binding:"required"`
+}
+
+type PasskeyCeremonyResponse struct {
+	SessionID string      `json:"session_id"`
+	ExpiresAt string      `json:"expires_at"`
+	Options   interface{} `json:"options"`
+}
+
+type PasskeyResponse struct {
+	ID         string  `json:"id"`
+	Name       string  `json:"name"`
+	CreatedAt  string  `json:"created_at"`
+	LastUsedAt *string `json:"last_used_at"`
+}
+
+type OAuthLoginRequest struct {
+	IDToken      string `json:"id_token"`
+	AccessToken  string `json:"access_token"`
+	Code         string `json:"code"`
+	RedirectURI  string `json:"redirect_uri"`
+	CodeVerifier string `json:"code_verifier"`
+	Nonce        string `json:"nonce"`
+}
+
+type IdentityResponse struct {
+	ID        string `json:"id"`
+	Provider  string `json:"provider"`
+	Email     string `json:"email"`
+	CreatedAt string `json:"created_at"`
+}
+
+// SetPasswordRequest proves the user is present like EmailChangeRequest.
+type SetPasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required_without=ReauthToken"`
+	ReauthToken     string `json:"reauth_token"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// EmailChangeRequest proves the user is present with the current password
+// or, for accounts without one, a token from POST /auth/reauth.
+type EmailChangeRequest struct {
+	NewEmail        string `json:"new_email" binding:"required,email"`
+	CurrentPassword string `json:"current_password" binding:"required_without=ReauthToken"`
+	ReauthToken     string `json:"reauth_token"`
+}
+
+// AccountDeletionRequest proves the user is present like EmailChangeRequest.
+type AccountDeletionRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required_without=ReauthToken"`
+	ReauthToken     string `json:"reauth_token"`
+}
+
+type EmailChangeTokenRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type AuthEventResponse struct {
+	ID        string  `json:"id"`
+	UserID    *string `json:"user_id"`
+	ActorID   *string `json:"actor_id,omitempty"`
+	Email     string  `json:"email"`
+	Type      string  `json:"type"`
+	Method    string  `json:"method,omitempty"`
+	Outcome   string  `json:"outcome"`
+	Reason    string  `json:"reason,omitempty"`
+	IP        string  `json:"ip"`
+	UserAgent string  `json:"user_agent"`
+	CreatedAt string  `json:"created_at"`
+}
+
+// ImpersonateRequest names the user support staff want to act as and why;
+// the reason is kept in the audit log.
+type ImpersonateRequest struct {
+	UserID string `json:"user_id" binding:"required,uuid"`
+	Reason string `json:"reason" binding:"required"`
+}
+
+type DeviceReportRequest struct {
+	Token string `json:"token" binding:"required"`
+}
@@ -0,0 +1,93 @@
+package dto
+
+type CreateOAuthClientRequest struct {
+	Name         string   `json:"name" binding:"required"`
+	RedirectURIs []string `json:"redirect_uris" binding:"required"`
+	Scopes       []string `json:"scopes" binding:"required"`
+	Confidential bool     `json:"confidential"`
+}
+
+type OAuthClientResponse struct {
+	ID           string   `json:"id"`
+	ClientID     string   `json:"client_id"`
+	Name         string   `json:"name"`
+	RedirectURIs []string `json:"redirect_uris"`
+	Scopes       []string `json:"scopes"`
+	Confidential bool     `json:"confidential"`
+	CreatedAt    string   `json:"created_at"`
+}
+
+// CreatedOAuthClientResponse carries the client secret of a confidential
+// client, which is only shown once.
+type CreatedOAuthClientResponse struct {
+	OAuthClientResponse
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+// OAuthAuthorizeRequest holds the authorization request parameters, read from
+// the query string for the consent screen and from the body for the decision.
+type OAuthAuthorizeRequest struct {
+	ResponseType        string `form:"response_type" json:"response_type"`
+	ClientID            string `form:"client_id" json:"client_id"`
+	RedirectURI         string `form:"redirect_uri" json:"redirect_uri"`
+	Scope               string `form:"scope" json:"scope"`
+	State               string `form:"state" json:"state"`
+	CodeChallenge       string `form:"code_challenge" json:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method" json:"code_challenge_method"`
+}
+
+type OAuthDecisionRequest struct {
+	OAuthAuthorizeRequest
+	Approve bool `json:"approve"`
+}
+
+type OAuthConsentClient struct {
+	ClientID string `json:"client_id"`
+	Name     string `json:"name"`
+}
+
+// OAuthConsentResponse is shown on the consent screen. Consented means the
+// user already granted these scopes and the screen may approve right away.
+type OAuthConsentResponse struct {
+	Client      OAuthConsentClient `json:"client"`
+	Scopes      []string           `json:"scopes"`
+	RedirectURI string             `json:"redirect_uri"`
+	Consented   bool               `json:"consented"`
+}
+
+type OAuthRedirectResponse struct {
+	RedirectURL string `json:"redirect_url"`
+}
+
+// OAuthTokenResponse is the RFC 6749 token response.
+type OAuthTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	Scope        string `json:"scope"`
+}
+
+// OAuthErrorResponse is the RFC 6749 error response.
+type OAuthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// OAuthIntrospectionResponse is the RFC 7662 introspection response.
+type OAuthIntrospectionResponse struct {
+	Active    bool   `json:"active"`
+	TokenType string `json:"token_type,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+	Scope     string `json:"scope,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+}
+
+type OAuthGrantResponse struct {
+	Client    OAuthConsentClient `json:"client"`
+	Scopes    []string           `json:"scopes"`
+	CreatedAt string             `json:"created_at"`
+	UpdatedAt string             `json:"updated_at"`
+}
@@ -0,0 +1,29 @@
+package dto
+
+type CreateRoleRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type SetRolePermissionsRequest struct {
+	Permissions []string `json:"permissions"`
+}
+
+type AssignRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+type PermissionResponse struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type RoleResponse struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+	CreatedAt   string   `json:"created_at"`
+	UpdatedAt   string   `json:"updated_at"`
+}
@@ -1,7 +1,7 @@
 package dto
 
 type CreateTrackRequest struct {
-	ArtistUserID string  `json:"artist_user_id" binding:"required"`
+	ArtistUserID string  `json:"artist_user_id"`
 	AlbumID      *string `json:"album_id"`
 	Title        string  `json:"title" binding:"required"`
 	AudioURL     string  `json:"audio_url" binding:"required"`
@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 type CreateUserRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
@@ -12,13 +14,26 @@ type UpdateUserRequest struct {
 	Password  *string `json:"password"`
 }
 
+// SuspendUserRequest suspends a user until Until, or until reinstated when
+// it is empty. Ban bans the user instead and ignores Until.
+type SuspendUserRequest struct {
+	Reason string     `json:"reason" binding:"required"`
+	Until  *time.Time `json:"until"`
+	Ban    bool       `json:"ban"`
+}
+
 type UserResponse struct {
-	ID        string `json:"id"`
-	FirstName string `json:"first_name"`
-	LastName  string `json:"last_name"`
-	Email     string `json:"email"`
-	Role      string `json:"role"`
-	IsActive  bool   `json:"is_active"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
+	ID         string `json:"id"`
+	FirstName  string `json:"first_name"`
+	LastName   string `json:"last_name"`
+	Email      string `json:"email"`
+	Role       string `json:"role"`
+	IsActive   bool   `json:"is_active"`
+	Status     string `json:"status"`
+	Reason     string `json:"status_reason,omitempty"`
+	Until      string `json:"suspended_until,omitempty"`
+	MFAEnabled bool   `json:"mfa_enabled"`
+	CreatedAt  string `json:"created_at"`
+	UpdatedAt  string `json:"updated_at"`
+	PurgeAt    string `json:"purge_at,omitempty"`
 }
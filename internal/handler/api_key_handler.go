@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"wavefy-be/helper"
+	"wavefy-be/internal/dto"
+	"wavefy-be/internal/model"
+	"wavefy-be/internal/service"
+)
+
+type APIKeyHandler struct {
+	service service.APIKeyService
+}
+
+func NewAPIKeyHandler(service service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{service: service}
+}
+
+// Create godoc
+// @Summary      Create API key
+// @Description  Create an API key for scripts, limited to scopes: permissions the caller's role grants, or the read scopes profile:read and tracks:read. Uploads need tracks:write. The key is only returned once; send it as X-API-Key or as a bearer token.
+// @Tags         api-keys
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.CreateAPIKeyRequest true "API key"
+// @Success      200 {object} helper.Response{data=dto.CreatedAPIKeyResponse}
+// @Failure      400 {object} helper.Response
+// @Failure      401 {object} helper.Response
+// @Failure      403 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /api-keys [post]
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	userID, err := authSubject(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	var req dto.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.RespondError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	key, plain, err := h.service.Create(c.Request.Context(), userID, service.CreateAPIKeyInput{
+		Name:      req.Name,
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		switch err {
+		case service.ErrInvalidInput:
+			helper.RespondError(c, http.StatusBadRequest, err.Error())
+		case service.ErrScopeNotGranted:
+			helper.RespondError(c, http.StatusForbidden, err.Error())
+		case service.ErrNotFound:
+			helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		default:
+			helper.RespondError(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	helper.RespondOK(c, dto.CreatedAPIKeyResponse{
+		APIKeyResponse: mapAPIKeyResponse(key),
+		Key:            plain,
+	})
+}
+
+// List godoc
+// @Summary      List API keys
+// @Tags         api-keys
+// @Produce      json
+// @Success      200 {object} helper.Response{data=[]dto.APIKeyResponse}
+// @Failure      401 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /api-keys [get]
+func (h *APIKeyHandler) List(c *gin.Context) {
+	userID, err := authSubject(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	keys, err := h.service.List(c.Request.Context(), userID)
+	if err != nil {
+		helper.RespondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := make([]dto.APIKeyResponse, 0, len(keys))
+	for i := range keys {
+		resp = append(resp, mapAPIKeyResponse(&keys[i]))
+	}
+	helper.RespondOK(c, resp)
+}
+
+// Revoke godoc
+// @Summary      Revoke API key
+// @Tags         api-keys
+// @Produce      json
+// @Param        id path string true "API key ID"
+// @Success      200 {object} helper.Response
+// @Failure      400 {object} helper.Response
+// @Failure      401 {object} helper.Response
+// @Failure      404 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /api-keys/{id} [delete]
+func (h *APIKeyHandler) Revoke(c *gin.Context) {
+	userID, err := authSubject(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+	id, err := parseUUIDParam(c, "id")
+	if err != nil {
+		helper.RespondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.service.Revoke(c.Request.Context(), userID, id); err != nil {
+		switch err {
+		case service.ErrNotFound:
+			helper.RespondError(c, http.StatusNotFound, err.Error())
+		default:
+			helper.RespondError(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	helper.RespondOK(c, gin.H{"revoked": true})
+}
+
+func mapAPIKeyResponse(key *model.APIKey) dto.APIKeyResponse {
+	resp := dto.APIKeyResponse{
+		ID:        key.ID.String(),
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		Scopes:    key.Scopes,
+		CreatedAt: key.CreatedAt.Format(time.RFC3339),
+	}
+	if resp.Scopes == nil {
+		resp.Scopes = []string{}
+	}
+	if key.ExpiresAt != nil {
+		expiresAt := key.ExpiresAt.Format(time.RFC3339)
+		resp.ExpiresAt = &expiresAt
+	}
+	if key.LastUsedAt != nil {
+		lastUsed := key.LastUsedAt.Format(time.RFC3339)
+		resp.LastUsedAt = &lastUsed
+	}
+	return resp
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"wavefy-be/helper"
+	"wavefy-be/internal/dto"
+	"wavefy-be/internal/service"
+)
+
+// RequestAccountDeletion godoc
+// @Summary      Request account deletion
+// @Description  Schedule the account, its tracks and uploads to be deleted for good after the grace period; it can be cancelled until then
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.AccountDeletionRequest true "Current password or reauthentication token"
+// @Success      200 {object} helper.Response{data=dto.UserResponse}
+// @Failure      400 {object} helper.Response
+// @Failure      401 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /auth/account/deletion [post]
+func (h *AuthHandler) RequestAccountDeletion(c *gin.Context) {
+	userID, err := authSubject(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	var req dto.AccountDeletionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.RespondError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	proof := service.Reauth{CurrentPassword: req.CurrentPassword, Token: req.ReauthToken}
+	user, err := h.service.RequestAccountDeletion(c.Request.Context(), userID, proof)
+	if err != nil {
+		respondAccountDeletionError(c, err)
+		return
+	}
+
+	helper.RespondOK(c, mapUserResponse(user))
+}
+
+// CancelAccountDeletion godoc
+// @Summary      Cancel account deletion
+// @Description  Keep the account while its deletion grace period is running
+// @Tags         auth
+// @Produce      json
+// @Success      200 {object} helper.Response{data=dto.UserResponse}
+// @Failure      401 {object} helper.Response
+// @Failure      409 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /auth/account/deletion [delete]
+func (h *AuthHandler) CancelAccountDeletion(c *gin.Context) {
+	userID, err := authSubject(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	user, err := h.service.CancelAccountDeletion(c.Request.Context(), userID)
+	if err != nil {
+		respondAccountDeletionError(c, err)
+		return
+	}
+
+	helper.RespondOK(c, mapUserResponse(user))
+}
+
+func respondAccountDeletionError(c *gin.Context, err error) {
+	switch err {
+	case service.ErrInvalidCredentials, service.ErrNotFound, service.ErrInvalidReauthToken:
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+	case service.ErrDeletionNotScheduled:
+		helper.RespondError(c, http.StatusConflict, err.Error())
+	default:
+		helper.RespondError(c, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"wavefy-be/helper"
+	"wavefy-be/internal/dto"
+	"wavefy-be/internal/service"
+)
+
+// ReportDevice godoc
+// @Summary      Report an unrecognised sign-in
+// @Description  Handle the "this wasn't me" link of a new device alert: sign out every session, remove the password, passkeys, linked accounts, API keys and app grants, and mail a reset link listing them
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.DeviceReportRequest true "Report token"
+// @Success      200 {object} helper.Response
+// @Failure      400 {object} helper.Response
+// @Failure      401 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /auth/devices/report [post]
+func (h *AuthHandler) ReportDevice(c *gin.Context) {
+	var req dto.DeviceReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.RespondError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := h.service.ReportDevice(c.Request.Context(), req.Token, clientInfo(c)); err != nil {
+		switch err {
+		case service.ErrInvalidDeviceReport:
+			helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		default:
+			helper.RespondError(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	h.clearRefreshCookie(c)
+	helper.RespondOK(c, gin.H{"reported": true})
+}
@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"wavefy-be/helper"
+	"wavefy-be/internal/dto"
+	"wavefy-be/internal/service"
+)
+
+// RequestReauthentication godoc
+// @Summary      Request reauthentication link
+// @Description  Mail a single-use link whose token can be sent instead of the current password to set a password, change the email or delete the account
+// @Tags         auth
+// @Produce      json
+// @Success      200 {object} helper.Response
+// @Failure      401 {object} helper.Response
+// @Failure      503 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /auth/reauth [post]
+func (h *AuthHandler) RequestReauthentication(c *gin.Context) {
+	userID, err := authSubject(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	if err := h.service.RequestReauthentication(c.Request.Context(), userID); err != nil {
+		respondEmailChangeError(c, err)
+		return
+	}
+
+	helper.RespondOK(c, gin.H{"sent": true})
+}
+
+// RequestEmailChange godoc
+// @Summary      Request email change
+// @Description  Send a confirmation link to the new address; the email changes once it is confirmed
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.EmailChangeRequest true "New email and current password or reauthentication token"
+// @Success      200 {object} helper.Response
+// @Failure      400 {object} helper.Response
+// @Failure      401 {object} helper.Response
+// @Failure      409 {object} helper.Response
+// @Failure      503 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /auth/email [post]
+func (h *AuthHandler) RequestEmailChange(c *gin.Context) {
+	userID, err := authSubject(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	var req dto.EmailChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.RespondError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	proof := service.Reauth{CurrentPassword: req.CurrentPassword, Token: req.ReauthToken}
+	if err := h.service.RequestEmailChange(c.Request.Context(), userID, proof, req.NewEmail); err != nil {
+		respondEmailChangeError(c, err)
+		return
+	}
+
+	helper.RespondOK(c, gin.H{"sent": true})
+}
+
+// ConfirmEmailChange godoc
+// @Summary      Confirm email change
+// @Description  Apply an email change from the link sent to the new address
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.EmailChangeTokenRequest true "Confirmation token"
+// @Success      200 {object} helper.Response{data=dto.UserResponse}
+// @Failure      400 {object} helper.Response
+// @Failure      401 {object} helper.Response
+// @Failure      409 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /auth/email/confirm [post]
+func (h *AuthHandler) ConfirmEmailChange(c *gin.Context) {
+	var req dto.EmailChangeTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.RespondError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	user, err := h.service.ConfirmEmailChange(c.Request.Context(), req.Token)
+	if err != nil {
+		respondEmailChangeError(c, err)
+		return
+	}
+
+	helper.RespondOK(c, mapUserResponse(user))
+}
+
+// RevertEmailChange godoc
+// @Summary      Revert email change
+// @Description  Restore the previous email from the link sent to it and sign out every session
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.EmailChangeTokenRequest true "Revert token"
+// @Success      200 {object} helper.Response
+// @Failure      400 {object} helper.Response
+// @Failure      401 {object} helper.Response
+// @Failure      409 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /auth/email/revert [post]
+func (h *AuthHandler) RevertEmailChange(c *gin.Context) {
+	var req dto.EmailChangeTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.RespondError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := h.service.RevertEmailChange(c.Request.Context(), req.Token); err != nil {
+		respondEmailChangeError(c, err)
+		return
+	}
+
+	helper.RespondOK(c, gin.H{"reverted": true})
+}
+
+func respondEmailChangeError(c *gin.Context, err error) {
+	switch err {
+	case service.ErrInvalidInput, service.ErrSameEmail:
+		helper.RespondError(c, http.StatusBadRequest, err.Error())
+	case service.ErrInvalidCredentials, service.ErrNotFound, service.ErrInvalidEmailChangeToken, service.ErrInvalidReauthToken:
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+	case service.ErrEmailExists:
+		helper.RespondError(c, http.StatusConflict, err.Error())
+	case service.ErrMailNotConfigured:
+		helper.RespondError(c, http.StatusServiceUnavailable, err.Error())
+	default:
+		helper.RespondError(c, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"wavefy-be/helper"
+	"wavefy-be/internal/dto"
+	"wavefy-be/internal/model"
+	"wavefy-be/internal/repository"
+)
+
+// ListActivity godoc
+// @Summary      Recent account activity
+// @Description  List the recent sign-ins, refreshes, password resets and email verifications of the current user
+// @Tags         auth
+// @Produce      json
+// @Param        limit query int false "Limit" default(20)
+// @Success      200 {object} helper.Response{data=[]dto.AuthEventResponse}
+// @Failure      401 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /auth/activity [get]
+func (h *AuthHandler) ListActivity(c *gin.Context) {
+	userID, err := authSubject(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	events, err := h.service.ListActivity(c.Request.Context(), userID, parseIntQuery(c, "limit", 20))
+	if err != nil {
+		helper.RespondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	helper.RespondOK(c, mapAuthEventResponses(events))
+}
+
+// ListAuthEvents godoc
+// @Summary      Query the authentication audit log
+// @Description  List authentication events of all users, newest first. Requires the audit:read permission.
+// @Tags         auth
+// @Produce      json
+// @Param        user_id query string false "User ID"
+// @Param        actor_id query string false "Staff member behind impersonation events"
+// @Param        email query string false "Email"
+// @Param        type query string false "Event type" Enums(login, lockout, refresh, password_reset_request, password_reset, email_verify, impersonation_start, impersonated_request)
+// @Param        outcome query string false "Outcome" Enums(success, failure, mfa_required)
+// @Param        ip query string false "Client IP"
+// @Param        since query string false "RFC 3339 start time"
+// @Param        until query string false "RFC 3339 end time"
+// @Param        limit query int false "Limit" default(50)
+// @Param        offset query int false "Offset" default(0)
+// @Success      200 {object} helper.Response{data=[]dto.AuthEventResponse}
+// @Failure      400 {object} helper.Response
+// @Failure      403 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /auth/events [get]
+func (h *AuthHandler) ListAuthEvents(c *gin.Context) {
+	filter, err := parseAuthEventFilter(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	limit := parseIntQuery(c, "limit", 50)
+	offset := parseIntQuery(c, "offset", 0)
+	events, err := h.service.ListAuthEvents(c.Request.Context(), filter, limit, offset)
+	if err != nil {
+		helper.RespondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	helper.RespondOK(c, mapAuthEventResponses(events))
+}
+
+func parseAuthEventFilter(c *gin.Context) (repository.AuthEventFilter, error) {
+	filter := repository.AuthEventFilter{
+		Email:   c.Query("email"),
+		Type:    c.Query("type"),
+		Outcome: c.Query("outcome"),
+		IP:      c.Query("ip"),
+	}
+	if value := c.Query("user_id"); value != "" {
+		userID, err := uuid.Parse(value)
+		if err != nil {
+			return filter, errors.New("invalid user_id")
+		}
+		filter.UserID = &userID
+	}
+	if value := c.Query("actor_id"); value != "" {
+		actorID, err := uuid.Parse(value)
+		if err != nil {
+			return filter, errors.New("invalid actor_id")
+		}
+		filter.ActorID = &actorID
+	}
+	if value := c.Query("since"); value != "" {
+		since, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, errors.New("invalid since")
+		}
+		filter.Since = since
+	}
+	if value := c.Query("until"); value != "" {
+		until, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, errors.New("invalid until")
+		}
+		filter.Until = until
+	}
+	return filter, nil
+}
+
+func mapAuthEventResponses(events []model.AuthEvent) []dto.AuthEventResponse {
+	resp := make([]dto.AuthEventResponse, 0, len(events))
+	for i := range events {
+		resp = append(resp, mapAuthEventResponse(&events[i]))
+	}
+	return resp
+}
+
+func mapAuthEventResponse(event *model.AuthEvent) dto.AuthEventResponse {
+	var userID *string
+	if event.UserID != nil {
+		value := event.UserID.String()
+		userID = &value
+	}
+	var actorID *string
+	if event.ActorID != nil {
+		value := event.ActorID.String()
+		actorID = &value
+	}
+	return dto.AuthEventResponse{
+		ID:        event.ID.String(),
+		UserID:    userID,
+		ActorID:   actorID,
+		Email:     event.Email,
+		Type:      event.Type,
+		Method:    event.Method,
+		Outcome:   event.Outcome,
+		Reason:    event.Reason,
+		IP:        event.IP,
+		UserAgent: event.UserAgent,
+		CreatedAt: event.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -1,16 +1,21 @@
 package handler
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 
 	"wavefy-be/config"
 	"wavefy-be/helper"
 	"wavefy-be/internal/dto"
 	"wavefy-be/internal/service"
+	"wavefy-be/internal/token"
 )
 
 type AuthHandler struct {
@@ -20,6 +25,7 @@ type AuthHandler struct {
 
 const refreshCookieName = "refresh_token"
 const refreshCookiePath = "/api/auth/refresh"
+const captchaHeader = "X-Captcha-Token"
 
 func NewAuthHandler(service service.AuthService, cfg config.AuthConfig) *AuthHandler {
 	return &AuthHandler{
@@ -35,9 +41,11 @@ func NewAuthHandler(service service.AuthService, cfg config.AuthConfig) *AuthHan
 // @Accept       json
 // @Produce      json
 // @Param        request body dto.RegisterRequest true "Register"
+// @Param        X-Captcha-Token header string false "Solved CAPTCHA, once one is required"
 // @Success      200 {object} helper.Response{data=dto.AuthResponse}
 // @Failure      400 {object} helper.Response
 // @Failure      409 {object} helper.Response
+// @Failure      428 {object} helper.Response{details=dto.CaptchaRequiredResponse}
 // @Failure      500 {object} helper.Response
 // @Router       /auth/register [post]
 func (h *AuthHandler) Register(c *gin.Context) {
@@ -50,8 +58,11 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	user, token, err := h.service.Register(c.Request.Context(), service.CreateUserInput{
 		Email:    req.Email,
 		Password: req.Password,
-	})
+	}, clientInfo(c))
 	if err != nil {
+		if respondPasswordPolicy(c, err) || respondCaptchaChallenge(c, err) {
+			return
+		}
 		switch err {
 		case service.ErrInvalidInput:
 			helper.RespondError(c, http.StatusBadRequest, err.Error())
@@ -75,14 +86,18 @@ func (h *AuthHandler) Register(c *gin.Context) {
 
 // Login godoc
 // @Summary      Login
-// @Description  Login with email and password
+// @Description  Login with email and password. Accounts with two-factor authentication receive dto.MFAChallengeResponse instead, to be exchanged at /auth/mfa/verify
 // @Tags         auth
 // @Accept       json
 // @Produce      json
 // @Param        request body dto.LoginRequest true "Login"
+// @Param        X-Captcha-Token header string false "Solved CAPTCHA, once one is required"
 // @Success      200 {object} helper.Response{data=dto.AuthResponse}
 // @Failure      400 {object} helper.Response
 // @Failure      401 {object} helper.Response
+// @Failure      403 {object} helper.Response{details=dto.EmailNotVerifiedResponse} "Email not verified, or account_suspended / account_banned with dto.AccountStatusResponse details"
+// @Failure      428 {object} helper.Response{details=dto.CaptchaRequiredResponse}
+// @Failure      429 {object} helper.Response
 // @Failure      500 {object} helper.Response
 // @Router       /auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
@@ -95,17 +110,22 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	user, token, err := h.service.Login(c.Request.Context(), service.LoginInput{
 		Email:    req.Email,
 		Password: req.Password,
-	})
+	}, clientInfo(c))
 	if err != nil {
+		if respondMFAChallenge(c, err) || respondCaptchaChallenge(c, err) || respondAccountStatus(c, err) {
+			return
+		}
 		switch err {
 		case service.ErrInvalidCredentials:
 			helper.RespondError(c, http.StatusUnauthorized, err.Error())
 		case service.ErrTooManyAttempts:
 			helper.RespondError(c, http.StatusTooManyRequests, err.Error())
 		case service.ErrEmailNotVerified:
-			helper.RespondError(c, http.StatusForbidden, err.Error())
-		case service.ErrMailNotConfigured:
-			helper.RespondError(c, http.StatusServiceUnavailable, err.Error())
+			helper.RespondErrorDetails(c, http.StatusForbidden, err.Error(), dto.EmailNotVerifiedResponse{
+				Code:       "email_not_verified",
+				Email:      strings.TrimSpace(strings.ToLower(req.Email)),
+				ResendPath: "/api/auth/verify-email/resend",
+			})
 		default:
 			helper.RespondError(c, http.StatusInternalServerError, err.Error())
 		}
@@ -132,6 +152,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 // @Success      200 {object} helper.Response{data=dto.AuthResponse}
 // @Failure      400 {object} helper.Response
 // @Failure      401 {object} helper.Response
+// @Failure      403 {object} helper.Response{details=dto.AccountStatusResponse}
 // @Failure      503 {object} helper.Response
 // @Failure      500 {object} helper.Response
 // @Router       /auth/google [post]
@@ -151,8 +172,11 @@ func (h *AuthHandler) GoogleLogin(c *gin.Context) {
 		return
 	}
 
-	user, token, err := h.service.LoginWithGoogle(c.Request.Context(), credential)
+	user, token, err := h.service.LoginWithGoogle(c.Request.Context(), credential, clientInfo(c))
 	if err != nil {
+		if respondMFAChallenge(c, err) || respondAccountStatus(c, err) {
+			return
+		}
 		switch err {
 		case service.ErrInvalidInput:
 			helper.RespondError(c, http.StatusBadRequest, err.Error())
@@ -186,6 +210,7 @@ func (h *AuthHandler) GoogleLogin(c *gin.Context) {
 // @Success      200 {object} helper.Response{data=dto.AuthResponse}
 // @Failure      400 {object} helper.Response
 // @Failure      401 {object} helper.Response
+// @Failure      403 {object} helper.Response{details=dto.AccountStatusResponse}
 // @Failure      500 {object} helper.Response
 // @Router       /auth/refresh [post]
 func (h *AuthHandler) Refresh(c *gin.Context) {
@@ -195,8 +220,12 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 		return
 	}
 
-	user, token, err := h.service.Refresh(c.Request.Context(), refreshToken)
+	user, token, err := h.service.Refresh(c.Request.Context(), refreshToken, clientInfo(c))
 	if err != nil {
+		if respondAccountStatus(c, err) {
+			h.clearRefreshCookie(c)
+			return
+		}
 		switch err {
 		case service.ErrInvalidCredentials:
 			helper.RespondError(c, http.StatusUnauthorized, err.Error())
@@ -223,8 +252,10 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 // @Accept       json
 // @Produce      json
 // @Param        request body dto.ForgotPasswordRequest true "Forgot password"
+// @Param        X-Captcha-Token header string false "Solved CAPTCHA, once one is required"
 // @Success      200 {object} helper.Response
 // @Failure      400 {object} helper.Response
+// @Failure      428 {object} helper.Response{details=dto.CaptchaRequiredResponse}
 // @Failure      503 {object} helper.Response
 // @Failure      500 {object} helper.Response
 // @Router       /auth/forgot-password [post]
@@ -235,7 +266,10 @@ func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.ForgotPassword(c.Request.Context(), req.Email); err != nil {
+	if err := h.service.ForgotPassword(c.Request.Context(), req.Email, clientInfo(c)); err != nil {
+		if respondCaptchaChallenge(c, err) {
+			return
+		}
 		switch err {
 		case service.ErrInvalidInput:
 			helper.RespondError(c, http.StatusBadRequest, err.Error())
@@ -269,7 +303,10 @@ func (h *AuthHandler) ResetPassword(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.ResetPassword(c.Request.Context(), req.Token, req.Password); err != nil {
+	if err := h.service.ResetPassword(c.Request.Context(), req.Token, req.Password, clientInfo(c)); err != nil {
+		if respondPasswordPolicy(c, err) {
+			return
+		}
 		switch err {
 		case service.ErrInvalidInput:
 			helper.RespondError(c, http.StatusBadRequest, err.Error())
@@ -303,7 +340,7 @@ func (h *AuthHandler) VerifyEmail(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.VerifyEmail(c.Request.Context(), req.Token); err != nil {
+	if err := h.service.VerifyEmail(c.Request.Context(), req.Token, clientInfo(c)); err != nil {
 		switch err {
 		case service.ErrInvalidVerifyToken:
 			helper.RespondError(c, http.StatusUnauthorized, err.Error())
@@ -316,6 +353,47 @@ func (h *AuthHandler) VerifyEmail(c *gin.Context) {
 	helper.RespondOK(c, gin.H{"verified": true})
 }
 
+// ResendVerifyEmail godoc
+// @Summary      Resend verification email
+// @Description  Send a new verification link to an unverified address. Limited per address by a cooldown and a daily cap.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.ResendVerifyEmailRequest true "Email"
+// @Success      200 {object} helper.Response
+// @Failure      400 {object} helper.Response
+// @Failure      429 {object} helper.Response
+// @Failure      503 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /auth/verify-email/resend [post]
+func (h *AuthHandler) ResendVerifyEmail(c *gin.Context) {
+	var req dto.ResendVerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.RespondError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := h.service.ResendVerifyEmail(c.Request.Context(), req.Email); err != nil {
+		var limited *service.VerifyResendLimitedError
+		if errors.As(err, &limited) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(limited.RetryAfter.Seconds()))))
+			helper.RespondError(c, http.StatusTooManyRequests, err.Error())
+			return
+		}
+		switch err {
+		case service.ErrInvalidInput:
+			helper.RespondError(c, http.StatusBadRequest, err.Error())
+		case service.ErrMailNotConfigured:
+			helper.RespondError(c, http.StatusServiceUnavailable, err.Error())
+		default:
+			helper.RespondError(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	helper.RespondOK(c, gin.H{"sent": true})
+}
+
 // Logout godoc
 // @Summary      Logout
 // @Description  Revoke refresh token and clear refresh cookie
@@ -349,6 +427,95 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 	helper.RespondOK(c, gin.H{"logged_out": true})
 }
 
+// LogoutAll godoc
+// @Summary      Logout from all devices
+// @Description  Revoke every session and access token of the current user, and delete its API keys and partner app grants
+// @Tags         auth
+// @Produce      json
+// @Success      200 {object} helper.Response
+// @Failure      401 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /auth/logout-all [post]
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	userID, err := authSubject(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	if err := h.service.LogoutAll(c.Request.Context(), userID); err != nil {
+		helper.RespondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.clearRefreshCookie(c)
+	helper.RespondOK(c, gin.H{"logged_out": true})
+}
+
+// ListSessions godoc
+// @Summary      List sessions
+// @Description  List the signed-in devices of the current user
+// @Tags         auth
+// @Produce      json
+// @Success      200 {object} helper.Response{data=[]dto.SessionResponse}
+// @Failure      401 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /auth/sessions [get]
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	userID, err := authSubject(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	sessions, err := h.service.ListSessions(c.Request.Context(), userID)
+	if err != nil {
+		helper.RespondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := make([]dto.SessionResponse, 0, len(sessions))
+	for i := range sessions {
+		resp = append(resp, mapSessionResponse(&sessions[i]))
+	}
+
+	helper.RespondOK(c, resp)
+}
+
+// RevokeSession godoc
+// @Summary      Revoke session
+// @Description  Sign out a single device of the current user
+// @Tags         auth
+// @Produce      json
+// @Param        id path string true "Session ID"
+// @Success      200 {object} helper.Response
+// @Failure      400 {object} helper.Response
+// @Failure      401 {object} helper.Response
+// @Failure      404 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /auth/sessions/{id} [delete]
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	userID, err := authSubject(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	if err := h.service.RevokeSession(c.Request.Context(), userID, c.Param("id")); err != nil {
+		switch err {
+		case service.ErrInvalidInput:
+			helper.RespondError(c, http.StatusBadRequest, err.Error())
+		case service.ErrNotFound:
+			helper.RespondError(c, http.StatusNotFound, err.Error())
+		default:
+			helper.RespondError(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	helper.RespondOK(c, gin.H{"revoked": true})
+}
+
 func (h *AuthHandler) setRefreshCookie(c *gin.Context, token string) {
 	if token == "" || h.cfg.RefreshTokenTTL <= 0 {
 		return
@@ -392,3 +559,69 @@ func isSecureRequest(c *gin.Context) bool {
 	}
 	return false
 }
+
+func clientInfo(c *gin.Context) service.ClientInfo {
+	return service.ClientInfo{
+		IP:           c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+		DeviceName:   strings.TrimSpace(c.GetHeader("X-Device-Name")),
+		CaptchaToken: strings.TrimSpace(c.GetHeader(captchaHeader)),
+	}
+}
+
+// respondCaptchaChallenge answers 428 with what the client needs to show a
+// challenge and resend the request with its token.
+func respondCaptchaChallenge(c *gin.Context, err error) bool {
+	var captchaErr *service.CaptchaRequiredError
+	if !errors.As(err, &captchaErr) {
+		return false
+	}
+	code := "captcha_required"
+	if captchaErr.Invalid {
+		code = "captcha_invalid"
+	}
+	helper.RespondErrorDetails(c, http.StatusPreconditionRequired, err.Error(), dto.CaptchaRequiredResponse{
+		Code:     code,
+		Provider: captchaErr.Provider,
+		SiteKey:  captchaErr.SiteKey,
+		Header:   captchaHeader,
+	})
+	return true
+}
+
+// respondAccountStatus answers 403 with why a suspended or banned account
+// cannot sign in.
+func respondAccountStatus(c *gin.Context, err error) bool {
+	var statusErr *service.AccountStatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	details := dto.AccountStatusResponse{
+		Code:   "account_" + statusErr.Status,
+		Reason: statusErr.Reason,
+	}
+	if statusErr.Until != nil {
+		details.Until = statusErr.Until.UTC().Format(time.RFC3339)
+	}
+	helper.RespondErrorDetails(c, http.StatusForbidden, err.Error(), details)
+	return true
+}
+
+func authSubject(c *gin.Context) (uuid.UUID, error) {
+	id, err := uuid.Parse(c.GetString("auth_subject"))
+	if err != nil {
+		return uuid.Nil, errors.New("invalid token subject")
+	}
+	return id, nil
+}
+
+func mapSessionResponse(session *token.Session) dto.SessionResponse {
+	return dto.SessionResponse{
+		ID:         session.ID,
+		DeviceName: session.DeviceName,
+		UserAgent:  session.UserAgent,
+		IP:         session.IP,
+		CreatedAt:  session.CreatedAt.Format(time.RFC3339),
+		LastUsedAt: session.LastUsedAt.Format(time.RFC3339),
+	}
+}
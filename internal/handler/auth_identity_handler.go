@@ -0,0 +1,250 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"wavefy-be/helper"
+	"wavefy-be/internal/dto"
+	"wavefy-be/internal/model"
+	"wavefy-be/internal/oauth"
+	"wavefy-be/internal/service"
+)
+
+// ListProviders godoc
+// @Summary      List sign-in providers
+// @Description  List the external identity providers configured on the server
+// @Tags         auth
+// @Produce      json
+// @Success      200 {object} helper.Response{data=[]string}
+// @Router       /auth/providers [get]
+func (h *AuthHandler) ListProviders(c *gin.Context) {
+	providers := h.service.ListProviders()
+	if providers == nil {
+		providers = []string{}
+	}
+	helper.RespondOK(c, providers)
+}
+
+// ProviderLogin godoc
+// @Summary      Login with a provider
+// @Description  Verify a provider credential (ID token, access token or authorization code) and issue internal tokens
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        provider path string true "Provider name"
+// @Param        request body dto.OAuthLoginRequest true "Provider credential"
+// @Success      200 {object} helper.Response{data=dto.AuthResponse}
+// @Failure      400 {object} helper.Response
+// @Failure      401 {object} helper.Response
+// @Failure      404 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /auth/oauth/{provider} [post]
+func (h *AuthHandler) ProviderLogin(c *gin.Context) {
+	var req dto.OAuthLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.RespondError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	user, token, err := h.service.LoginWithProvider(c.Request.Context(), c.Param("provider"), mapOAuthCredential(req), clientInfo(c))
+	if err != nil {
+		if respondMFAChallenge(c, err) || respondAccountStatus(c, err) {
+			return
+		}
+		respondIdentityError(c, err)
+		return
+	}
+
+	h.setRefreshCookie(c, token.RefreshToken)
+
+	helper.RespondOK(c, dto.AuthResponse{
+		AccessToken: token.AccessToken,
+		TokenType:   token.TokenType,
+		ExpiresAt:   token.ExpiresAt.Format(time.RFC3339),
+		User:        mapUserResponse(user),
+	})
+}
+
+// ListIdentities godoc
+// @Summary      List linked identities
+// @Description  List the external identities linked to the current user
+// @Tags         auth
+// @Produce      json
+// @Success      200 {object} helper.Response{data=[]dto.IdentityResponse}
+// @Failure      401 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /auth/identities [get]
+func (h *AuthHandler) ListIdentities(c *gin.Context) {
+	userID, err := authSubject(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	identities, err := h.service.ListIdentities(c.Request.Context(), userID)
+	if err != nil {
+		helper.RespondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := make([]dto.IdentityResponse, 0, len(identities))
+	for i := range identities {
+		resp = append(resp, mapIdentityResponse(&identities[i]))
+	}
+	helper.RespondOK(c, resp)
+}
+
+// LinkIdentity godoc
+// @Summary      Link identity
+// @Description  Link a provider account to the current user
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        provider path string true "Provider name"
+// @Param        request body dto.OAuthLoginRequest true "Provider credential"
+// @Success      200 {object} helper.Response{data=dto.IdentityResponse}
+// @Failure      400 {object} helper.Response
+// @Failure      401 {object} helper.Response
+// @Failure      404 {object} helper.Response
+// @Failure      409 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /auth/identities/{provider} [post]
+func (h *AuthHandler) LinkIdentity(c *gin.Context) {
+	userID, err := authSubject(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	var req dto.OAuthLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.RespondError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	identity, err := h.service.LinkIdentity(c.Request.Context(), userID, c.Param("provider"), mapOAuthCredential(req))
+	if err != nil {
+		respondIdentityError(c, err)
+		return
+	}
+
+	helper.RespondOK(c, mapIdentityResponse(identity))
+}
+
+// UnlinkIdentity godoc
+// @Summary      Unlink identity
+// @Description  Remove a linked provider account from the current user
+// @Tags         auth
+// @Produce      json
+// @Param        id path string true "Identity ID"
+// @Success      200 {object} helper.Response
+// @Failure      400 {object} helper.Response
+// @Failure      401 {object} helper.Response
+// @Failure      404 {object} helper.Response
+// @Failure      409 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /auth/identities/{id} [delete]
+func (h *AuthHandler) UnlinkIdentity(c *gin.Context) {
+	userID, err := authSubject(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	identityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		helper.RespondError(c, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	if err := h.service.UnlinkIdentity(c.Request.Context(), userID, identityID); err != nil {
+		respondIdentityError(c, err)
+		return
+	}
+
+	helper.RespondOK(c, gin.H{"deleted": true})
+}
+
+// SetPassword godoc
+// @Summary      Set password
+// @Description  Set or change the password of the current user with the current password or, on accounts without one, a token from POST /auth/reauth. Every other session is signed out and new tokens are issued.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.SetPasswordRequest true "Proof of presence and new password"
+// @Success      200 {object} helper.Response{data=dto.AuthResponse}
+// @Failure      400 {object} helper.Response
+// @Failure      401 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /auth/password [post]
+func (h *AuthHandler) SetPassword(c *gin.Context) {
+	userID, err := authSubject(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	var req dto.SetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.RespondError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	proof := service.Reauth{CurrentPassword: req.CurrentPassword, Token: req.ReauthToken}
+	user, token, err := h.service.SetPassword(c.Request.Context(), userID, proof, req.NewPassword, clientInfo(c))
+	if err != nil {
+		if respondPasswordPolicy(c, err) {
+			return
+		}
+		respondIdentityError(c, err)
+		return
+	}
+
+	h.setRefreshCookie(c, token.RefreshToken)
+
+	helper.RespondOK(c, dto.AuthResponse{
+		AccessToken: token.AccessToken,
+		TokenType:   token.TokenType,
+		ExpiresAt:   token.ExpiresAt.Format(time.RFC3339),
+		User:        mapUserResponse(user),
+	})
+}
+
+func respondIdentityError(c *gin.Context, err error) {
+	switch err {
+	case service.ErrInvalidInput, service.ErrProviderEmailRequired:
+		helper.RespondError(c, http.StatusBadRequest, err.Error())
+	case service.ErrInvalidCredentials, service.ErrNotFound, service.ErrInvalidReauthToken:
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+	case service.ErrProviderNotConfigured, service.ErrIdentityNotFound:
+		helper.RespondError(c, http.StatusNotFound, err.Error())
+	case service.ErrIdentityInUse, service.ErrLastLoginMethod:
+		helper.RespondError(c, http.StatusConflict, err.Error())
+	default:
+		helper.RespondError(c, http.StatusInternalServerError, err.Error())
+	}
+}
+
+func mapOAuthCredential(req dto.OAuthLoginRequest) oauth.Credential {
+	return oauth.Credential{
+		IDToken:      req.IDToken,
+		AccessToken:  req.AccessToken,
+		Code:         req.Code,
+		RedirectURI:  req.RedirectURI,
+		CodeVerifier: req.CodeVerifier,
+		Nonce:        req.Nonce,
+	}
+}
+
+func mapIdentityResponse(identity *model.Identity) dto.IdentityResponse {
+	return dto.IdentityResponse{
+		ID:        identity.ID.String(),
+		Provider:  identity.Provider,
+		Email:     identity.Email,
+		CreatedAt: identity.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"wavefy-be/helper"
+	"wavefy-be/internal/dto"
+	"wavefy-be/internal/service"
+)
+
+// Impersonate godoc
+// @Summary      Impersonate a user
+// @Description  Issue a short-lived access token to act as the user for support. It carries an act claim naming the caller, cannot be refreshed and is refused on password, email and account changes and on deletion. Every request made with it is recorded in the audit log. Requires the users:impersonate permission.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.ImpersonateRequest true "User and reason"
+// @Success      200 {object} helper.Response{data=dto.AuthResponse}
+// @Failure      400 {object} helper.Response
+// @Failure      401 {object} helper.Response
+// @Failure      403 {object} helper.Response
+// @Failure      404 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /auth/impersonate [post]
+func (h *AuthHandler) Impersonate(c *gin.Context) {
+	actor, err := authActor(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	var req dto.ImpersonateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.RespondError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	userID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		helper.RespondError(c, http.StatusBadRequest, "invalid user_id")
+		return
+	}
+
+	user, token, err := h.service.Impersonate(c.Request.Context(), actor, userID, req.Reason, clientInfo(c))
+	if err != nil {
+		if respondAccountStatus(c, err) {
+			return
+		}
+		switch err {
+		case service.ErrInvalidInput:
+			helper.RespondError(c, http.StatusBadRequest, err.Error())
+		case service.ErrForbidden:
+			helper.RespondError(c, http.StatusForbidden, err.Error())
+		case service.ErrNotFound:
+			helper.RespondError(c, http.StatusNotFound, err.Error())
+		default:
+			helper.RespondError(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	helper.RespondOK(c, dto.AuthResponse{
+		AccessToken: token.AccessToken,
+		TokenType:   token.TokenType,
+		ExpiresAt:   token.ExpiresAt.Format(time.RFC3339),
+		User:        mapUserResponse(user),
+	})
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"wavefy-be/helper"
+	"wavefy-be/internal/dto"
+	"wavefy-be/internal/service"
+)
+
+// RequestMagicLink godoc
+// @Summary      Request magic link
+// @Description  Email a single-use passwordless login link
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.MagicLinkRequest true "Magic link"
+// @Success      200 {object} helper.Response
+// @Failure      400 {object} helper.Response
+// @Failure      429 {object} helper.Response
+// @Failure      503 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /auth/magic-link [post]
+func (h *AuthHandler) RequestMagicLink(c *gin.Context) {
+	var req dto.MagicLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.RespondError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := h.service.RequestMagicLink(c.Request.Context(), req.Email); err != nil {
+		respondMagicLinkError(c, err)
+		return
+	}
+
+	helper.RespondOK(c, gin.H{"sent": true})
+}
+
+// ConsumeMagicLink godoc
+// @Summary      Login with magic link
+// @Description  Sign in with a magic link token and verify the email
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.MagicLinkConsumeRequest true "Magic link token"
+// @Success      200 {object} helper.Response{data=dto.AuthResponse}
+// @Failure      400 {object} helper.Response
+// @Failure      401 {object} helper.Response
+// @Failure      429 {object} helper.Response
+// @Failure      503 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /auth/magic-link/consume [post]
+func (h *AuthHandler) ConsumeMagicLink(c *gin.Context) {
+	var req dto.MagicLinkConsumeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.RespondError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	user, token, err := h.service.ConsumeMagicLink(c.Request.Context(), req.Token, clientInfo(c))
+	if err != nil {
+		if respondMFAChallenge(c, err) || respondAccountStatus(c, err) {
+			return
+		}
+		respondMagicLinkError(c, err)
+		return
+	}
+
+	h.setRefreshCookie(c, token.RefreshToken)
+
+	helper.RespondOK(c, dto.AuthResponse{
+		AccessToken: token.AccessToken,
+		TokenType:   token.TokenType,
+		ExpiresAt:   token.ExpiresAt.Format(time.RFC3339),
+		User:        mapUserResponse(user),
+	})
+}
+
+func respondMagicLinkError(c *gin.Context, err error) {
+	switch err {
+	case service.ErrInvalidInput:
+		helper.RespondError(c, http.StatusBadRequest, err.Error())
+	case service.ErrInvalidMagicLink:
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+	case service.ErrTooManyAttempts:
+		helper.RespondError(c, http.StatusTooManyRequests, err.Error())
+	case service.ErrMagicLinkNotConfigured:
+		helper.RespondError(c, http.StatusServiceUnavailable, err.Error())
+	default:
+		helper.RespondError(c, http.StatusInternalServerError, err.Error())
+	}
+}
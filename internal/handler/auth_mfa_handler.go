@@ -0,0 +1,235 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"wavefy-be/helper"
+	"wavefy-be/internal/dto"
+	"wavefy-be/internal/service"
+)
+
+// VerifyMFA godoc
+// @Summary      Complete two-factor login
+// @Description  Exchange the MFA challenge from login and a TOTP or recovery code for tokens
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.MFAVerifyRequest true "Verify MFA"
+// @Success      200 {object} helper.Response{data=dto.AuthResponse}
+// @Failure      400 {object} helper.Response
+// @Failure      401 {object} helper.Response
+// @Failure      429 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /auth/mfa/verify [post]
+func (h *AuthHandler) VerifyMFA(c *gin.Context) {
+	var req dto.MFAVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.RespondError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	user, token, err := h.service.VerifyMFA(c.Request.Context(), req.ChallengeToken, req.Code, clientInfo(c))
+	if err != nil {
+		if respondAccountStatus(c, err) {
+			return
+		}
+		switch err {
+		case service.ErrInvalidInput:
+			helper.RespondError(c, http.StatusBadRequest, err.Error())
+		case service.ErrInvalidMFAChallenge, service.ErrInvalidMFACode:
+			helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		case service.ErrTooManyAttempts:
+			helper.RespondError(c, http.StatusTooManyRequests, err.Error())
+		case service.ErrMFANotConfigured:
+			helper.RespondError(c, http.StatusServiceUnavailable, err.Error())
+		default:
+			helper.RespondError(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	h.setRefreshCookie(c, token.RefreshToken)
+
+	helper.RespondOK(c, dto.AuthResponse{
+		AccessToken: token.AccessToken,
+		TokenType:   token.TokenType,
+		ExpiresAt:   token.ExpiresAt.Format(time.RFC3339),
+		User:        mapUserResponse(user),
+	})
+}
+
+// SetupTOTP godoc
+// @Summary      Start TOTP enrolment
+// @Description  Generate a TOTP secret and otpauth URI; it is enabled once confirmed with a code
+// @Tags         auth
+// @Produce      json
+// @Success      200 {object} helper.Response{data=dto.TOTPSetupResponse}
+// @Failure      401 {object} helper.Response
+// @Failure      409 {object} helper.Response
+// @Failure      503 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /auth/mfa/totp/setup [post]
+func (h *AuthHandler) SetupTOTP(c *gin.Context) {
+	userID, err := authSubject(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	setup, err := h.service.SetupTOTP(c.Request.Context(), userID)
+	if err != nil {
+		switch err {
+		case service.ErrNotFound:
+			helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		case service.ErrMFAAlreadyEnabled:
+			helper.RespondError(c, http.StatusConflict, err.Error())
+		case service.ErrMFANotConfigured:
+			helper.RespondError(c, http.StatusServiceUnavailable, err.Error())
+		default:
+			helper.RespondError(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	helper.RespondOK(c, dto.TOTPSetupResponse{
+		Secret:     setup.Secret,
+		OTPAuthURI: setup.URI,
+	})
+}
+
+// ConfirmTOTP godoc
+// @Summary      Confirm TOTP enrolment
+// @Description  Enable TOTP with a code from the authenticator app and receive recovery codes
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.MFACodeRequest true "TOTP code"
+// @Success      200 {object} helper.Response{data=dto.RecoveryCodesResponse}
+// @Failure      400 {object} helper.Response
+// @Failure      401 {object} helper.Response
+// @Failure      409 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /auth/mfa/totp/confirm [post]
+func (h *AuthHandler) ConfirmTOTP(c *gin.Context) {
+	userID, err := authSubject(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	var req dto.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.RespondError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	codes, err := h.service.ConfirmTOTP(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	helper.RespondOK(c, dto.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTOTP godoc
+// @Summary      Disable TOTP
+// @Description  Turn off two-factor authentication with a TOTP or recovery code
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.MFACodeRequest true "TOTP or recovery code"
+// @Success      200 {object} helper.Response
+// @Failure      400 {object} helper.Response
+// @Failure      401 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /auth/mfa/totp/disable [post]
+func (h *AuthHandler) DisableTOTP(c *gin.Context) {
+	userID, err := authSubject(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	var req dto.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.RespondError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := h.service.DisableTOTP(c.Request.Context(), userID, req.Code); err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	helper.RespondOK(c, gin.H{"disabled": true})
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary      Regenerate recovery codes
+// @Description  Replace all recovery codes; requires a current TOTP code
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.MFACodeRequest true "TOTP code"
+// @Success      200 {object} helper.Response{data=dto.RecoveryCodesResponse}
+// @Failure      400 {object} helper.Response
+// @Failure      401 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /auth/mfa/recovery-codes [post]
+func (h *AuthHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, err := authSubject(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	var req dto.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.RespondError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	codes, err := h.service.RegenerateRecoveryCodes(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	helper.RespondOK(c, dto.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// respondMFAChallenge answers a login that needs a second factor. It reports
+// whether err was such a challenge.
+func respondMFAChallenge(c *gin.Context, err error) bool {
+	var mfaErr *service.MFARequiredError
+	if !errors.As(err, &mfaErr) {
+		return false
+	}
+	helper.RespondOK(c, dto.MFAChallengeResponse{
+		MFARequired:    true,
+		ChallengeToken: mfaErr.ChallengeToken,
+		ExpiresAt:      mfaErr.ExpiresAt.Format(time.RFC3339),
+		Methods:        []string{"totp", "recovery_code"},
+	})
+	return true
+}
+
+func respondMFAError(c *gin.Context, err error) {
+	switch err {
+	case service.ErrInvalidInput:
+		helper.RespondError(c, http.StatusBadRequest, err.Error())
+	case service.ErrNotFound, service.ErrInvalidMFACode:
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+	case service.ErrMFAAlreadyEnabled, service.ErrMFANotEnabled:
+		helper.RespondError(c, http.StatusConflict, err.Error())
+	case service.ErrMFANotConfigured:
+		helper.RespondError(c, http.StatusServiceUnavailable, err.Error())
+	default:
+		helper.RespondError(c, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,234 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"wavefy-be/helper"
+	"wavefy-be/internal/dto"
+	"wavefy-be/internal/model"
+	"wavefy-be/internal/service"
+)
+
+// BeginPasskeyRegistration godoc
+// @Summary      Start passkey registration
+// @Description  Return WebAuthn creation options for navigator.credentials.create
+// @Tags         auth
+// @Produce      json
+// @Success      200 {object} helper.Response{data=dto.PasskeyCeremonyResponse}
+// @Failure      401 {object} helper.Response
+// @Failure      503 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /auth/passkeys/register/begin [post]
+func (h *AuthHandler) BeginPasskeyRegistration(c *gin.Context) {
+	userID, err := authSubject(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	ceremony, err := h.service.BeginPasskeyRegistration(c.Request.Context(), userID)
+	if err != nil {
+		respondPasskeyError(c, err)
+		return
+	}
+
+	helper.RespondOK(c, mapPasskeyCeremonyResponse(ceremony))
+}
+
+// FinishPasskeyRegistration godoc
+// @Summary      Finish passkey registration
+// @Description  Verify the authenticator attestation and store the passkey
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.PasskeyFinishRequest true "Attestation response"
+// @Success      200 {object} helper.Response{data=dto.PasskeyResponse}
+// @Failure      400 {object} helper.Response
+// @Failure      401 {object} helper.Response
+// @Failure      503 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /auth/passkeys/register/finish [post]
+func (h *AuthHandler) FinishPasskeyRegistration(c *gin.Context) {
+	userID, err := authSubject(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	var req dto.PasskeyFinishRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.RespondError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	passkey, err := h.service.FinishPasskeyRegistration(c.Request.Context(), userID, req.SessionID, req.Name, req.Credential)
+	if err != nil {
+		respondPasskeyError(c, err)
+		return
+	}
+
+	helper.RespondOK(c, mapPasskeyResponse(passkey))
+}
+
+// ListPasskeys godoc
+// @Summary      List passkeys
+// @Description  List the passkeys registered to the current user
+// @Tags         auth
+// @Produce      json
+// @Success      200 {object} helper.Response{data=[]dto.PasskeyResponse}
+// @Failure      401 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /auth/passkeys [get]
+func (h *AuthHandler) ListPasskeys(c *gin.Context) {
+	userID, err := authSubject(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	passkeys, err := h.service.ListPasskeys(c.Request.Context(), userID)
+	if err != nil {
+		helper.RespondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := make([]dto.PasskeyResponse, 0, len(passkeys))
+	for i := range passkeys {
+		resp = append(resp, mapPasskeyResponse(&passkeys[i]))
+	}
+	helper.RespondOK(c, resp)
+}
+
+// DeletePasskey godoc
+// @Summary      Delete passkey
+// @Description  Remove a passkey from the current user
+// @Tags         auth
+// @Produce      json
+// @Param        id path string true "Passkey ID"
+// @Success      200 {object} helper.Response
+// @Failure      400 {object} helper.Response
+// @Failure      401 {object} helper.Response
+// @Failure      404 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /auth/passkeys/{id} [delete]
+func (h *AuthHandler) DeletePasskey(c *gin.Context) {
+	userID, err := authSubject(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	passkeyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		helper.RespondError(c, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	if err := h.service.DeletePasskey(c.Request.Context(), userID, passkeyID); err != nil {
+		respondPasskeyError(c, err)
+		return
+	}
+
+	helper.RespondOK(c, gin.H{"deleted": true})
+}
+
+// BeginPasskeyLogin godoc
+// @Summary      Start passkey login
+// @Description  Return WebAuthn request options for navigator.credentials.get
+// @Tags         auth
+// @Produce      json
+// @Success      200 {object} helper.Response{data=dto.PasskeyCeremonyResponse}
+// @Failure      503 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /auth/passkeys/login/begin [post]
+func (h *AuthHandler) BeginPasskeyLogin(c *gin.Context) {
+	ceremony, err := h.service.BeginPasskeyLogin(c.Request.Context())
+	if err != nil {
+		respondPasskeyError(c, err)
+		return
+	}
+
+	helper.RespondOK(c, mapPasskeyCeremonyResponse(ceremony))
+}
+
+// FinishPasskeyLogin godoc
+// @Summary      Finish passkey login
+// @Description  Verify the authenticator assertion and issue tokens
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.PasskeyFinishRequest true "Assertion response"
+// @Success      200 {object} helper.Response{data=dto.AuthResponse}
+// @Failure      400 {object} helper.Response
+// @Failure      401 {object} helper.Response
+// @Failure      403 {object} helper.Response
+// @Failure      503 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /auth/passkeys/login/finish [post]
+func (h *AuthHandler) FinishPasskeyLogin(c *gin.Context) {
+	var req dto.PasskeyFinishRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.RespondError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	user, token, err := h.service.FinishPasskeyLogin(c.Request.Context(), req.SessionID, req.Credential, clientInfo(c))
+	if err != nil {
+		if respondAccountStatus(c, err) {
+			return
+		}
+		respondPasskeyError(c, err)
+		return
+	}
+
+	h.setRefreshCookie(c, token.RefreshToken)
+
+	helper.RespondOK(c, dto.AuthResponse{
+		AccessToken: token.AccessToken,
+		TokenType:   token.TokenType,
+		ExpiresAt:   token.ExpiresAt.Format(time.RFC3339),
+		User:        mapUserResponse(user),
+	})
+}
+
+func respondPasskeyError(c *gin.Context, err error) {
+	switch err {
+	case service.ErrInvalidInput, service.ErrInvalidPasskeySession:
+		helper.RespondError(c, http.StatusBadRequest, err.Error())
+	case service.ErrNotFound, service.ErrInvalidPasskey:
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+	case service.ErrEmailNotVerified:
+		helper.RespondError(c, http.StatusForbidden, err.Error())
+	case service.ErrPasskeyNotFound:
+		helper.RespondError(c, http.StatusNotFound, err.Error())
+	case service.ErrPasskeyNotConfigured:
+		helper.RespondError(c, http.StatusServiceUnavailable, err.Error())
+	default:
+		helper.RespondError(c, http.StatusInternalServerError, err.Error())
+	}
+}
+
+func mapPasskeyCeremonyResponse(ceremony *service.PasskeyCeremony) dto.PasskeyCeremonyResponse {
+	return dto.PasskeyCeremonyResponse{
+		SessionID: ceremony.SessionID,
+		ExpiresAt: ceremony.ExpiresAt.Format(time.RFC3339),
+		Options:   ceremony.Options,
+	}
+}
+
+func mapPasskeyResponse(passkey *model.WebAuthnCredential) dto.PasskeyResponse {
+	resp := dto.PasskeyResponse{
+		ID:        passkey.ID.String(),
+		Name:      passkey.Name,
+		CreatedAt: passkey.CreatedAt.Format(time.RFC3339),
+	}
+	if passkey.LastUsedAt != nil {
+		lastUsed := passkey.LastUsedAt.Format(time.RFC3339)
+		resp.LastUsedAt = &lastUsed
+	}
+	return resp
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"wavefy-be/helper"
+	"wavefy-be/internal/service"
+)
+
+type DataExportHandler struct {
+	service service.DataExportService
+}
+
+func NewDataExportHandler(service service.DataExportService) *DataExportHandler {
+	return &DataExportHandler{service: service}
+}
+
+// RequestExport godoc
+// @Summary      Request personal data export
+// @Description  Build a ZIP of JSON files with the account's profile, tracks, auth events, sessions and linked credentials in the background and email a time-limited download link
+// @Tags         auth
+// @Produce      json
+// @Success      200 {object} helper.Response
+// @Failure      401 {object} helper.Response
+// @Failure      429 {object} helper.Response
+// @Failure      503 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /auth/account/export [post]
+func (h *DataExportHandler) RequestExport(c *gin.Context) {
+	userID, err := authSubject(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	if err := h.service.RequestExport(c.Request.Context(), userID); err != nil {
+		var limited *service.DataExportLimitedError
+		if errors.As(err, &limited) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(limited.RetryAfter.Seconds()))))
+			helper.RespondError(c, http.StatusTooManyRequests, err.Error())
+			return
+		}
+		switch err {
+		case service.ErrNotFound:
+			helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		case service.ErrMailNotConfigured:
+			helper.RespondError(c, http.StatusServiceUnavailable, err.Error())
+		default:
+			helper.RespondError(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	helper.RespondOK(c, gin.H{"requested": true})
+}
@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"wavefy-be/internal/token"
+)
+
+type JWKSHandler struct {
+	keys *token.KeyRing
+}
+
+func NewJWKSHandler(keys *token.KeyRing) *JWKSHandler {
+	return &JWKSHandler{keys: keys}
+}
+
+// JWKS godoc
+// @Summary      JSON Web Key Set
+// @Description  Public keys that verify access tokens, selected by kid
+// @Tags         auth
+// @Produce      json
+// @Success      200 {object} token.JWKSet
+// @Router       /.well-known/jwks.json [get]
+func (h *JWKSHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.keys.JWKS())
+}
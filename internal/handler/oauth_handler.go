@@ -0,0 +1,451 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"wavefy-be/helper"
+	"wavefy-be/internal/dto"
+	"wavefy-be/internal/model"
+	"wavefy-be/internal/service"
+)
+
+// OAuthHandler serves Wavefy as an OAuth2 authorization server for partner
+// apps. The token, revocation and introspection endpoints answer in the RFC
+// formats rather than helper.Response, since OAuth client libraries call
+// them.
+type OAuthHandler struct {
+	service service.OAuthService
+}
+
+func NewOAuthHandler(service service.OAuthService) *OAuthHandler {
+	return &OAuthHandler{service: service}
+}
+
+// CreateClient godoc
+// @Summary      Register OAuth client
+// @Description  Register a partner app. Confidential clients get a client secret, which is only returned once.
+// @Tags         oauth
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.CreateOAuthClientRequest true "Client"
+// @Success      200 {object} helper.Response{data=dto.CreatedOAuthClientResponse}
+// @Failure      400 {object} helper.Response
+// @Failure      401 {object} helper.Response
+// @Failure      403 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /oauth/clients [post]
+func (h *OAuthHandler) CreateClient(c *gin.Context) {
+	userID, err := authSubject(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	var req dto.CreateOAuthClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.RespondError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	client, secret, err := h.service.CreateClient(c.Request.Context(), userID, service.CreateOAuthClientInput{
+		Name:         req.Name,
+		RedirectURIs: req.RedirectURIs,
+		Scopes:       req.Scopes,
+		Confidential: req.Confidential,
+	})
+	if err != nil {
+		switch err {
+		case service.ErrInvalidInput:
+			helper.RespondError(c, http.StatusBadRequest, err.Error())
+		default:
+			helper.RespondError(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	helper.RespondOK(c, dto.CreatedOAuthClientResponse{
+		OAuthClientResponse: mapOAuthClientResponse(client),
+		ClientSecret:        secret,
+	})
+}
+
+// ListClients godoc
+// @Summary      List OAuth clients
+// @Tags         oauth
+// @Produce      json
+// @Success      200 {object} helper.Response{data=[]dto.OAuthClientResponse}
+// @Failure      401 {object} helper.Response
+// @Failure      403 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /oauth/clients [get]
+func (h *OAuthHandler) ListClients(c *gin.Context) {
+	clients, err := h.service.ListClients(c.Request.Context())
+	if err != nil {
+		helper.RespondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := make([]dto.OAuthClientResponse, 0, len(clients))
+	for i := range clients {
+		resp = append(resp, mapOAuthClientResponse(&clients[i]))
+	}
+	helper.RespondOK(c, resp)
+}
+
+// DeleteClient godoc
+// @Summary      Delete OAuth client
+// @Description  Remove a partner app and every grant users gave it, revoking the tokens it was issued
+// @Tags         oauth
+// @Produce      json
+// @Param        id path string true "Client ID"
+// @Success      200 {object} helper.Response
+// @Failure      400 {object} helper.Response
+// @Failure      401 {object} helper.Response
+// @Failure      403 {object} helper.Response
+// @Failure      404 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /oauth/clients/{id} [delete]
+func (h *OAuthHandler) DeleteClient(c *gin.Context) {
+	id, err := parseUUIDParam(c, "id")
+	if err != nil {
+		helper.RespondError(c, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	if err := h.service.DeleteClient(c.Request.Context(), id); err != nil {
+		switch err {
+		case service.ErrNotFound:
+			helper.RespondError(c, http.StatusNotFound, err.Error())
+		default:
+			helper.RespondError(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	helper.RespondOK(c, gin.H{"deleted": true})
+}
+
+// Authorize godoc
+// @Summary      Start OAuth authorization
+// @Description  Validate an authorization code request (PKCE S256 required) and describe it for the consent screen
+// @Tags         oauth
+// @Produce      json
+// @Param        response_type query string true "Must be code"
+// @Param        client_id query string true "Client ID"
+// @Param        redirect_uri query string false "Registered redirect URI"
+// @Param        scope query string true "Space separated scopes"
+// @Param        state query string false "Opaque value returned to the client"
+// @Param        code_challenge query string true "PKCE code challenge"
+// @Param        code_challenge_method query string true "Must be S256"
+// @Success      200 {object} helper.Response{data=dto.OAuthConsentResponse}
+// @Failure      400 {object} helper.Response
+// @Failure      401 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /oauth/authorize [get]
+func (h *OAuthHandler) Authorize(c *gin.Context) {
+	userID, err := authSubject(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	var req dto.OAuthAuthorizeRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		helper.RespondError(c, http.StatusBadRequest, "invalid request")
+		return
+	}
+
+	prompt, err := h.service.Authorize(c.Request.Context(), userID, mapAuthorizationRequest(req))
+	if err != nil {
+		respondAuthorizeError(c, err)
+		return
+	}
+
+	helper.RespondOK(c, dto.OAuthConsentResponse{
+		Client:      dto.OAuthConsentClient{ClientID: prompt.Client.ClientID, Name: prompt.Client.Name},
+		Scopes:      prompt.Scopes,
+		RedirectURI: prompt.RedirectURI,
+		Consented:   prompt.Consented,
+	})
+}
+
+// Decide godoc
+// @Summary      Answer OAuth consent
+// @Description  Approve or deny a partner app and get the URL to send the browser back to, carrying the code or access_denied
+// @Tags         oauth
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.OAuthDecisionRequest true "Authorization request and decision"
+// @Success      200 {object} helper.Response{data=dto.OAuthRedirectResponse}
+// @Failure      400 {object} helper.Response
+// @Failure      401 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /oauth/authorize [post]
+func (h *OAuthHandler) Decide(c *gin.Context) {
+	userID, err := authSubject(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	var req dto.OAuthDecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.RespondError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	redirectURL, err := h.service.Decide(c.Request.Context(), userID, mapAuthorizationRequest(req.OAuthAuthorizeRequest), req.Approve)
+	if err != nil {
+		respondAuthorizeError(c, err)
+		return
+	}
+
+	helper.RespondOK(c, dto.OAuthRedirectResponse{RedirectURL: redirectURL})
+}
+
+// Token godoc
+// @Summary      OAuth token endpoint
+// @Description  Exchange an authorization code (with its PKCE verifier) or a refresh token for tokens. Confidential clients authenticate with HTTP Basic or client_secret.
+// @Tags         oauth
+// @Accept       x-www-form-urlencoded
+// @Produce      json
+// @Param        grant_type formData string true "authorization_code or refresh_token"
+// @Param        code formData string false "Authorization code"
+// @Param        redirect_uri formData string false "Redirect URI used for the code"
+// @Param        code_verifier formData string false "PKCE code verifier"
+// @Param        refresh_token formData string false "Refresh token"
+// @Param        scope formData string false "Narrower scopes on refresh"
+// @Param        client_id formData string false "Client ID when not using HTTP Basic"
+// @Param        client_secret formData string false "Client secret when not using HTTP Basic"
+// @Success      200 {object} dto.OAuthTokenResponse
+// @Failure      400 {object} dto.OAuthErrorResponse
+// @Failure      401 {object} dto.OAuthErrorResponse
+// @Failure      500 {object} dto.OAuthErrorResponse
+// @Router       /oauth/token [post]
+func (h *OAuthHandler) Token(c *gin.Context) {
+	result, err := h.service.Token(c.Request.Context(), service.OAuthTokenRequest{
+		Client:       oauthClientCredentials(c),
+		GrantType:    c.PostForm("grant_type"),
+		Code:         c.PostForm("code"),
+		RedirectURI:  c.PostForm("redirect_uri"),
+		CodeVerifier: c.PostForm("code_verifier"),
+		RefreshToken: c.PostForm("refresh_token"),
+		Scope:        c.PostForm("scope"),
+	})
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+	c.JSON(http.StatusOK, dto.OAuthTokenResponse{
+		AccessToken:  result.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(time.Until(result.ExpiresAt).Seconds()),
+		RefreshToken: result.RefreshToken,
+		Scope:        strings.Join(result.Scopes, " "),
+	})
+}
+
+// Revoke godoc
+// @Summary      OAuth token revocation
+// @Description  Revoke an access or refresh token issued to the calling client (RFC 7009). Unknown tokens are accepted silently.
+// @Tags         oauth
+// @Accept       x-www-form-urlencoded
+// @Produce      json
+// @Param        token formData string true "Access or refresh token"
+// @Param        token_type_hint formData string false "access_token or refresh_token"
+// @Success      200
+// @Failure      400 {object} dto.OAuthErrorResponse
+// @Failure      401 {object} dto.OAuthErrorResponse
+// @Failure      500 {object} dto.OAuthErrorResponse
+// @Router       /oauth/revoke [post]
+func (h *OAuthHandler) Revoke(c *gin.Context) {
+	if err := h.service.Revoke(c.Request.Context(), oauthClientCredentials(c), c.PostForm("token")); err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// Introspect godoc
+// @Summary      OAuth token introspection
+// @Description  Describe a token issued to the calling client (RFC 7662)
+// @Tags         oauth
+// @Accept       x-www-form-urlencoded
+// @Produce      json
+// @Param        token formData string true "Access or refresh token"
+// @Success      200 {object} dto.OAuthIntrospectionResponse
+// @Failure      400 {object} dto.OAuthErrorResponse
+// @Failure      401 {object} dto.OAuthErrorResponse
+// @Failure      500 {object} dto.OAuthErrorResponse
+// @Router       /oauth/introspect [post]
+func (h *OAuthHandler) Introspect(c *gin.Context) {
+	result, err := h.service.Introspect(c.Request.Context(), oauthClientCredentials(c), c.PostForm("token"))
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+
+	resp := dto.OAuthIntrospectionResponse{Active: result.Active}
+	if result.Active {
+		resp.TokenType = result.TokenType
+		resp.ClientID = result.ClientID
+		resp.Sub = result.Subject
+		resp.Scope = strings.Join(result.Scopes, " ")
+		resp.Exp = result.ExpiresAt.Unix()
+		if !result.IssuedAt.IsZero() {
+			resp.Iat = result.IssuedAt.Unix()
+		}
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, resp)
+}
+
+// ListGrants godoc
+// @Summary      List authorized apps
+// @Description  List the partner apps the current user has granted access to
+// @Tags         oauth
+// @Produce      json
+// @Success      200 {object} helper.Response{data=[]dto.OAuthGrantResponse}
+// @Failure      401 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /oauth/grants [get]
+func (h *OAuthHandler) ListGrants(c *gin.Context) {
+	userID, err := authSubject(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	grants, err := h.service.ListGrants(c.Request.Context(), userID)
+	if err != nil {
+		helper.RespondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := make([]dto.OAuthGrantResponse, 0, len(grants))
+	for _, grant := range grants {
+		resp = append(resp, dto.OAuthGrantResponse{
+			Client:    dto.OAuthConsentClient{ClientID: grant.Client.ClientID, Name: grant.Client.Name},
+			Scopes:    grant.Scopes,
+			CreatedAt: grant.CreatedAt.Format(time.RFC3339),
+			UpdatedAt: grant.UpdatedAt.Format(time.RFC3339),
+		})
+	}
+	helper.RespondOK(c, resp)
+}
+
+// RevokeGrant godoc
+// @Summary      Revoke app access
+// @Description  Withdraw the current user's consent for a partner app; its refresh and access tokens stop working
+// @Tags         oauth
+// @Produce      json
+// @Param        client_id path string true "Client ID"
+// @Success      200 {object} helper.Response
+// @Failure      401 {object} helper.Response
+// @Failure      404 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /oauth/grants/{client_id} [delete]
+func (h *OAuthHandler) RevokeGrant(c *gin.Context) {
+	userID, err := authSubject(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	if err := h.service.RevokeGrant(c.Request.Context(), userID, c.Param("client_id")); err != nil {
+		switch err {
+		case service.ErrNotFound:
+			helper.RespondError(c, http.StatusNotFound, err.Error())
+		default:
+			helper.RespondError(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	helper.RespondOK(c, gin.H{"revoked": true})
+}
+
+// oauthClientCredentials reads client credentials from HTTP Basic, whose
+// parts are form encoded per RFC 6749 section 2.3.1, or from the form body.
+func oauthClientCredentials(c *gin.Context) service.OAuthClientCredentials {
+	if id, secret, ok := c.Request.BasicAuth(); ok {
+		if decoded, err := url.QueryUnescape(id); err == nil {
+			id = decoded
+		}
+		if decoded, err := url.QueryUnescape(secret); err == nil {
+			secret = decoded
+		}
+		return service.OAuthClientCredentials{ClientID: id, ClientSecret: secret}
+	}
+	return service.OAuthClientCredentials{
+		ClientID:     c.PostForm("client_id"),
+		ClientSecret: c.PostForm("client_secret"),
+	}
+}
+
+func respondOAuthError(c *gin.Context, err error) {
+	var oauthErr *service.OAuthError
+	if !errors.As(err, &oauthErr) {
+		c.JSON(http.StatusInternalServerError, dto.OAuthErrorResponse{Error: "server_error"})
+		return
+	}
+	status := http.StatusBadRequest
+	if oauthErr.Code == service.OAuthErrInvalidClient {
+		status = http.StatusUnauthorized
+		c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+	}
+	c.JSON(status, dto.OAuthErrorResponse{Error: oauthErr.Code, ErrorDescription: oauthErr.Description})
+}
+
+func respondAuthorizeError(c *gin.Context, err error) {
+	var oauthErr *service.OAuthError
+	if errors.As(err, &oauthErr) {
+		helper.RespondErrorDetails(c, http.StatusBadRequest, oauthErr.Code, dto.OAuthErrorResponse{
+			Error:            oauthErr.Code,
+			ErrorDescription: oauthErr.Description,
+		})
+		return
+	}
+	helper.RespondError(c, http.StatusInternalServerError, err.Error())
+}
+
+func mapAuthorizationRequest(req dto.OAuthAuthorizeRequest) service.AuthorizationRequest {
+	return service.AuthorizationRequest{
+		ResponseType:        req.ResponseType,
+		ClientID:            req.ClientID,
+		RedirectURI:         req.RedirectURI,
+		Scope:               req.Scope,
+		State:               req.State,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+	}
+}
+
+func mapOAuthClientResponse(client *model.OAuthClient) dto.OAuthClientResponse {
+	resp := dto.OAuthClientResponse{
+		ID:           client.ID.String(),
+		ClientID:     client.ClientID,
+		Name:         client.Name,
+		RedirectURIs: client.RedirectURIs,
+		Scopes:       client.Scopes,
+		Confidential: client.Confidential,
+		CreatedAt:    client.CreatedAt.Format(time.RFC3339),
+	}
+	if resp.RedirectURIs == nil {
+		resp.RedirectURIs = []string{}
+	}
+	if resp.Scopes == nil {
+		resp.Scopes = []string{}
+	}
+	return resp
+}
@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"wavefy-be/helper"
+	"wavefy-be/internal/dto"
+	"wavefy-be/internal/model"
+	"wavefy-be/internal/service"
+)
+
+type RoleHandler struct {
+	service service.RoleService
+}
+
+func NewRoleHandler(service service.RoleService) *RoleHandler {
+	return &RoleHandler{service: service}
+}
+
+// List godoc
+// @Summary      List roles
+// @Tags         roles
+// @Produce      json
+// @Success      200 {object} helper.Response{data=[]dto.RoleResponse}
+// @Failure      403 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /roles [get]
+func (h *RoleHandler) List(c *gin.Context) {
+	roles, err := h.service.List(c.Request.Context())
+	if err != nil {
+		helper.RespondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := make([]dto.RoleResponse, 0, len(roles))
+	for i := range roles {
+		resp = append(resp, mapRoleResponse(&roles[i]))
+	}
+	helper.RespondOK(c, resp)
+}
+
+// Create godoc
+// @Summary      Create role
+// @Tags         roles
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.CreateRoleRequest true "Create role"
+// @Success      200 {object} helper.Response{data=dto.RoleResponse}
+// @Failure      400 {object} helper.Response
+// @Failure      403 {object} helper.Response
+// @Failure      409 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /roles [post]
+func (h *RoleHandler) Create(c *gin.Context) {
+	var req dto.CreateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.RespondError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	role, err := h.service.Create(c.Request.Context(), service.CreateRoleInput{
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: req.Permissions,
+	})
+	if err != nil {
+		respondRoleError(c, err)
+		return
+	}
+
+	helper.RespondOK(c, mapRoleResponse(role))
+}
+
+// SetPermissions godoc
+// @Summary      Set role permissions
+// @Description  Replace the permissions granted to a role
+// @Tags         roles
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Role ID"
+// @Param        request body dto.SetRolePermissionsRequest true "Permissions"
+// @Success      200 {object} helper.Response{data=dto.RoleResponse}
+// @Failure      400 {object} helper.Response
+// @Failure      403 {object} helper.Response
+// @Failure      404 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /roles/{id}/permissions [put]
+func (h *RoleHandler) SetPermissions(c *gin.Context) {
+	id, err := parseUUIDParam(c, "id")
+	if err != nil {
+		helper.RespondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req dto.SetRolePermissionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.RespondError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	role, err := h.service.SetPermissions(c.Request.Context(), id, req.Permissions)
+	if err != nil {
+		respondRoleError(c, err)
+		return
+	}
+
+	helper.RespondOK(c, mapRoleResponse(role))
+}
+
+// ListPermissions godoc
+// @Summary      List permissions
+// @Tags         roles
+// @Produce      json
+// @Success      200 {object} helper.Response{data=[]dto.PermissionResponse}
+// @Failure      403 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /permissions [get]
+func (h *RoleHandler) ListPermissions(c *gin.Context) {
+	permissions, err := h.service.ListPermissions(c.Request.Context())
+	if err != nil {
+		helper.RespondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := make([]dto.PermissionResponse, 0, len(permissions))
+	for _, permission := range permissions {
+		resp = append(resp, dto.PermissionResponse{
+			Name:        permission.Name,
+			Description: permission.Description,
+		})
+	}
+	helper.RespondOK(c, resp)
+}
+
+// AssignRole godoc
+// @Summary      Assign role to user
+// @Description  Change a user's role; their current access tokens are revoked
+// @Tags         roles
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "User ID"
+// @Param        request body dto.AssignRoleRequest true "Role"
+// @Success      200 {object} helper.Response{data=dto.UserResponse}
+// @Failure      400 {object} helper.Response
+// @Failure      403 {object} helper.Response
+// @Failure      404 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /users/{id}/role [put]
+func (h *RoleHandler) AssignRole(c *gin.Context) {
+	id, err := parseUUIDParam(c, "id")
+	if err != nil {
+		helper.RespondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req dto.AssignRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.RespondError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	user, err := h.service.AssignRole(c.Request.Context(), id, req.Role)
+	if err != nil {
+		respondRoleError(c, err)
+		return
+	}
+
+	helper.RespondOK(c, mapUserResponse(user))
+}
+
+func respondRoleError(c *gin.Context, err error) {
+	switch err {
+	case service.ErrInvalidInput, service.ErrUnknownPermission:
+		helper.RespondError(c, http.StatusBadRequest, err.Error())
+	case service.ErrRoleProtected:
+		helper.RespondError(c, http.StatusForbidden, err.Error())
+	case service.ErrNotFound:
+		helper.RespondError(c, http.StatusNotFound, err.Error())
+	case service.ErrRoleExists:
+		helper.RespondError(c, http.StatusConflict, err.Error())
+	default:
+		helper.RespondError(c, http.StatusInternalServerError, err.Error())
+	}
+}
+
+func mapRoleResponse(role *model.Role) dto.RoleResponse {
+	permissions := make([]string, 0, len(role.Permissions))
+	for _, permission := range role.Permissions {
+		permissions = append(permissions, permission.Name)
+	}
+	return dto.RoleResponse{
+		ID:          role.ID.String(),
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: permissions,
+		CreatedAt:   role.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   role.UpdatedAt.Format(time.RFC3339),
+	}
+}
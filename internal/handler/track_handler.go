@@ -26,16 +26,32 @@ func NewTrackHandler(service service.TrackService, uploadService service.UploadS
 const trackKeyPrefix = "tracks/"
 const trackImageKeyPrefix = "tracks/images/"
 
-func newTrackObjectKey(contentType string) string {
+// Object keys are namespaced by the uploading user, tracks/<user id>/<file>
+// and tracks/images/<user id>/<file>, so ownership can be checked from the key.
+func newTrackObjectKey(ownerID uuid.UUID, contentType string) string {
 	base := uuid.NewString()
 	ext := trackExtFromContentType(contentType)
-	return trackKeyPrefix + base + ext
+	return trackKeyPrefix + ownerID.String() + "/" + base + ext
 }
 
-func newTrackImageObjectKey(contentType string) string {
+func newTrackImageObjectKey(ownerID uuid.UUID, contentType string) string {
 	base := uuid.NewString()
 	ext := trackImageExtFromContentType(contentType)
-	return trackImageKeyPrefix + base + ext
+	return trackImageKeyPrefix + ownerID.String() + "/" + base + ext
+}
+
+// trackKeyOwner returns the user a key is namespaced under, or uuid.Nil for
+// keys uploaded before namespacing, which only admins can manage.
+func trackKeyOwner(key, prefix string) uuid.UUID {
+	owner, _, ok := strings.Cut(strings.TrimPrefix(key, prefix), "/")
+	if !ok {
+		return uuid.Nil
+	}
+	id, err := uuid.Parse(owner)
+	if err != nil {
+		return uuid.Nil
+	}
+	return id
 }
 
 func trackExtFromContentType(contentType string) string {
@@ -86,7 +102,7 @@ func trackImageExtFromContentType(contentType string) string {
 
 func normalizeTrackKey(key string) (string, error) {
 	trimmed := strings.TrimSpace(key)
-	if trimmed == "" || !strings.HasPrefix(trimmed, trackKeyPrefix) {
+	if trimmed == "" || !strings.HasPrefix(trimmed, trackKeyPrefix) || strings.HasPrefix(trimmed, trackImageKeyPrefix) {
 		return "", service.ErrInvalidInput
 	}
 	return trimmed, nil
@@ -112,6 +128,12 @@ func normalizeTrackImageKey(key string) (string, error) {
 // @Failure      500 {object} helper.Response
 // @Router       /tracks/audio/presign [post]
 func (h *TrackHandler) PresignPut(c *gin.Context) {
+	actor, err := authActor(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
 	var req dto.PresignTrackPutRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		helper.RespondError(c, http.StatusBadRequest, "invalid request body")
@@ -119,7 +141,7 @@ func (h *TrackHandler) PresignPut(c *gin.Context) {
 	}
 
 	out, err := h.uploadService.PresignPut(c.Request.Context(), service.PresignPutInput{
-		Key:          newTrackObjectKey(req.ContentType),
+		Key:          newTrackObjectKey(actor.UserID, req.ContentType),
 		ContentType:  req.ContentType,
 		ExpiresInSec: req.ExpiresInSec,
 	})
@@ -203,10 +225,17 @@ func (h *TrackHandler) PresignGet(c *gin.Context) {
 // @Param        request body dto.DeleteObjectRequest true "Delete object"
 // @Success      200 {object} helper.Response{data=dto.DeleteObjectResponse}
 // @Failure      400 {object} helper.Response
+// @Failure      403 {object} helper.Response
 // @Failure      503 {object} helper.Response
 // @Failure      500 {object} helper.Response
 // @Router       /tracks/audio/delete [post]
 func (h *TrackHandler) DeleteObject(c *gin.Context) {
+	actor, err := authActor(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
 	var req dto.DeleteObjectRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		helper.RespondError(c, http.StatusBadRequest, "invalid request body")
@@ -218,6 +247,10 @@ func (h *TrackHandler) DeleteObject(c *gin.Context) {
 		helper.RespondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
+	if !actor.CanManage(trackKeyOwner(key, trackKeyPrefix), model.PermissionTracksManage) {
+		helper.RespondError(c, http.StatusForbidden, service.ErrForbidden.Error())
+		return
+	}
 
 	out, err := h.uploadService.DeleteObject(c.Request.Context(), service.DeleteObjectInput{
 		Key: key,
@@ -253,6 +286,12 @@ func (h *TrackHandler) DeleteObject(c *gin.Context) {
 // @Failure      500 {object} helper.Response
 // @Router       /tracks/image/presign [post]
 func (h *TrackHandler) PresignImagePut(c *gin.Context) {
+	actor, err := authActor(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
 	var req dto.PresignTrackImagePutRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		helper.RespondError(c, http.StatusBadRequest, "invalid request body")
@@ -260,7 +299,7 @@ func (h *TrackHandler) PresignImagePut(c *gin.Context) {
 	}
 
 	out, err := h.uploadService.PresignPut(c.Request.Context(), service.PresignPutInput{
-		Key:          newTrackImageObjectKey(req.ContentType),
+		Key:          newTrackImageObjectKey(actor.UserID, req.ContentType),
 		ContentType:  req.ContentType,
 		ExpiresInSec: req.ExpiresInSec,
 	})
@@ -344,10 +383,17 @@ func (h *TrackHandler) PresignImageGet(c *gin.Context) {
 // @Param        request body dto.DeleteObjectRequest true "Delete object"
 // @Success      200 {object} helper.Response{data=dto.DeleteObjectResponse}
 // @Failure      400 {object} helper.Response
+// @Failure      403 {object} helper.Response
 // @Failure      503 {object} helper.Response
 // @Failure      500 {object} helper.Response
 // @Router       /tracks/image/delete [post]
 func (h *TrackHandler) DeleteImageObject(c *gin.Context) {
+	actor, err := authActor(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
 	var req dto.DeleteObjectRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		helper.RespondError(c, http.StatusBadRequest, "invalid request body")
@@ -359,6 +405,10 @@ func (h *TrackHandler) DeleteImageObject(c *gin.Context) {
 		helper.RespondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
+	if !actor.CanManage(trackKeyOwner(key, trackImageKeyPrefix), model.PermissionTracksManage) {
+		helper.RespondError(c, http.StatusForbidden, service.ErrForbidden.Error())
+		return
+	}
 
 	out, err := h.uploadService.DeleteObject(c.Request.Context(), service.DeleteObjectInput{
 		Key: key,
@@ -448,16 +498,23 @@ func (h *TrackHandler) List(c *gin.Context) {
 // @Param        request body dto.CreateTrackRequest true "Create track"
 // @Success      200 {object} helper.Response{data=dto.TrackResponse}
 // @Failure      400 {object} helper.Response
+// @Failure      403 {object} helper.Response
 // @Failure      500 {object} helper.Response
 // @Router       /tracks [post]
 func (h *TrackHandler) Create(c *gin.Context) {
+	actor, err := authActor(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
 	var req dto.CreateTrackRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		helper.RespondError(c, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
-	track, err := h.service.Create(c.Request.Context(), service.CreateTrackInput{
+	track, err := h.service.Create(c.Request.Context(), actor, service.CreateTrackInput{
 		ArtistUserID: req.ArtistUserID,
 		AlbumID:      req.AlbumID,
 		Title:        req.Title,
@@ -470,6 +527,8 @@ func (h *TrackHandler) Create(c *gin.Context) {
 		switch err {
 		case service.ErrInvalidInput:
 			helper.RespondError(c, http.StatusBadRequest, err.Error())
+		case service.ErrForbidden:
+			helper.RespondError(c, http.StatusForbidden, err.Error())
 		default:
 			helper.RespondError(c, http.StatusInternalServerError, err.Error())
 		}
@@ -488,10 +547,17 @@ func (h *TrackHandler) Create(c *gin.Context) {
 // @Param        request body dto.UpdateTrackRequest true "Update track"
 // @Success      200 {object} helper.Response{data=dto.TrackResponse}
 // @Failure      400 {object} helper.Response
+// @Failure      403 {object} helper.Response
 // @Failure      404 {object} helper.Response
 // @Failure      500 {object} helper.Response
 // @Router       /tracks/{id} [patch]
 func (h *TrackHandler) Update(c *gin.Context) {
+	actor, err := authActor(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
 	id, err := parseUUIDParam(c, "id")
 	if err != nil {
 		helper.RespondError(c, http.StatusBadRequest, err.Error())
@@ -504,7 +570,7 @@ func (h *TrackHandler) Update(c *gin.Context) {
 		return
 	}
 
-	track, err := h.service.Update(c.Request.Context(), id, service.UpdateTrackInput{
+	track, err := h.service.Update(c.Request.Context(), actor, id, service.UpdateTrackInput{
 		AlbumID:     req.AlbumID,
 		Title:       req.Title,
 		AudioURL:    req.AudioURL,
@@ -516,6 +582,8 @@ func (h *TrackHandler) Update(c *gin.Context) {
 		switch err {
 		case service.ErrInvalidInput:
 			helper.RespondError(c, http.StatusBadRequest, err.Error())
+		case service.ErrForbidden:
+			helper.RespondError(c, http.StatusForbidden, err.Error())
 		case service.ErrNotFound:
 			helper.RespondError(c, http.StatusNotFound, err.Error())
 		default:
@@ -534,18 +602,27 @@ func (h *TrackHandler) Update(c *gin.Context) {
 // @Param        id path string true "Track ID"
 // @Success      200 {object} helper.Response
 // @Failure      400 {object} helper.Response
+// @Failure      403 {object} helper.Response
 // @Failure      404 {object} helper.Response
 // @Failure      500 {object} helper.Response
 // @Router       /tracks/{id} [delete]
 func (h *TrackHandler) Delete(c *gin.Context) {
+	actor, err := authActor(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
 	id, err := parseUUIDParam(c, "id")
 	if err != nil {
 		helper.RespondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	if err := h.service.Delete(c.Request.Context(), id); err != nil {
+	if err := h.service.Delete(c.Request.Context(), actor, id); err != nil {
 		switch err {
+		case service.ErrForbidden:
+			helper.RespondError(c, http.StatusForbidden, err.Error())
 		case service.ErrNotFound:
 			helper.RespondError(c, http.StatusNotFound, err.Error())
 		default:
@@ -12,6 +12,7 @@ import (
 	"wavefy-be/helper"
 	"wavefy-be/internal/dto"
 	"wavefy-be/internal/model"
+	"wavefy-be/internal/password"
 	"wavefy-be/internal/service"
 )
 
@@ -30,19 +31,28 @@ func NewUserHandler(service service.UserService) *UserHandler {
 // @Param        id path string true "User ID"
 // @Success      200 {object} helper.Response{data=dto.UserResponse}
 // @Failure      400 {object} helper.Response
+// @Failure      403 {object} helper.Response
 // @Failure      404 {object} helper.Response
 // @Failure      500 {object} helper.Response
 // @Router       /users/{id} [get]
 func (h *UserHandler) Get(c *gin.Context) {
+	actor, err := authActor(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
 	id, err := parseUUIDParam(c, "id")
 	if err != nil {
 		helper.RespondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	user, err := h.service.Get(c.Request.Context(), id)
+	user, err := h.service.Get(c.Request.Context(), actor, id)
 	if err != nil {
 		switch err {
+		case service.ErrForbidden:
+			helper.RespondError(c, http.StatusForbidden, err.Error())
 		case service.ErrNotFound:
 			helper.RespondError(c, http.StatusNotFound, err.Error())
 		default:
@@ -61,6 +71,7 @@ func (h *UserHandler) Get(c *gin.Context) {
 // @Param        limit query int false "Limit" default(20)
 // @Param        offset query int false "Offset" default(0)
 // @Success      200 {object} helper.Response{data=[]dto.UserResponse}
+// @Failure      403 {object} helper.Response
 // @Failure      500 {object} helper.Response
 // @Router       /users [get]
 func (h *UserHandler) List(c *gin.Context) {
@@ -90,11 +101,18 @@ func (h *UserHandler) List(c *gin.Context) {
 // @Param        request body dto.UpdateUserRequest true "Update user"
 // @Success      200 {object} helper.Response{data=dto.UserResponse}
 // @Failure      400 {object} helper.Response
+// @Failure      403 {object} helper.Response
 // @Failure      404 {object} helper.Response
 // @Failure      409 {object} helper.Response
 // @Failure      500 {object} helper.Response
 // @Router       /users/{id} [patch]
 func (h *UserHandler) Update(c *gin.Context) {
+	actor, err := authActor(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
 	id, err := parseUUIDParam(c, "id")
 	if err != nil {
 		helper.RespondError(c, http.StatusBadRequest, err.Error())
@@ -107,18 +125,23 @@ func (h *UserHandler) Update(c *gin.Context) {
 		return
 	}
 
-	user, err := h.service.Update(c.Request.Context(), id, service.UpdateUserInput{
+	user, err := h.service.Update(c.Request.Context(), actor, id, service.UpdateUserInput{
 		FirstName: req.FirstName,
 		LastName:  req.LastName,
 		Email:     req.Email,
 		Password:  req.Password,
 	})
 	if err != nil {
+		if respondPasswordPolicy(c, err) {
+			return
+		}
 		switch err {
-		case service.ErrInvalidInput:
+		case service.ErrInvalidInput, service.ErrEmailChangeNeedsConfirmation:
 			helper.RespondError(c, http.StatusBadRequest, err.Error())
 		case service.ErrEmailExists:
 			helper.RespondError(c, http.StatusConflict, err.Error())
+		case service.ErrForbidden:
+			helper.RespondError(c, http.StatusForbidden, err.Error())
 		case service.ErrNotFound:
 			helper.RespondError(c, http.StatusNotFound, err.Error())
 		default:
@@ -132,23 +155,33 @@ func (h *UserHandler) Update(c *gin.Context) {
 
 // Delete godoc
 // @Summary      Delete user
+// @Description  Delete the user at once; the account, their tracks and uploads are purged for good once the deletion grace period has passed
 // @Tags         users
 // @Produce      json
 // @Param        id path string true "User ID"
 // @Success      200 {object} helper.Response
 // @Failure      400 {object} helper.Response
+// @Failure      403 {object} helper.Response
 // @Failure      404 {object} helper.Response
 // @Failure      500 {object} helper.Response
 // @Router       /users/{id} [delete]
 func (h *UserHandler) Delete(c *gin.Context) {
+	actor, err := authActor(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
 	id, err := parseUUIDParam(c, "id")
 	if err != nil {
 		helper.RespondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	if err := h.service.Delete(c.Request.Context(), id); err != nil {
+	if err := h.service.Delete(c.Request.Context(), actor, id); err != nil {
 		switch err {
+		case service.ErrForbidden:
+			helper.RespondError(c, http.StatusForbidden, err.Error())
 		case service.ErrNotFound:
 			helper.RespondError(c, http.StatusNotFound, err.Error())
 		default:
@@ -160,6 +193,102 @@ func (h *UserHandler) Delete(c *gin.Context) {
 	helper.RespondOK(c, gin.H{"deleted": true})
 }
 
+// Suspend godoc
+// @Summary      Suspend or ban user
+// @Description  Suspend the user until the given time, or until reinstated without one; with ban set the user is banned. Sign-ins and requests with the user's tokens are refused with the reason, and the user's sessions, API keys and app grants are revoked
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "User ID"
+// @Param        request body dto.SuspendUserRequest true "Suspension"
+// @Success      200 {object} helper.Response{data=dto.UserResponse}
+// @Failure      400 {object} helper.Response
+// @Failure      403 {object} helper.Response
+// @Failure      404 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /users/{id}/suspension [post]
+func (h *UserHandler) Suspend(c *gin.Context) {
+	actor, err := authActor(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	id, err := parseUUIDParam(c, "id")
+	if err != nil {
+		helper.RespondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req dto.SuspendUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.RespondError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	user, err := h.service.Suspend(c.Request.Context(), actor, id, service.SuspendUserInput{
+		Reason: req.Reason,
+		Until:  req.Until,
+		Ban:    req.Ban,
+	})
+	if err != nil {
+		respondSuspensionError(c, err)
+		return
+	}
+
+	helper.RespondOK(c, mapUserResponse(user))
+}
+
+// Reinstate godoc
+// @Summary      Reinstate user
+// @Description  Lift the user's suspension or ban
+// @Tags         users
+// @Produce      json
+// @Param        id path string true "User ID"
+// @Success      200 {object} helper.Response{data=dto.UserResponse}
+// @Failure      400 {object} helper.Response
+// @Failure      403 {object} helper.Response
+// @Failure      404 {object} helper.Response
+// @Failure      409 {object} helper.Response
+// @Failure      500 {object} helper.Response
+// @Router       /users/{id}/suspension [delete]
+func (h *UserHandler) Reinstate(c *gin.Context) {
+	actor, err := authActor(c)
+	if err != nil {
+		helper.RespondError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	id, err := parseUUIDParam(c, "id")
+	if err != nil {
+		helper.RespondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	user, err := h.service.Reinstate(c.Request.Context(), actor, id)
+	if err != nil {
+		respondSuspensionError(c, err)
+		return
+	}
+
+	helper.RespondOK(c, mapUserResponse(user))
+}
+
+func respondSuspensionError(c *gin.Context, err error) {
+	switch err {
+	case service.ErrInvalidInput:
+		helper.RespondError(c, http.StatusBadRequest, err.Error())
+	case service.ErrForbidden:
+		helper.RespondError(c, http.StatusForbidden, err.Error())
+	case service.ErrNotFound:
+		helper.RespondError(c, http.StatusNotFound, err.Error())
+	case service.ErrNotSuspended:
+		helper.RespondError(c, http.StatusConflict, err.Error())
+	default:
+		helper.RespondError(c, http.StatusInternalServerError, err.Error())
+	}
+}
+
 // Create godoc
 // @Summary      Create user
 // @Description  Create a new user with email and password
@@ -169,6 +298,7 @@ func (h *UserHandler) Delete(c *gin.Context) {
 // @Param        request body dto.CreateUserRequest true "Create user"
 // @Success      200 {object} helper.Response{data=dto.UserResponse}
 // @Failure      400 {object} helper.Response
+// @Failure      403 {object} helper.Response
 // @Failure      409 {object} helper.Response
 // @Failure      500 {object} helper.Response
 // @Router       /users [post]
@@ -184,6 +314,9 @@ func (h *UserHandler) Create(c *gin.Context) {
 		Password: req.Password,
 	})
 	if err != nil {
+		if respondPasswordPolicy(c, err) {
+			return
+		}
 		switch {
 		case err == service.ErrInvalidInput:
 			helper.RespondError(c, http.StatusBadRequest, err.Error())
@@ -202,22 +335,56 @@ func (h *UserHandler) Create(c *gin.Context) {
 		Email:     user.Email,
 		Role:      user.Role.Name,
 		IsActive:  user.IsActive,
+		Status:    user.Status,
 		CreatedAt: user.CreatedAt.Format(time.RFC3339),
 		UpdatedAt: user.UpdatedAt.Format(time.RFC3339),
 	})
 }
 
 func mapUserResponse(user *model.User) dto.UserResponse {
-	return dto.UserResponse{
-		ID:        user.ID.String(),
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Email:     user.Email,
-		Role:      user.Role.Name,
-		IsActive:  user.IsActive,
-		CreatedAt: user.CreatedAt.Format(time.RFC3339),
-		UpdatedAt: user.UpdatedAt.Format(time.RFC3339),
+	resp := dto.UserResponse{
+		ID:         user.ID.String(),
+		FirstName:  user.FirstName,
+		LastName:   user.LastName,
+		Email:      user.Email,
+		Role:       user.Role.Name,
+		IsActive:   user.IsActive,
+		Status:     user.Status,
+		Reason:     user.StatusReason,
+		MFAEnabled: user.TOTPEnabled,
+		CreatedAt:  user.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:  user.UpdatedAt.Format(time.RFC3339),
+	}
+	if user.SuspendUntil != nil {
+		resp.Until = user.SuspendUntil.Format(time.RFC3339)
+	}
+	if user.PurgeAt != nil {
+		resp.PurgeAt = user.PurgeAt.Format(time.RFC3339)
+	}
+	return resp
+}
+
+// authActor builds the service actor from the claims stored by JWTAuth.
+func authActor(c *gin.Context) (service.Actor, error) {
+	userID, err := authSubject(c)
+	if err != nil {
+		return service.Actor{}, err
+	}
+	return service.Actor{
+		UserID:      userID,
+		Role:        c.GetString("auth_role"),
+		Permissions: c.GetStringSlice("auth_permissions"),
+	}, nil
+}
+
+// respondPasswordPolicy writes the field errors of a rejected password.
+func respondPasswordPolicy(c *gin.Context, err error) bool {
+	var policyErr *password.ValidationError
+	if !errors.As(err, &policyErr) {
+		return false
 	}
+	helper.RespondValidationError(c, policyErr.Error(), policyErr.Fields)
+	return true
 }
 
 func parseUUIDParam(c *gin.Context, key string) (uuid.UUID, error) {
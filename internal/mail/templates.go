@@ -4,9 +4,10 @@ import (
 	"bytes"
 	"embed"
 	"html/template"
+	"time"
 )
 
-//go:embed templates/reset_password.html templates/verify_email.html
+//go:embed templates/reset_password.html templates/verify_email.html templates/magic_link.html templates/email_change_confirm.html templates/email_change_notice.html templates/new_device_alert.html templates/account_deletion.html templates/data_export.html templates/reauth.html templates/device_report_reset.html
 var templatesFS embed.FS
 
 var resetPasswordTemplate = template.Must(
@@ -17,6 +18,38 @@ var verifyEmailTemplate = template.Must(
 	template.ParseFS(templatesFS, "templates/verify_email.html"),
 )
 
+var magicLinkTemplate = template.Must(
+	template.ParseFS(templatesFS, "templates/magic_link.html"),
+)
+
+var emailChangeConfirmTemplate = template.Must(
+	template.ParseFS(templatesFS, "templates/email_change_confirm.html"),
+)
+
+var emailChangeNoticeTemplate = template.Must(
+	template.ParseFS(templatesFS, "templates/email_change_notice.html"),
+)
+
+var newDeviceAlertTemplate = template.Must(
+	template.ParseFS(templatesFS, "templates/new_device_alert.html"),
+)
+
+var accountDeletionTemplate = template.Must(
+	template.ParseFS(templatesFS, "templates/account_deletion.html"),
+)
+
+var dataExportTemplate = template.Must(
+	template.ParseFS(templatesFS, "templates/data_export.html"),
+)
+
+var reauthTemplate = template.Must(
+	template.ParseFS(templatesFS, "templates/reauth.html"),
+)
+
+var deviceReportResetTemplate = template.Must(
+	template.ParseFS(templatesFS, "templates/device_report_reset.html"),
+)
+
 type resetPasswordData struct {
 	ResetURL string
 }
@@ -40,3 +73,145 @@ func RenderVerifyEmailHTML(verifyURL string) (string, error) {
 	}
 	return buf.String(), nil
 }
+
+type magicLinkData struct {
+	LoginURL         string
+	ExpiresInMinutes int
+}
+
+func RenderMagicLinkHTML(loginURL string, expiresIn time.Duration) (string, error) {
+	var buf bytes.Buffer
+	data := magicLinkData{LoginURL: loginURL, ExpiresInMinutes: int(expiresIn.Minutes())}
+	if err := magicLinkTemplate.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+type emailChangeConfirmData struct {
+	ConfirmURL string
+	NewEmail   string
+}
+
+func RenderEmailChangeConfirmHTML(confirmURL, newEmail string) (string, error) {
+	var buf bytes.Buffer
+	data := emailChangeConfirmData{ConfirmURL: confirmURL, NewEmail: newEmail}
+	if err := emailChangeConfirmTemplate.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+type emailChangeNoticeData struct {
+	RevertURL       string
+	OldEmail        string
+	NewEmail        string
+	RevertValidDays int
+}
+
+func RenderEmailChangeNoticeHTML(revertURL, oldEmail, newEmail string, revertValidFor time.Duration) (string, error) {
+	var buf bytes.Buffer
+	data := emailChangeNoticeData{
+		RevertURL:       revertURL,
+		OldEmail:        oldEmail,
+		NewEmail:        newEmail,
+		RevertValidDays: int(revertValidFor.Hours() / 24),
+	}
+	if err := emailChangeNoticeTemplate.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// DeviceReportRemovals counts what was taken off an account after a device
+// report, so the owner knows what to set up again.
+type DeviceReportRemovals struct {
+	Passkeys   int64
+	Identities int64
+	APIKeys    int64
+	Grants     int64
+}
+
+type deviceReportResetData struct {
+	ResetURL string
+	DeviceReportRemovals
+}
+
+func RenderDeviceReportResetHTML(resetURL string, removed DeviceReportRemovals) (string, error) {
+	var buf bytes.Buffer
+	data := deviceReportResetData{ResetURL: resetURL, DeviceReportRemovals: removed}
+	if err := deviceReportResetTemplate.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+type newDeviceAlertData struct {
+	ReportURL       string
+	UserAgent       string
+	IP              string
+	SignedInAt      string
+	ReportValidDays int
+}
+
+func RenderNewDeviceAlertHTML(reportURL, userAgent, ip string, signedInAt time.Time, reportValidFor time.Duration) (string, error) {
+	var buf bytes.Buffer
+	data := newDeviceAlertData{
+		ReportURL:       reportURL,
+		UserAgent:       userAgent,
+		IP:              ip,
+		SignedInAt:      signedInAt.UTC().Format("15:04 02/01/2006 (UTC)"),
+		ReportValidDays: int(reportValidFor.Hours() / 24),
+	}
+	if err := newDeviceAlertTemplate.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+type accountDeletionData struct {
+	CancelURL string
+	PurgeAt   string
+	GraceDays int
+}
+
+func RenderAccountDeletionHTML(cancelURL string, purgeAt time.Time, grace time.Duration) (string, error) {
+	var buf bytes.Buffer
+	data := accountDeletionData{
+		CancelURL: cancelURL,
+		PurgeAt:   purgeAt.UTC().Format("02/01/2006 (UTC)"),
+		GraceDays: int(grace.Hours() / 24),
+	}
+	if err := accountDeletionTemplate.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+type dataExportData struct {
+	DownloadURL      string
+	ExpiresInMinutes int
+}
+
+func RenderDataExportHTML(downloadURL string, expiresIn time.Duration) (string, error) {
+	var buf bytes.Buffer
+	data := dataExportData{DownloadURL: downloadURL, ExpiresInMinutes: int(expiresIn.Minutes())}
+	if err := dataExportTemplate.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+type reauthData struct {
+	ConfirmURL       string
+	ExpiresInMinutes int
+}
+
+func RenderReauthHTML(confirmURL string, expiresIn time.Duration) (string, error) {
+	var buf bytes.Buffer
+	data := reauthData{ConfirmURL: confirmURL, ExpiresInMinutes: int(expiresIn.Minutes())}
+	if err := reauthTemplate.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,43 @@
+package mfa
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	RecoveryCodeCount = 10
+	recoveryAlphabet  = "abcdefghjkmnpqrstuvwxyz023456789"
+)
+
+// GenerateRecoveryCodes returns n one-time codes formatted as xxxxx-xxxxx.
+func GenerateRecoveryCodes(n int) ([]string, error) {
+	codes := make([]string, 0, n)
+	buf := make([]byte, 10)
+	for i := 0; i < n; i++ {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		var b strings.Builder
+		for j, v := range buf {
+			if j == 5 {
+				b.WriteByte('-')
+			}
+			b.WriteByte(recoveryAlphabet[int(v)%len(recoveryAlphabet)])
+		}
+		codes = append(codes, b.String())
+	}
+	return codes, nil
+}
+
+// HashRecoveryCode normalises a code as typed by the user and hashes it for
+// storage and lookup.
+func HashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.TrimSpace(code))
+	normalized = strings.ReplaceAll(normalized, "-", "")
+	normalized = strings.ReplaceAll(normalized, " ", "")
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,30 @@
+package mfa
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNewCipherSecretLength(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret string
+		want   error
+	}{
+		{name: "empty", secret: "", want: ErrSecretTooShort},
+		{name: "short placeholder", secret: "change-me", want: ErrSecretTooShort},
+		{name: "base64 of 16 bytes", secret: "q83vEjRWeJq83vEjRWeJqw==", want: ErrSecretTooShort},
+		{name: "hex of 16 bytes", secret: strings.Repeat("ab", 16), want: ErrSecretTooShort},
+		{name: "base64 of 32 bytes", secret: "3q2+7wEjRWeJq83vASNFZ4mrze8BI0VniavN7wEjRWc="},
+		{name: "hex of 32 bytes", secret: strings.Repeat("ab", 32)},
+		{name: "long passphrase", secret: "a passphrase of well over thirty-two bytes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewCipher(tt.secret); !errors.Is(err, tt.want) {
+				t.Fatalf("NewCipher: got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole lets the request through only when the role stored by JWTAuth
+// is one of roles. It must run after JWTAuth.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(roles))
+	for _, role := range roles {
+		allowed[role] = true
+	}
+	return func(c *gin.Context) {
+		if !allowed[c.GetString("auth_role")] {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"status": "error",
+				"code":   http.StatusForbidden,
+				"error":  "forbidden",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequirePermission lets the request through only when the access token
+// carries every one of permissions. It must run after JWTAuth.
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		granted := map[string]bool{}
+		for _, permission := range c.GetStringSlice("auth_permissions") {
+			granted[permission] = true
+		}
+		for _, permission := range permissions {
+			if !granted[permission] {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+					"status": "error",
+					"code":   http.StatusForbidden,
+					"error":  "forbidden",
+				})
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// RequireScope lets a partner app's OAuth token or an API key through only
+// when it carries every one of scopes. Sessions are not limited by scopes and
+// pass. It must run after JWTAuth.
+func RequireScope(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("auth_client_id") == "" && c.GetString("auth_api_key_id") == "" {
+			c.Next()
+			return
+		}
+		granted := map[string]bool{}
+		for _, scope := range c.GetStringSlice("auth_scopes") {
+			granted[scope] = true
+		}
+		for _, scope := range scopes {
+			if !granted[scope] {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+					"status": "error",
+					"code":   http.StatusForbidden,
+					"error":  "insufficient scope",
+				})
+				return
+			}
+		}
+		c.Next()
+	}
+}
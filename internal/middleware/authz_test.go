@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		clientID string
+		apiKeyID string
+		scopes   []string
+		want     int
+	}{
+		{name: "session", want: http.StatusOK},
+		{name: "partner app with scope", clientID: "app", scopes: []string{"tracks:write"}, want: http.StatusOK},
+		{name: "partner app without scope", clientID: "app", scopes: []string{"tracks:read"}, want: http.StatusForbidden},
+		{name: "api key with scope", apiKeyID: "key", scopes: []string{"tracks:read", "tracks:write"}, want: http.StatusOK},
+		{name: "api key without scope", apiKeyID: "key", scopes: []string{"tracks:read"}, want: http.StatusForbidden},
+		{name: "api key without scopes", apiKeyID: "key", want: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.PATCH("/tracks/:id", func(c *gin.Context) {
+				if tt.clientID != "" {
+					c.Set("auth_client_id", tt.clientID)
+				}
+				if tt.apiKeyID != "" {
+					c.Set("auth_api_key_id", tt.apiKeyID)
+				}
+				c.Set("auth_scopes", tt.scopes)
+			}, RequireScope("tracks:write"), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPatch, "/tracks/1", nil))
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
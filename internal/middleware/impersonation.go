@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImpersonationAuditor records a request made with an impersonation token.
+type ImpersonationAuditor interface {
+	RecordImpersonatedRequest(ctx context.Context, actorID, userID, method, path string, status int, ip, userAgent string)
+}
+
+// AuditImpersonation records every request made with an impersonation token,
+// including refused ones, once it has been handled. It must run right after
+// JWTAuth.
+func AuditImpersonation(auditor ImpersonationAuditor) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorID := c.GetString("auth_actor")
+		if actorID == "" || auditor == nil {
+			c.Next()
+			return
+		}
+
+		c.Next()
+		auditor.RecordImpersonatedRequest(
+			c.Request.Context(),
+			actorID,
+			c.GetString("auth_subject"),
+			c.Request.Method,
+			c.Request.URL.Path,
+			c.Writer.Status(),
+			c.ClientIP(),
+			c.Request.UserAgent(),
+		)
+	}
+}
@@ -1,21 +1,40 @@
 package middleware
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 
 	"wavefy-be/config"
 	"wavefy-be/internal/token"
 )
 
-func JWTAuth(cfg config.AuthConfig) gin.HandlerFunc {
-	secret := []byte(cfg.JWTSecret)
+const apiKeyHeader = "X-API-Key"
 
+// APIKeyAuthenticator resolves an API key sent in place of an access token.
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, key string) (*token.APIKeyPrincipal, error)
+}
+
+// JWTAuth authenticates the request with an access token. When apiKeys is
+// set it also accepts an API key, in the X-API-Key header or as a bearer
+// token with the key prefix; such requests get auth_api_key_id and
+// auth_scopes set. Tokens issued to a partner app through OAuth get
+// auth_client_id and auth_scopes;
+// impersonation tokens get auth_actor, the staff member behind the request.
+// Requests of suspended and banned accounts listed in statuses are refused
+// with the reason.
+func JWTAuth(cfg config.AuthConfig, keys *token.KeyRing, revocations token.AccessTokenRevocationStore, statuses token.AccountStatusStore, apiKeys APIKeyAuthenticator) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if key := extractAPIKey(c); key != "" {
+			authenticateAPIKey(c, apiKeys, statuses, key)
+			return
+		}
+
 		tokenStr, err := extractBearerToken(c.GetHeader("Authorization"))
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
@@ -26,37 +45,189 @@ func JWTAuth(cfg config.AuthConfig) gin.HandlerFunc {
 			return
 		}
 
-		claims := &token.AccessTokenClaims{}
-		token, err := jwt.ParseWithClaims(tokenStr, claims, func(t *jwt.Token) (interface{}, error) {
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, errors.New("invalid signing method")
-			}
-			return secret, nil
-		})
-		if err != nil || !token.Valid {
+		claims, err := token.ParseAccessToken(cfg, keys, tokenStr)
+		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 				"status": "error",
 				"code":   http.StatusUnauthorized,
-				"error":  "invalid token",
+				"error":  err.Error(),
 			})
 			return
 		}
 
-		if cfg.AccessTokenIss != "" && claims.Issuer != cfg.AccessTokenIss {
+		if revocations != nil {
+			revoked, err := revocations.IsRevoked(c.Request.Context(), claims)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+					"status": "error",
+					"code":   http.StatusInternalServerError,
+					"error":  "internal error",
+				})
+				return
+			}
+			if revoked {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+					"status": "error",
+					"code":   http.StatusUnauthorized,
+					"error":  "token revoked",
+				})
+				return
+			}
+		}
+		if rejectBlockedAccount(c, statuses, claims.Subject) {
+			return
+		}
+
+		c.Set("auth_subject", claims.Subject)
+		c.Set("auth_role", claims.Role)
+		c.Set("auth_permissions", claims.Permissions)
+		c.Set("auth_token_id", claims.ID)
+		if claims.Delegated() {
+			c.Set("auth_client_id", claims.ClientID)
+			c.Set("auth_scopes", strings.Fields(claims.Scope))
+		}
+		if claims.Impersonated() {
+			c.Set("auth_actor", claims.Actor.Subject)
+		}
+		c.Next()
+	}
+}
+
+func authenticateAPIKey(c *gin.Context, apiKeys APIKeyAuthenticator, statuses token.AccountStatusStore, key string) {
+	if apiKeys == nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"status": "error",
+			"code":   http.StatusUnauthorized,
+			"error":  "api keys are not accepted here",
+		})
+		return
+	}
+
+	principal, err := apiKeys.Authenticate(c.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, token.ErrInvalidAPIKey) {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 				"status": "error",
 				"code":   http.StatusUnauthorized,
-				"error":  "invalid token issuer",
+				"error":  err.Error(),
 			})
 			return
 		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"status": "error",
+			"code":   http.StatusInternalServerError,
+			"error":  "internal error",
+		})
+		return
+	}
+	if rejectBlockedAccount(c, statuses, principal.Subject) {
+		return
+	}
 
-		c.Set("auth_subject", claims.Subject)
-		c.Set("auth_role", claims.Role)
+	c.Set("auth_subject", principal.Subject)
+	c.Set("auth_role", principal.Role)
+	c.Set("auth_permissions", principal.Permissions)
+	c.Set("auth_api_key_id", principal.KeyID)
+	c.Set("auth_scopes", principal.Scopes)
+	c.Next()
+}
+
+// rejectBlockedAccount answers 403 with an account_suspended or
+// account_banned code when the subject's account may not be used.
+func rejectBlockedAccount(c *gin.Context, statuses token.AccountStatusStore, subject string) bool {
+	if statuses == nil {
+		return false
+	}
+	block, err := statuses.Get(c.Request.Context(), subject)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"status": "error",
+			"code":   http.StatusInternalServerError,
+			"error":  "internal error",
+		})
+		return true
+	}
+	if block == nil {
+		return false
+	}
+	details := gin.H{
+		"code":   "account_" + block.Status,
+		"reason": block.Reason,
+	}
+	if block.Until != nil {
+		details["until"] = block.Until.UTC().Format(time.RFC3339)
+	}
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+		"status":  "error",
+		"code":    http.StatusForbidden,
+		"error":   "account " + block.Status,
+		"details": details,
+	})
+	return true
+}
+
+// RequireSession rejects requests authenticated with an API key, with a
+// partner app's OAuth token or with an impersonation token, for routes that
+// manage the account itself. It must run after JWTAuth.
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if rejectImpersonation(c) {
+			return
+		}
+		if c.GetString("auth_api_key_id") != "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"status": "error",
+				"code":   http.StatusForbidden,
+				"error":  "api keys cannot be used here",
+			})
+			return
+		}
+		if c.GetString("auth_client_id") != "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"status": "error",
+				"code":   http.StatusForbidden,
+				"error":  "oauth tokens cannot be used here",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// DenyImpersonation rejects impersonation tokens on routes that are not
+// session-only but must not be used while acting as someone else. It must
+// run after JWTAuth.
+func DenyImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if rejectImpersonation(c) {
+			return
+		}
 		c.Next()
 	}
 }
 
+func rejectImpersonation(c *gin.Context) bool {
+	if c.GetString("auth_actor") == "" {
+		return false
+	}
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+		"status": "error",
+		"code":   http.StatusForbidden,
+		"error":  "not allowed while impersonating",
+	})
+	return true
+}
+
+func extractAPIKey(c *gin.Context) string {
+	if key := strings.TrimSpace(c.GetHeader(apiKeyHeader)); key != "" {
+		return key
+	}
+	if value, err := extractBearerToken(c.GetHeader("Authorization")); err == nil && token.IsAPIKey(value) {
+		return value
+	}
+	return ""
+}
+
 func extractBearerToken(value string) (string, error) {
 	if value == "" {
 		return "", errors.New("missing authorization header")
@@ -1,52 +1,61 @@
 package middleware
 
 import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/redis/go-redis/v9"
 
+	"wavefy-be/config"
 	"wavefy-be/helper"
+	"wavefy-be/internal/token"
 )
 
-const (
-	loginRateLimitMax = int64(5)
-	loginRateLimitTTL = 60 * time.Second
-	loginRateLimitKey = "login:ip:"
-)
+const loginRateLimitMaxBody = 1 << 16
 
-func LoginRateLimit(client *redis.Client) gin.HandlerFunc {
+// LoginRateLimit limits login style endpoints per client IP and, when the
+// JSON body carries an email, per email and per IP and email pair. Every
+// response gets RateLimit-* headers; refused requests also get Retry-After.
+// The returned handler shares the limiter's counters across the routes it is
+// attached to.
+func LoginRateLimit(limiter token.RateLimiter, cfg config.RateLimitConfig) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if client == nil {
-			c.Next()
-			return
-		}
-
 		ip := strings.TrimSpace(c.ClientIP())
-		if ip == "" {
+		email := peekEmail(c)
+
+		var keys []token.RateLimitKey
+		if ip != "" && cfg.IPLimit > 0 {
+			keys = append(keys, token.RateLimitKey{Name: "ip:" + ip, Limit: cfg.IPLimit})
+		}
+		if email != "" && cfg.EmailLimit > 0 {
+			keys = append(keys, token.RateLimitKey{Name: "email:" + email, Limit: cfg.EmailLimit})
+		}
+		if ip != "" && email != "" && cfg.IPEmailLimit > 0 {
+			keys = append(keys, token.RateLimitKey{Name: "ip_email:" + ip + ":" + email, Limit: cfg.IPEmailLimit})
+		}
+		if len(keys) == 0 {
 			c.Next()
 			return
 		}
 
-		key := loginRateLimitKey + ip
-		count, err := client.Incr(c.Request.Context(), key).Result()
+		result, err := limiter.Allow(c.Request.Context(), keys...)
 		if err != nil {
 			helper.RespondError(c, http.StatusInternalServerError, "internal error")
 			c.Abort()
 			return
 		}
 
-		if count == 1 {
-			if err := client.Expire(c.Request.Context(), key, loginRateLimitTTL).Err(); err != nil {
-				helper.RespondError(c, http.StatusInternalServerError, "internal error")
-				c.Abort()
-				return
-			}
-		}
-
-		if count >= loginRateLimitMax {
+		c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
 			helper.RespondError(c, http.StatusTooManyRequests, "too many login attempts")
 			c.Abort()
 			return
@@ -55,3 +64,28 @@ func LoginRateLimit(client *redis.Client) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// peekEmail reads the "email" field of a JSON body and puts the body back
+// for the handler.
+func peekEmail(c *gin.Context) string {
+	if c.Request.Body == nil || !strings.HasPrefix(c.ContentType(), "application/json") {
+		return ""
+	}
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, loginRateLimitMaxBody+1))
+	c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+	if err != nil || len(body) > loginRateLimitMaxBody {
+		return ""
+	}
+
+	var payload struct {
+		Email string `json:"email"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(payload.Email))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
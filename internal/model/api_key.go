@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey lets a user's scripts call the API without a session. Only the
+// SHA-256 of the key is stored; Prefix keeps its first characters so users
+// can tell their keys apart. Scopes are permission names, which never exceed
+// what the owner's role grants at request time, or read scopes from
+// OAuthReadScopes. Routes check them as they check a partner app's scopes,
+// so a key for uploads needs tracks:write.
+type APIKey struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;index:idx_api_keys_user_id"`
+	User       User       `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Name       string     `gorm:"size:100;not null"`
+	Prefix     string     `gorm:"size:20;not null"`
+	KeyHash    string     `gorm:"size:64;not null;uniqueIndex"`
+	Scopes     []string   `gorm:"serializer:json;type:text"`
+	ExpiresAt  *time.Time `gorm:"index"`
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
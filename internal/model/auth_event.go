@@ -0,0 +1,48 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Auth event types.
+const (
+	AuthEventLogin                = "login"
+	AuthEventLockout              = "lockout"
+	AuthEventRefresh              = "refresh"
+	AuthEventPasswordResetRequest = "password_reset_request"
+	AuthEventPasswordReset        = "password_reset"
+	AuthEventEmailVerify          = "email_verify"
+	AuthEventDeviceReport         = "device_report"
+	AuthEventImpersonationStart   = "impersonation_start"
+	AuthEventImpersonatedRequest  = "impersonated_request"
+)
+
+// Auth event outcomes. A login that passed the first factor but still has
+// to answer an MFA challenge is recorded as AuthOutcomeMFARequired.
+const (
+	AuthOutcomeSuccess     = "success"
+	AuthOutcomeFailure     = "failure"
+	AuthOutcomeMFARequired = "mfa_required"
+)
+
+// AuthEvent is one entry of the authentication audit log. UserID is empty
+// when the attempt did not match an account; Email keeps what was tried.
+// Events are not tied to the user row so they outlive a deleted account;
+// purging the account clears UserID and Email from them.
+// ActorID is the staff member behind impersonation events; a request made
+// while impersonating keeps its HTTP method in Method and its path in Reason.
+type AuthEvent struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	UserID    *uuid.UUID `gorm:"type:uuid;index:idx_auth_events_user_id"`
+	ActorID   *uuid.UUID `gorm:"type:uuid;index:idx_auth_events_actor_id"`
+	Email     string     `gorm:"size:255;index:idx_auth_events_email"`
+	Type      string     `gorm:"size:50;not null;index:idx_auth_events_type"`
+	Method    string     `gorm:"size:50"`
+	Outcome   string     `gorm:"size:20;not null"`
+	Reason    string     `gorm:"size:255"`
+	IP        string     `gorm:"size:64"`
+	UserAgent string     `gorm:"size:512"`
+	CreatedAt time.Time  `gorm:"index:idx_auth_events_created_at"`
+}
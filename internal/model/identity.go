@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Identity links an account at an external provider to a user. Provider and
+// Subject identify the external account; Email is what the provider reported
+// when it was linked.
+type Identity struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index:idx_identities_user_id"`
+	User      User      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Provider  string    `gorm:"size:50;not null;uniqueIndex:idx_identities_provider_subject"`
+	Subject   string    `gorm:"size:255;not null;uniqueIndex:idx_identities_provider_subject"`
+	Email     string    `gorm:"size:255"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// KnownDevice is a device a user has signed in from. Fingerprint hashes the
+// user agent and the network prefix of the IP, so the same browser on the
+// same network is recognised even when its address changes slightly.
+type KnownDevice struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID      uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_known_devices_user_fingerprint"`
+	User        User      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Fingerprint string    `gorm:"size:64;not null;uniqueIndex:idx_known_devices_user_fingerprint"`
+	UserAgent   string    `gorm:"size:512"`
+	IP          string    `gorm:"size:64"`
+	LastSeenAt  time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Scopes a partner app can ask for. tracks:write matches the permission of
+// the same name and also covers uploading audio and cover images; there is
+// no separate uploads scope. The read scopes only gate routes.
+const (
+	OAuthScopeProfileRead = "profile:read"
+	OAuthScopeTracksRead  = "tracks:read"
+	OAuthScopeTracksWrite = "tracks:write"
+)
+
+// OAuthScopes lists every scope a client can be registered with.
+var OAuthScopes = []string{OAuthScopeProfileRead, OAuthScopeTracksRead, OAuthScopeTracksWrite}
+
+// OAuthReadScopes grant no permission, so any user may hand them out.
+var OAuthReadScopes = []string{OAuthScopeProfileRead, OAuthScopeTracksRead}
+
+// OAuthClient is a partner app that acts on behalf of users through the
+// authorization code flow. Only the SHA-256 of the secret is stored; public
+// clients (mobile or browser apps) have none and rely on PKCE alone.
+type OAuthClient struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey"`
+	ClientID     string    `gorm:"size:64;not null;uniqueIndex"`
+	SecretHash   string    `gorm:"size:64"`
+	Name         string    `gorm:"size:100;not null"`
+	RedirectURIs []string  `gorm:"serializer:json;type:text"`
+	Scopes       []string  `gorm:"serializer:json;type:text"`
+	OwnerID      uuid.UUID `gorm:"type:uuid;not null"`
+	Confidential bool      `gorm:"not null;default:false"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// OAuthGrant remembers the scopes a user consented to for a client. Refresh
+// tokens stop working once the grant is revoked.
+type OAuthGrant struct {
+	ID        uuid.UUID   `gorm:"type:uuid;primaryKey"`
+	UserID    uuid.UUID   `gorm:"type:uuid;not null;uniqueIndex:idx_oauth_grants_user_client"`
+	User      User        `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	ClientID  uuid.UUID   `gorm:"type:uuid;not null;uniqueIndex:idx_oauth_grants_user_client"`
+	Client    OAuthClient `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Scopes    []string    `gorm:"serializer:json;type:text"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Permission names are "<resource>:<action>".
+const (
+	PermissionUsersRead    = "users:read"
+	PermissionUsersManage  = "users:manage"
+	PermissionRolesManage  = "roles:manage"
+	PermissionTracksWrite  = "tracks:write"
+	PermissionTracksManage = "tracks:manage"
+	PermissionAuditRead    = "audit:read"
+	PermissionOAuthManage  = "oauth_clients:manage"
+	PermissionImpersonate  = "users:impersonate"
+)
+
+type Permission struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Name        string    `gorm:"size:100;uniqueIndex;not null"`
+	Description string    `gorm:"size:255"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
@@ -7,9 +7,10 @@ import (
 )
 
 type Role struct {
-	ID          uuid.UUID `gorm:"type:uuid;primaryKey"`
-	Name        string    `gorm:"size:50;uniqueIndex;not null"`
-	Description string    `gorm:"size:255"`
+	ID          uuid.UUID    `gorm:"type:uuid;primaryKey"`
+	Name        string       `gorm:"size:50;uniqueIndex;not null"`
+	Description string       `gorm:"size:255"`
+	Permissions []Permission `gorm:"many2many:role_permissions;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 }
@@ -7,6 +7,17 @@ import (
 	"gorm.io/gorm"
 )
 
+// Account statuses. IsActive only records that the email was verified; the
+// status says whether the account may be used at all.
+const (
+	UserStatusPending   = "pending_verification"
+	UserStatusActive    = "active"
+	UserStatusSuspended = "suspended"
+	UserStatusBanned    = "banned"
+)
+
+// User is an account. A suspension lifts itself once SuspendUntil has passed;
+// without it, as for a ban, it lasts until an admin reinstates the account.
 type User struct {
 	ID           uuid.UUID `gorm:"type:uuid;primaryKey"`
 	FirstName    string    `gorm:"size:100"`
@@ -14,9 +25,15 @@ type User struct {
 	Email        string    `gorm:"size:255;uniqueIndex;not null"`
 	PasswordHash string    `gorm:"size:255;not null"`
 	IsActive     bool      `gorm:"default:false"`
+	TOTPSecret   string    `gorm:"column:totp_secret;size:255"`
+	TOTPEnabled  bool      `gorm:"column:totp_enabled;not null;default:false"`
 	RoleID       uuid.UUID `gorm:"type:uuid;not null;index:idx_users_role_id"`
 	Role         Role      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
+	Status       string `gorm:"size:30;not null;default:pending_verification;index"`
+	StatusReason string `gorm:"size:500"`
+	SuspendUntil *time.Time
+	PurgeAt      *time.Time     `gorm:"index"`
 	DeletedAt    gorm.DeletedAt `gorm:"index"`
 }
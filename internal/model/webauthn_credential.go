@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebAuthnCredential is a passkey registered by a user. CredentialID is the
+// raw id chosen by the authenticator and is what assertions refer to.
+type WebAuthnCredential struct {
+	ID              uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID          uuid.UUID `gorm:"type:uuid;not null;index:idx_webauthn_credentials_user_id"`
+	User            User      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Name            string    `gorm:"size:100"`
+	CredentialID    []byte    `gorm:"type:bytea;not null;uniqueIndex"`
+	PublicKey       []byte    `gorm:"type:bytea;not null"`
+	AttestationType string    `gorm:"size:50"`
+	Transports      string    `gorm:"size:255"`
+	AAGUID          []byte    `gorm:"column:aaguid;type:bytea"`
+	SignCount       int64     `gorm:"not null;default:0"`
+	UserVerified    bool      `gorm:"not null;default:false"`
+	BackupEligible  bool      `gorm:"not null;default:false"`
+	BackupState     bool      `gorm:"not null;default:false"`
+	LastUsedAt      *time.Time
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
@@ -0,0 +1,104 @@
+package oauth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/github"
+)
+
+const githubAPI = "https://api.github.com"
+
+// GitHubProvider signs users in with a GitHub OAuth app. GitHub has no ID
+// token, so the identity is read from the REST API with the access token.
+type GitHubProvider struct {
+	cfg oauth2.Config
+}
+
+func NewGitHubProvider(clientID, clientSecret, redirectURL string) *GitHubProvider {
+	return &GitHubProvider{cfg: oauth2.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Endpoint:     github.Endpoint,
+		RedirectURL:  redirectURL,
+		Scopes:       []string{"read:user", "user:email"},
+	}}
+}
+
+func (p *GitHubProvider) Name() string {
+	return ProviderGitHub
+}
+
+func (p *GitHubProvider) Authenticate(ctx context.Context, credential Credential) (*Identity, error) {
+	ctx = withHTTPClient(ctx)
+	accessToken, err := exchangeAccessToken(ctx, p.cfg, credential, p.checkToken)
+	if err != nil {
+		return nil, err
+	}
+
+	var user struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+		Name  string `json:"name"`
+	}
+	if err := getJSON(ctx, githubAPI+"/user", accessToken, &user); err != nil {
+		return nil, err
+	}
+	if user.ID == 0 {
+		return nil, ErrInvalidCredential
+	}
+
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := getJSON(ctx, githubAPI+"/user/emails", accessToken, &emails); err != nil {
+		return nil, err
+	}
+
+	identity := &Identity{Provider: p.Name(), Subject: strconv.FormatInt(user.ID, 10)}
+	for _, e := range emails {
+		if e.Primary {
+			identity.Email = strings.TrimSpace(strings.ToLower(e.Email))
+			identity.EmailVerified = e.Verified
+		}
+	}
+	identity.FirstName, identity.LastName = splitName(user.Name)
+	return identity, nil
+}
+
+// checkToken asks GitHub whether accessToken was issued to this OAuth app. A
+// token from any other app is refused, so it cannot be replayed here to sign
+// in as its owner.
+func (p *GitHubProvider) checkToken(ctx context.Context, accessToken string) error {
+	body, err := json.Marshal(map[string]string{"access_token": accessToken})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, githubAPI+"/applications/"+url.PathEscape(p.cfg.ClientID)+"/token", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(p.cfg.ClientID, p.cfg.ClientSecret)
+	req.Header.Set("Content-Type", "application/json")
+
+	var check struct {
+		App struct {
+			ClientID string `json:"client_id"`
+		} `json:"app"`
+	}
+	if err := doJSON(req, &check); err != nil {
+		return err
+	}
+	if check.App.ClientID != p.cfg.ClientID {
+		return ErrInvalidCredential
+	}
+	return nil
+}
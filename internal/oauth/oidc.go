@@ -0,0 +1,137 @@
+package oauth
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// OIDCProvider signs users in with any OpenID Connect issuer. Discovery runs
+// on first use so an unreachable issuer does not stop the server starting.
+type OIDCProvider struct {
+	name         string
+	issuer       string
+	clientID     string
+	clientSecret string
+	redirectURL  string
+
+	mu       sync.Mutex
+	provider *oidc.Provider
+}
+
+func NewOIDCProvider(name, issuer, clientID, clientSecret, redirectURL string) *OIDCProvider {
+	return &OIDCProvider{
+		name:         name,
+		issuer:       issuer,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		redirectURL:  redirectURL,
+	}
+}
+
+func (p *OIDCProvider) Name() string {
+	return p.name
+}
+
+func (p *OIDCProvider) Authenticate(ctx context.Context, credential Credential) (*Identity, error) {
+	ctx = withHTTPClient(ctx)
+	provider, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rawIDToken := strings.TrimSpace(credential.IDToken)
+	if rawIDToken == "" && credential.Code != "" {
+		rawIDToken, err = p.exchange(ctx, provider, credential)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if rawIDToken == "" {
+		return nil, ErrUnsupportedCredential
+	}
+
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: p.clientID}).Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, ErrInvalidCredential
+	}
+	if credential.Nonce != "" && idToken.Nonce != credential.Nonce {
+		return nil, ErrInvalidCredential
+	}
+
+	var claims struct {
+		Email         string      `json:"email"`
+		EmailVerified interface{} `json:"email_verified"`
+		GivenName     string      `json:"given_name"`
+		FamilyName    string      `json:"family_name"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, ErrInvalidCredential
+	}
+
+	return &Identity{
+		Provider:      p.name,
+		Subject:       idToken.Subject,
+		Email:         strings.TrimSpace(strings.ToLower(claims.Email)),
+		EmailVerified: isTrue(claims.EmailVerified),
+		FirstName:     strings.TrimSpace(claims.GivenName),
+		LastName:      strings.TrimSpace(claims.FamilyName),
+	}, nil
+}
+
+func (p *OIDCProvider) exchange(ctx context.Context, provider *oidc.Provider, credential Credential) (string, error) {
+	cfg := oauth2.Config{
+		ClientID:     p.clientID,
+		ClientSecret: p.clientSecret,
+		Endpoint:     provider.Endpoint(),
+		RedirectURL:  p.redirectURL,
+		Scopes:       []string{oidc.ScopeOpenID, "email", "profile"},
+	}
+	if credential.RedirectURI != "" {
+		cfg.RedirectURL = credential.RedirectURI
+	}
+	var opts []oauth2.AuthCodeOption
+	if credential.CodeVerifier != "" {
+		opts = append(opts, oauth2.VerifierOption(credential.CodeVerifier))
+	}
+	tok, err := cfg.Exchange(ctx, credential.Code, opts...)
+	if err != nil {
+		return "", ErrInvalidCredential
+	}
+	rawIDToken, _ := tok.Extra("id_token").(string)
+	if rawIDToken == "" {
+		return "", ErrInvalidCredential
+	}
+	return rawIDToken, nil
+}
+
+func (p *OIDCProvider) discover(ctx context.Context) (*oidc.Provider, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.provider != nil {
+		return p.provider, nil
+	}
+	// The provider outlives the request, so discovery must not be bound to
+	// the request context.
+	provider, err := oidc.NewProvider(context.WithoutCancel(ctx), p.issuer)
+	if err != nil {
+		return nil, err
+	}
+	p.provider = provider
+	return provider, nil
+}
+
+// isTrue accepts both JSON booleans and the "true" strings Apple sends.
+func isTrue(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		return strings.EqualFold(v, "true")
+	default:
+		return false
+	}
+}
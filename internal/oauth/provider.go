@@ -0,0 +1,194 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"wavefy-be/config"
+)
+
+const (
+	ProviderGoogle   = "google"
+	ProviderApple    = "apple"
+	ProviderFacebook = "facebook"
+	ProviderGitHub   = "github"
+)
+
+var (
+	ErrInvalidCredential     = errors.New("invalid provider credential")
+	ErrUnsupportedCredential = errors.New("unsupported provider credential")
+)
+
+// Credential is what a client obtained from a provider. Depending on the
+// provider and flow it carries an ID token, an access token or an
+// authorization code to exchange.
+type Credential struct {
+	IDToken      string
+	AccessToken  string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	Nonce        string
+}
+
+// Identity is the account a provider vouched for. Subject is stable per
+// provider; the email may change or be missing.
+type Identity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	FirstName     string
+	LastName      string
+}
+
+type Provider interface {
+	Name() string
+	Authenticate(ctx context.Context, credential Credential) (*Identity, error)
+}
+
+// Registry holds the configured providers by name.
+type Registry struct {
+	providers map[string]Provider
+}
+
+func NewRegistry(providers ...Provider) *Registry {
+	r := &Registry{providers: map[string]Provider{}}
+	for _, p := range providers {
+		r.providers[p.Name()] = p
+	}
+	return r
+}
+
+// NewRegistryFromConfig registers every provider that has a client id.
+func NewRegistryFromConfig(google config.GoogleOAuthConfig, cfg config.OAuthConfig) *Registry {
+	var providers []Provider
+	if google.ClientID != "" {
+		providers = append(providers, NewOIDCProvider(ProviderGoogle, "https://accounts.google.com", google.ClientID, google.ClientSecret, google.RedirectURL))
+	}
+	if cfg.Apple.ClientID != "" {
+		providers = append(providers, NewOIDCProvider(ProviderApple, "https://appleid.apple.com", cfg.Apple.ClientID, cfg.Apple.ClientSecret, cfg.Apple.RedirectURL))
+	}
+	if cfg.Facebook.ClientID != "" {
+		providers = append(providers, NewFacebookProvider(cfg.Facebook.ClientID, cfg.Facebook.ClientSecret, cfg.Facebook.RedirectURL))
+	}
+	if cfg.GitHub.ClientID != "" {
+		providers = append(providers, NewGitHubProvider(cfg.GitHub.ClientID, cfg.GitHub.ClientSecret, cfg.GitHub.RedirectURL))
+	}
+	if cfg.OIDC.ClientID != "" && cfg.OIDC.Issuer != "" && cfg.OIDCName != "" {
+		providers = append(providers, NewOIDCProvider(strings.ToLower(cfg.OIDCName), cfg.OIDC.Issuer, cfg.OIDC.ClientID, cfg.OIDC.ClientSecret, cfg.OIDC.RedirectURL))
+	}
+	return NewRegistry(providers...)
+}
+
+func (r *Registry) Get(name string) (Provider, bool) {
+	if r == nil {
+		return nil, false
+	}
+	p, ok := r.providers[strings.ToLower(strings.TrimSpace(name))]
+	return p, ok
+}
+
+func (r *Registry) Names() []string {
+	if r == nil {
+		return nil
+	}
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// exchangeAccessToken trades an authorization code for an access token. An
+// access token sent by the client is only used once check confirms it was
+// issued to this app.
+func exchangeAccessToken(ctx context.Context, cfg oauth2.Config, credential Credential, check func(context.Context, string) error) (string, error) {
+	if token := strings.TrimSpace(credential.AccessToken); token != "" {
+		if err := check(ctx, token); err != nil {
+			return "", err
+		}
+		return token, nil
+	}
+	if credential.Code == "" {
+		return "", ErrUnsupportedCredential
+	}
+	if credential.RedirectURI != "" {
+		cfg.RedirectURL = credential.RedirectURI
+	}
+	var opts []oauth2.AuthCodeOption
+	if credential.CodeVerifier != "" {
+		opts = append(opts, oauth2.VerifierOption(credential.CodeVerifier))
+	}
+	tok, err := cfg.Exchange(ctx, credential.Code, opts...)
+	if err != nil || tok.AccessToken == "" {
+		return "", ErrInvalidCredential
+	}
+	return tok.AccessToken, nil
+}
+
+// httpClient bounds every call to a provider so a slow provider cannot hold
+// a sign-in request open.
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// withHTTPClient makes the oauth2 and go-oidc libraries use httpClient.
+func withHTTPClient(ctx context.Context) context.Context {
+	return context.WithValue(ctx, oauth2.HTTPClient, httpClient)
+}
+
+func getJSON(ctx context.Context, endpoint, accessToken string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	return doJSON(req, out)
+}
+
+// maxResponseBytes caps how much of a provider response is read.
+const maxResponseBytes = 1 << 20
+
+func doJSON(req *http.Request, out interface{}) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(out)
+	case http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusUnprocessableEntity:
+		return ErrInvalidCredential
+	default:
+		// The query may hold an app secret proof, so only the path is reported.
+		return &httpError{url: req.URL.Host + req.URL.Path, status: resp.StatusCode}
+	}
+}
+
+type httpError struct {
+	url    string
+	status int
+}
+
+func (e *httpError) Error() string {
+	return "oauth: " + e.url + " returned " + strconv.Itoa(e.status)
+}
+
+func splitName(name string) (string, string) {
+	name = strings.TrimSpace(name)
+	first, last, _ := strings.Cut(name, " ")
+	return first, strings.TrimSpace(last)
+}
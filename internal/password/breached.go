@@ -0,0 +1,60 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"strings"
+)
+
+// BreachedList is a set of SHA-1 password hashes, as published by Have I Been
+// Pwned. The file has one uppercase or lowercase hex hash per line, optionally
+// followed by ":count"; blank lines and lines starting with # are skipped.
+type BreachedList struct {
+	hashes map[[sha1.Size]byte]struct{}
+}
+
+func LoadBreachedList(path string) (*BreachedList, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	list := &BreachedList{hashes: map[[sha1.Size]byte]struct{}{}}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		hash, _, _ := strings.Cut(line, ":")
+		raw, err := hex.DecodeString(hash)
+		if err != nil || len(raw) != sha1.Size {
+			continue
+		}
+		var key [sha1.Size]byte
+		copy(key[:], raw)
+		list.hashes[key] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (l *BreachedList) Contains(password string) bool {
+	if l == nil {
+		return false
+	}
+	_, ok := l.hashes[sha1.Sum([]byte(password))]
+	return ok
+}
+
+func (l *BreachedList) Len() int {
+	if l == nil {
+		return 0
+	}
+	return len(l.hashes)
+}
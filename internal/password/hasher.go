@@ -0,0 +1,159 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+
+	"wavefy-be/config"
+)
+
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+)
+
+var ErrUnknownHash = errors.New("unknown password hash format")
+
+// Hasher hashes new passwords with the configured algorithm and verifies
+// hashes made by any supported one. Verify reports needsRehash when a
+// matching hash was made with another algorithm or weaker parameters.
+type Hasher interface {
+	Hash(password string) (string, error)
+	Verify(hash, password string) (ok bool, needsRehash bool, err error)
+}
+
+// Argon2Params are the argon2id cost parameters. Memory is in KiB.
+type Argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+type hasher struct {
+	algorithm  string
+	argon2     Argon2Params
+	bcryptCost int
+}
+
+func NewHasher(cfg config.PasswordConfig) Hasher {
+	h := &hasher{
+		algorithm: cfg.HashAlgorithm,
+		argon2: Argon2Params{
+			Memory:      cfg.Argon2Memory,
+			Iterations:  cfg.Argon2Iterations,
+			Parallelism: cfg.Argon2Parallelism,
+			SaltLength:  16,
+			KeyLength:   32,
+		},
+		bcryptCost: cfg.BcryptCost,
+	}
+	if h.algorithm != AlgorithmBcrypt {
+		h.algorithm = AlgorithmArgon2id
+	}
+	if h.argon2.Memory == 0 {
+		h.argon2.Memory = 64 * 1024
+	}
+	if h.argon2.Iterations == 0 {
+		h.argon2.Iterations = 3
+	}
+	if h.argon2.Parallelism == 0 {
+		h.argon2.Parallelism = 2
+	}
+	if h.bcryptCost < bcrypt.MinCost || h.bcryptCost > bcrypt.MaxCost {
+		h.bcryptCost = bcrypt.DefaultCost
+	}
+	return h
+}
+
+func (h *hasher) Hash(password string) (string, error) {
+	if h.algorithm == AlgorithmBcrypt {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), h.bcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return string(hash), nil
+	}
+
+	salt := make([]byte, h.argon2.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	p := h.argon2
+	key := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.Memory, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h *hasher) Verify(hash, password string) (bool, bool, error) {
+	switch {
+	case hash == "":
+		return false, false, nil
+	case strings.HasPrefix(hash, "$argon2id$"):
+		params, salt, key, err := parseArgon2id(hash)
+		if err != nil {
+			return false, false, err
+		}
+		candidate := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+		if subtle.ConstantTimeCompare(candidate, key) != 1 {
+			return false, false, nil
+		}
+		needsRehash := h.algorithm != AlgorithmArgon2id ||
+			params.Memory < h.argon2.Memory ||
+			params.Iterations < h.argon2.Iterations ||
+			params.Parallelism != h.argon2.Parallelism ||
+			uint32(len(key)) < h.argon2.KeyLength
+		return true, needsRehash, nil
+	case strings.HasPrefix(hash, "$2"):
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, false, nil
+		}
+		if err != nil {
+			return false, false, err
+		}
+		cost, err := bcrypt.Cost([]byte(hash))
+		if err != nil {
+			return false, false, err
+		}
+		return true, h.algorithm != AlgorithmBcrypt || cost < h.bcryptCost, nil
+	default:
+		return false, false, ErrUnknownHash
+	}
+}
+
+// parseArgon2id reads a PHC string: $argon2id$v=19$m=65536,t=3,p=2$salt$key.
+func parseArgon2id(hash string) (Argon2Params, []byte, []byte, error) {
+	var params Argon2Params
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return params, nil, nil, ErrUnknownHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrUnknownHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, ErrUnknownHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrUnknownHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrUnknownHash
+	}
+	return params, salt, key, nil
+}
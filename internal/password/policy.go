@@ -0,0 +1,151 @@
+package password
+
+import (
+	"strings"
+	"unicode"
+
+	"wavefy-be/config"
+)
+
+// bcryptMaxBytes is the input length bcrypt looks at; anything after it is
+// silently ignored, so longer passwords are rejected instead.
+const bcryptMaxBytes = 72
+
+const (
+	CodeRequired     = "required"
+	CodeTooShort     = "too_short"
+	CodeTooLong      = "too_long"
+	CodeMissingUpper = "missing_uppercase"
+	CodeMissingLower = "missing_lowercase"
+	CodeMissingDigit = "missing_digit"
+	CodeMissingOther = "missing_symbol"
+	CodeContainsMail = "contains_email"
+	CodeBreached     = "breached"
+)
+
+// FieldError is one reason a field was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ValidationError carries every policy violation of a request.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	return "password does not meet policy"
+}
+
+type Policy struct {
+	minLength     int
+	maxLength     int
+	requireUpper  bool
+	requireLower  bool
+	requireDigit  bool
+	requireSymbol bool
+	breached      *BreachedList
+}
+
+// NewPolicy builds the policy from config and loads the breached password
+// list when a path is set.
+func NewPolicy(cfg config.PasswordConfig) (*Policy, error) {
+	maxLength := cfg.MaxLength
+	if maxLength <= 0 || maxLength > bcryptMaxBytes {
+		maxLength = bcryptMaxBytes
+	}
+	p := &Policy{
+		minLength:     cfg.MinLength,
+		maxLength:     maxLength,
+		requireUpper:  cfg.RequireUpper,
+		requireLower:  cfg.RequireLower,
+		requireDigit:  cfg.RequireDigit,
+		requireSymbol: cfg.RequireSymbol,
+	}
+	if cfg.BreachedListPath != "" {
+		list, err := LoadBreachedList(cfg.BreachedListPath)
+		if err != nil {
+			return nil, err
+		}
+		p.breached = list
+	}
+	return p, nil
+}
+
+// Check validates password for the account identified by email and reports
+// violations against field. It returns nil or a *ValidationError.
+func (p *Policy) Check(field, password, email string) error {
+	if password == "" {
+		return &ValidationError{Fields: []FieldError{{Field: field, Code: CodeRequired, Message: "password is required"}}}
+	}
+	if p == nil {
+		return nil
+	}
+
+	var errs []FieldError
+	add := func(code, message string) {
+		errs = append(errs, FieldError{Field: field, Code: code, Message: message})
+	}
+
+	if len([]rune(password)) < p.minLength {
+		add(CodeTooShort, "password is too short")
+	}
+	if len(password) > p.maxLength {
+		add(CodeTooLong, "password is too long")
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		default:
+			hasSymbol = true
+		}
+	}
+	if p.requireUpper && !hasUpper {
+		add(CodeMissingUpper, "password needs an uppercase letter")
+	}
+	if p.requireLower && !hasLower {
+		add(CodeMissingLower, "password needs a lowercase letter")
+	}
+	if p.requireDigit && !hasDigit {
+		add(CodeMissingDigit, "password needs a digit")
+	}
+	if p.requireSymbol && !hasSymbol {
+		add(CodeMissingOther, "password needs a symbol")
+	}
+
+	if containsEmail(password, email) {
+		add(CodeContainsMail, "password must not contain the email address")
+	}
+	if p.breached.Contains(password) {
+		add(CodeBreached, "password appears in a data breach")
+	}
+
+	if len(errs) > 0 {
+		return &ValidationError{Fields: errs}
+	}
+	return nil
+}
+
+// containsEmail reports whether the password contains the address or its
+// local part. Very short local parts are ignored to avoid false positives.
+func containsEmail(password, email string) bool {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return false
+	}
+	lowered := strings.ToLower(password)
+	if strings.Contains(lowered, email) {
+		return true
+	}
+	local, _, _ := strings.Cut(email, "@")
+	return len(local) >= 3 && strings.Contains(lowered, local)
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"wavefy-be/internal/model"
+)
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *model.APIKey) error
+	GetByHash(ctx context.Context, hash string) (*model.APIKey, error)
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]model.APIKey, error)
+	TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error
+	Delete(ctx context.Context, userID, id uuid.UUID) (bool, error)
+	DeleteByUser(ctx context.Context, userID uuid.UUID) (int64, error)
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *model.APIKey) error {
+	return r.db.WithContext(ctx).Omit("User").Create(key).Error
+}
+
+func (r *apiKeyRepository) GetByHash(ctx context.Context, hash string) (*model.APIKey, error) {
+	var key model.APIKey
+	err := r.db.WithContext(ctx).First(&key, "key_hash = ?", hash).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]model.APIKey, error) {
+	var keys []model.APIKey
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at desc").Find(&keys).Error
+	return keys, err
+}
+
+func (r *apiKeyRepository) TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.APIKey{}).Where("id = ?", id).UpdateColumn("last_used_at", at).Error
+}
+
+func (r *apiKeyRepository) Delete(ctx context.Context, userID, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Delete(&model.APIKey{}, "id = ? AND user_id = ?", id, userID)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *apiKeyRepository) DeleteByUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	result := r.db.WithContext(ctx).Delete(&model.APIKey{}, "user_id = ?", userID)
+	return result.RowsAffected, result.Error
+}
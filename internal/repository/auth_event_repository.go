@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"wavefy-be/internal/model"
+)
+
+// AuthEventFilter narrows an audit log query. Zero fields are ignored.
+type AuthEventFilter struct {
+	UserID  *uuid.UUID
+	ActorID *uuid.UUID
+	Email   string
+	Type    string
+	Outcome string
+	IP      string
+	Since   time.Time
+	Until   time.Time
+	// SkipTypes leaves out events of these types.
+	SkipTypes []string
+}
+
+type AuthEventRepository interface {
+	Create(ctx context.Context, event *model.AuthEvent) error
+	List(ctx context.Context, filter AuthEventFilter, limit, offset int) ([]model.AuthEvent, error)
+}
+
+type authEventRepository struct {
+	db *gorm.DB
+}
+
+func NewAuthEventRepository(db *gorm.DB) AuthEventRepository {
+	return &authEventRepository{db: db}
+}
+
+func (r *authEventRepository) Create(ctx context.Context, event *model.AuthEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+func (r *authEventRepository) List(ctx context.Context, filter AuthEventFilter, limit, offset int) ([]model.AuthEvent, error) {
+	query := r.db.WithContext(ctx).Model(&model.AuthEvent{})
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.Email != "" {
+		query = query.Where("email = ?", filter.Email)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.Outcome != "" {
+		query = query.Where("outcome = ?", filter.Outcome)
+	}
+	if filter.IP != "" {
+		query = query.Where("ip = ?", filter.IP)
+	}
+	if !filter.Since.IsZero() {
+		query = query.Where("created_at >= ?", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		query = query.Where("created_at < ?", filter.Until)
+	}
+	if len(filter.SkipTypes) > 0 {
+		query = query.Where("type NOT IN ?", filter.SkipTypes)
+	}
+
+	var events []model.AuthEvent
+	err := query.Limit(limit).Offset(offset).Order("created_at desc").Find(&events).Error
+	return events, err
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"wavefy-be/internal/model"
+)
+
+type IdentityRepository interface {
+	Create(ctx context.Context, identity *model.Identity) error
+	GetByProviderSubject(ctx context.Context, provider, subject string) (*model.Identity, error)
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]model.Identity, error)
+	Delete(ctx context.Context, userID, id uuid.UUID) (bool, error)
+	DeleteByUser(ctx context.Context, userID uuid.UUID) (int64, error)
+}
+
+type identityRepository struct {
+	db *gorm.DB
+}
+
+func NewIdentityRepository(db *gorm.DB) IdentityRepository {
+	return &identityRepository{db: db}
+}
+
+func (r *identityRepository) Create(ctx context.Context, identity *model.Identity) error {
+	return r.db.WithContext(ctx).Omit("User").Create(identity).Error
+}
+
+func (r *identityRepository) GetByProviderSubject(ctx context.Context, provider, subject string) (*model.Identity, error) {
+	var identity model.Identity
+	err := r.db.WithContext(ctx).Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *identityRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]model.Identity, error) {
+	var identities []model.Identity
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at asc").Find(&identities).Error
+	return identities, err
+}
+
+func (r *identityRepository) Delete(ctx context.Context, userID, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Delete(&model.Identity{}, "id = ? AND user_id = ?", id, userID)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *identityRepository) DeleteByUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	result := r.db.WithContext(ctx).Delete(&model.Identity{}, "user_id = ?", userID)
+	return result.RowsAffected, result.Error
+}
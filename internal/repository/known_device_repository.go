@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"wavefy-be/internal/model"
+)
+
+type KnownDeviceRepository interface {
+	Create(ctx context.Context, device *model.KnownDevice) error
+	GetByFingerprint(ctx context.Context, userID uuid.UUID, fingerprint string) (*model.KnownDevice, error)
+	CountByUser(ctx context.Context, userID uuid.UUID) (int64, error)
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]model.KnownDevice, error)
+	Update(ctx context.Context, device *model.KnownDevice) error
+	DeleteByFingerprint(ctx context.Context, userID uuid.UUID, fingerprint string) error
+}
+
+type knownDeviceRepository struct {
+	db *gorm.DB
+}
+
+func NewKnownDeviceRepository(db *gorm.DB) KnownDeviceRepository {
+	return &knownDeviceRepository{db: db}
+}
+
+func (r *knownDeviceRepository) Create(ctx context.Context, device *model.KnownDevice) error {
+	return r.db.WithContext(ctx).Omit("User").Create(device).Error
+}
+
+func (r *knownDeviceRepository) GetByFingerprint(ctx context.Context, userID uuid.UUID, fingerprint string) (*model.KnownDevice, error) {
+	var device model.KnownDevice
+	err := r.db.WithContext(ctx).First(&device, "user_id = ? AND fingerprint = ?", userID, fingerprint).Error
+	if err != nil {
+		return nil, err
+	}
+	return &device, nil
+}
+
+func (r *knownDeviceRepository) CountByUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.KnownDevice{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+func (r *knownDeviceRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]model.KnownDevice, error) {
+	var devices []model.KnownDevice
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("last_seen_at desc").Find(&devices).Error
+	return devices, err
+}
+
+func (r *knownDeviceRepository) Update(ctx context.Context, device *model.KnownDevice) error {
+	return r.db.WithContext(ctx).Omit("User").Save(device).Error
+}
+
+func (r *knownDeviceRepository) DeleteByFingerprint(ctx context.Context, userID uuid.UUID, fingerprint string) error {
+	return r.db.WithContext(ctx).Delete(&model.KnownDevice{}, "user_id = ? AND fingerprint = ?", userID, fingerprint).Error
+}
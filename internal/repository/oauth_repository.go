@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"wavefy-be/internal/model"
+)
+
+type OAuthClientRepository interface {
+	Create(ctx context.Context, client *model.OAuthClient) error
+	GetByID(ctx context.Context, id uuid.UUID) (*model.OAuthClient, error)
+	GetByClientID(ctx context.Context, clientID string) (*model.OAuthClient, error)
+	List(ctx context.Context) ([]model.OAuthClient, error)
+	Delete(ctx context.Context, id uuid.UUID) (bool, error)
+}
+
+type oauthClientRepository struct {
+	db *gorm.DB
+}
+
+func NewOAuthClientRepository(db *gorm.DB) OAuthClientRepository {
+	return &oauthClientRepository{db: db}
+}
+
+func (r *oauthClientRepository) Create(ctx context.Context, client *model.OAuthClient) error {
+	return r.db.WithContext(ctx).Create(client).Error
+}
+
+func (r *oauthClientRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.OAuthClient, error) {
+	var client model.OAuthClient
+	err := r.db.WithContext(ctx).First(&client, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+func (r *oauthClientRepository) GetByClientID(ctx context.Context, clientID string) (*model.OAuthClient, error) {
+	var client model.OAuthClient
+	err := r.db.WithContext(ctx).First(&client, "client_id = ?", clientID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+func (r *oauthClientRepository) List(ctx context.Context) ([]model.OAuthClient, error) {
+	var clients []model.OAuthClient
+	err := r.db.WithContext(ctx).Order("created_at desc").Find(&clients).Error
+	return clients, err
+}
+
+func (r *oauthClientRepository) Delete(ctx context.Context, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Delete(&model.OAuthClient{}, "id = ?", id)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+type OAuthGrantRepository interface {
+	Get(ctx context.Context, userID, clientID uuid.UUID) (*model.OAuthGrant, error)
+	Save(ctx context.Context, grant *model.OAuthGrant) error
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]model.OAuthGrant, error)
+	Delete(ctx context.Context, userID, clientID uuid.UUID) (bool, error)
+	DeleteByUser(ctx context.Context, userID uuid.UUID) (int64, error)
+}
+
+type oauthGrantRepository struct {
+	db *gorm.DB
+}
+
+func NewOAuthGrantRepository(db *gorm.DB) OAuthGrantRepository {
+	return &oauthGrantRepository{db: db}
+}
+
+func (r *oauthGrantRepository) Get(ctx context.Context, userID, clientID uuid.UUID) (*model.OAuthGrant, error) {
+	var grant model.OAuthGrant
+	err := r.db.WithContext(ctx).First(&grant, "user_id = ? AND client_id = ?", userID, clientID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &grant, nil
+}
+
+// Save creates the grant or replaces the scopes of the existing one for the
+// same user and client.
+func (r *oauthGrantRepository) Save(ctx context.Context, grant *model.OAuthGrant) error {
+	return r.db.WithContext(ctx).Omit("User", "Client").Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "client_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"scopes", "updated_at"}),
+	}).Create(grant).Error
+}
+
+func (r *oauthGrantRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]model.OAuthGrant, error) {
+	var grants []model.OAuthGrant
+	err := r.db.WithContext(ctx).Preload("Client").Where("user_id = ?", userID).Order("updated_at desc").Find(&grants).Error
+	return grants, err
+}
+
+func (r *oauthGrantRepository) Delete(ctx context.Context, userID, clientID uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Delete(&model.OAuthGrant{}, "user_id = ? AND client_id = ?", userID, clientID)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *oauthGrantRepository) DeleteByUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	result := r.db.WithContext(ctx).Delete(&model.OAuthGrant{}, "user_id = ?", userID)
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"wavefy-be/internal/model"
+)
+
+type PermissionRepository interface {
+	List(ctx context.Context) ([]model.Permission, error)
+	GetByNames(ctx context.Context, names []string) ([]model.Permission, error)
+}
+
+type permissionRepository struct {
+	db *gorm.DB
+}
+
+func NewPermissionRepository(db *gorm.DB) PermissionRepository {
+	return &permissionRepository{db: db}
+}
+
+func (r *permissionRepository) List(ctx context.Context) ([]model.Permission, error) {
+	var permissions []model.Permission
+	err := r.db.WithContext(ctx).Order("name asc").Find(&permissions).Error
+	return permissions, err
+}
+
+func (r *permissionRepository) GetByNames(ctx context.Context, names []string) ([]model.Permission, error) {
+	var permissions []model.Permission
+	if len(names) == 0 {
+		return permissions, nil
+	}
+	err := r.db.WithContext(ctx).Where("name IN ?", names).Find(&permissions).Error
+	return permissions, err
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"wavefy-be/internal/model"
+)
+
+type RecoveryCodeRepository interface {
+	Replace(ctx context.Context, userID uuid.UUID, codeHashes []string) error
+	Consume(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error)
+	DeleteByUser(ctx context.Context, userID uuid.UUID) error
+}
+
+type recoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+func NewRecoveryCodeRepository(db *gorm.DB) RecoveryCodeRepository {
+	return &recoveryCodeRepository{db: db}
+}
+
+func (r *recoveryCodeRepository) Replace(ctx context.Context, userID uuid.UUID, codeHashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&model.RecoveryCode{}, "user_id = ?", userID).Error; err != nil {
+			return err
+		}
+		codes := make([]model.RecoveryCode, 0, len(codeHashes))
+		for _, hash := range codeHashes {
+			codes = append(codes, model.RecoveryCode{
+				ID:       uuid.New(),
+				UserID:   userID,
+				CodeHash: hash,
+			})
+		}
+		if len(codes) == 0 {
+			return nil
+		}
+		return tx.Omit("User").Create(&codes).Error
+	})
+}
+
+func (r *recoveryCodeRepository) Consume(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&model.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now().UTC())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *recoveryCodeRepository) DeleteByUser(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&model.RecoveryCode{}, "user_id = ?", userID).Error
+}
@@ -10,8 +10,11 @@ import (
 )
 
 type RoleRepository interface {
+	Create(ctx context.Context, role *model.Role) error
 	GetByID(ctx context.Context, id uuid.UUID) (*model.Role, error)
 	GetByName(ctx context.Context, name string) (*model.Role, error)
+	List(ctx context.Context) ([]model.Role, error)
+	ReplacePermissions(ctx context.Context, role *model.Role, permissions []model.Permission) error
 }
 
 type roleRepository struct {
@@ -22,9 +25,13 @@ func NewRoleRepository(db *gorm.DB) RoleRepository {
 	return &roleRepository{db: db}
 }
 
+func (r *roleRepository) Create(ctx context.Context, role *model.Role) error {
+	return r.db.WithContext(ctx).Create(role).Error
+}
+
 func (r *roleRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Role, error) {
 	var role model.Role
-	err := r.db.WithContext(ctx).First(&role, "id = ?", id).Error
+	err := r.db.WithContext(ctx).Preload("Permissions").First(&role, "id = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -33,9 +40,19 @@ func (r *roleRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Role
 
 func (r *roleRepository) GetByName(ctx context.Context, name string) (*model.Role, error) {
 	var role model.Role
-	err := r.db.WithContext(ctx).Where("name = ?", name).First(&role).Error
+	err := r.db.WithContext(ctx).Preload("Permissions").Where("name = ?", name).First(&role).Error
 	if err != nil {
 		return nil, err
 	}
 	return &role, nil
 }
+
+func (r *roleRepository) List(ctx context.Context) ([]model.Role, error) {
+	var roles []model.Role
+	err := r.db.WithContext(ctx).Preload("Permissions").Order("name asc").Find(&roles).Error
+	return roles, err
+}
+
+func (r *roleRepository) ReplacePermissions(ctx context.Context, role *model.Role, permissions []model.Permission) error {
+	return r.db.WithContext(ctx).Model(role).Association("Permissions").Replace(permissions)
+}
@@ -15,6 +15,7 @@ type TrackRepository interface {
 	List(ctx context.Context, limit, offset int) ([]model.Track, error)
 	Update(ctx context.Context, track *model.Track) error
 	Delete(ctx context.Context, id uuid.UUID) error
+	ListByArtist(ctx context.Context, artistID uuid.UUID) ([]model.Track, error)
 }
 
 type trackRepository struct {
@@ -51,3 +52,10 @@ func (r *trackRepository) Update(ctx context.Context, track *model.Track) error
 func (r *trackRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return r.db.WithContext(ctx).Delete(&model.Track{}, "id = ?", id).Error
 }
+
+// ListByArtist returns every track of a user, soft-deleted ones included.
+func (r *trackRepository) ListByArtist(ctx context.Context, artistID uuid.UUID) ([]model.Track, error) {
+	var tracks []model.Track
+	err := r.db.WithContext(ctx).Unscoped().Where("artist_user_id = ?", artistID).Find(&tracks).Error
+	return tracks, err
+}
@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -15,7 +17,12 @@ type UserRepository interface {
 	GetByEmail(ctx context.Context, email string) (*model.User, error)
 	List(ctx context.Context, limit, offset int) ([]model.User, error)
 	Update(ctx context.Context, user *model.User) error
+	UpdateRole(ctx context.Context, id, roleID uuid.UUID) error
+	ListIDsByRole(ctx context.Context, roleID uuid.UUID) ([]uuid.UUID, error)
+	ListBlocked(ctx context.Context) ([]model.User, error)
 	Delete(ctx context.Context, id uuid.UUID) error
+	ListDueForPurge(ctx context.Context, now time.Time, limit int) ([]model.User, error)
+	Purge(ctx context.Context, id uuid.UUID) error
 }
 
 type userRepository struct {
@@ -58,6 +65,62 @@ func (r *userRepository) Update(ctx context.Context, user *model.User) error {
 	return r.db.WithContext(ctx).Save(user).Error
 }
 
+func (r *userRepository) UpdateRole(ctx context.Context, id, roleID uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", id).Update("role_id", roleID).Error
+}
+
+func (r *userRepository) ListIDsByRole(ctx context.Context, roleID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Model(&model.User{}).Where("role_id = ?", roleID).Pluck("id", &ids).Error
+	return ids, err
+}
+
+// ListBlocked returns suspended and banned accounts with only their status
+// fields loaded.
+func (r *userRepository) ListBlocked(ctx context.Context) ([]model.User, error) {
+	var users []model.User
+	err := r.db.WithContext(ctx).
+		Select("id", "status", "status_reason", "suspend_until").
+		Where("status IN ?", []string{model.UserStatusSuspended, model.UserStatusBanned}).
+		Find(&users).Error
+	return users, err
+}
+
 func (r *userRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return r.db.WithContext(ctx).Delete(&model.User{}, "id = ?", id).Error
 }
+
+// ListDueForPurge returns accounts whose deletion grace period has passed,
+// including ones soft-deleted by an admin.
+func (r *userRepository) ListDueForPurge(ctx context.Context, now time.Time, limit int) ([]model.User, error) {
+	var users []model.User
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("purge_at <= ?", now).
+		Order("created_at").Limit(limit).Find(&users).Error
+	return users, err
+}
+
+// Purge hard-deletes a user with their tracks. Rows owned through cascading
+// foreign keys go with the user. Audit events stay in the log but no longer
+// name the account: the user id and email are cleared from them.
+func (r *userRepository) Purge(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user model.User
+		if err := tx.Unscoped().Select("email").First(&user, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+		if err := tx.Unscoped().Delete(&model.Track{}, "artist_user_id = ?", id).Error; err != nil {
+			return err
+		}
+		err := tx.Model(&model.AuthEvent{}).
+			Where("user_id = ? OR email = ?", id, user.Email).
+			Updates(map[string]interface{}{"user_id": nil, "email": ""}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&model.User{}, "id = ?", id).Error
+	})
+}
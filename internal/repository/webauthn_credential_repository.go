@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"wavefy-be/internal/model"
+)
+
+type WebAuthnCredentialRepository interface {
+	Create(ctx context.Context, credential *model.WebAuthnCredential) error
+	GetByCredentialID(ctx context.Context, credentialID []byte) (*model.WebAuthnCredential, error)
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]model.WebAuthnCredential, error)
+	Update(ctx context.Context, credential *model.WebAuthnCredential) error
+	Delete(ctx context.Context, userID, id uuid.UUID) (bool, error)
+	DeleteByUser(ctx context.Context, userID uuid.UUID) (int64, error)
+}
+
+type webAuthnCredentialRepository struct {
+	db *gorm.DB
+}
+
+func NewWebAuthnCredentialRepository(db *gorm.DB) WebAuthnCredentialRepository {
+	return &webAuthnCredentialRepository{db: db}
+}
+
+func (r *webAuthnCredentialRepository) Create(ctx context.Context, credential *model.WebAuthnCredential) error {
+	return r.db.WithContext(ctx).Omit("User").Create(credential).Error
+}
+
+func (r *webAuthnCredentialRepository) GetByCredentialID(ctx context.Context, credentialID []byte) (*model.WebAuthnCredential, error) {
+	var credential model.WebAuthnCredential
+	err := r.db.WithContext(ctx).First(&credential, "credential_id = ?", credentialID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+func (r *webAuthnCredentialRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]model.WebAuthnCredential, error) {
+	var credentials []model.WebAuthnCredential
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at asc").Find(&credentials).Error
+	return credentials, err
+}
+
+func (r *webAuthnCredentialRepository) Update(ctx context.Context, credential *model.WebAuthnCredential) error {
+	return r.db.WithContext(ctx).Omit("User").Save(credential).Error
+}
+
+func (r *webAuthnCredentialRepository) Delete(ctx context.Context, userID, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Delete(&model.WebAuthnCredential{}, "id = ? AND user_id = ?", id, userID)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *webAuthnCredentialRepository) DeleteByUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	result := r.db.WithContext(ctx).Delete(&model.WebAuthnCredential{}, "user_id = ?", userID)
+	return result.RowsAffected, result.Error
+}
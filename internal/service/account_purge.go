@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"wavefy-be/internal/model"
+	"wavefy-be/internal/repository"
+	"wavefy-be/internal/token"
+)
+
+// Track objects are uploaded under tracks/<user id>/ and
+// tracks/images/<user id>/; older uploads only appear in the track rows.
+const (
+	trackObjectPrefix      = "tracks/"
+	trackImageObjectPrefix = "tracks/images/"
+	purgeBatchSize         = 100
+)
+
+// AccountPurgeService hard-deletes accounts whose deletion is due: the user
+// row and what hangs off it, their tracks, their R2 objects and their
+// sessions in Redis, which frees the email for a new account.
+type AccountPurgeService interface {
+	PurgeDue(ctx context.Context) (int, error)
+}
+
+type accountPurgeService struct {
+	userRepo      repository.UserRepository
+	trackRepo     repository.TrackRepository
+	uploadService UploadService
+	refreshStore  token.RefreshTokenStore
+	revocations   token.AccessTokenRevocationStore
+	loginStore    token.LoginAttemptStore
+}
+
+func NewAccountPurgeService(userRepo repository.UserRepository, trackRepo repository.TrackRepository, uploadService UploadService, refreshStore token.RefreshTokenStore, revocations token.AccessTokenRevocationStore, loginStore token.LoginAttemptStore) AccountPurgeService {
+	return &accountPurgeService{
+		userRepo:      userRepo,
+		trackRepo:     trackRepo,
+		uploadService: uploadService,
+		refreshStore:  refreshStore,
+		revocations:   revocations,
+		loginStore:    loginStore,
+	}
+}
+
+// PurgeDue purges every due account and returns how many were purged. An
+// account that fails is logged and left for the next run, so a storage
+// outage never drops the database rows that point at its objects.
+func (s *accountPurgeService) PurgeDue(ctx context.Context) (int, error) {
+	purged := 0
+	failed := map[uuid.UUID]bool{}
+	for {
+		users, err := s.userRepo.ListDueForPurge(ctx, time.Now().UTC(), purgeBatchSize+len(failed))
+		if err != nil {
+			return purged, err
+		}
+		progressed := false
+		for i := range users {
+			user := &users[i]
+			if failed[user.ID] {
+				continue
+			}
+			if err := s.purge(ctx, user); err != nil {
+				log.Printf("account purge: user %s: %v", user.ID, err)
+				failed[user.ID] = true
+				continue
+			}
+			purged++
+			progressed = true
+		}
+		if !progressed || len(users) < purgeBatchSize+len(failed) {
+			return purged, nil
+		}
+	}
+}
+
+func (s *accountPurgeService) purge(ctx context.Context, user *model.User) error {
+	tracks, err := s.trackRepo.ListByArtist(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	prefixes := []string{
+		trackObjectPrefix + user.ID.String() + "/",
+		trackImageObjectPrefix + user.ID.String() + "/",
+		dataExportPrefix + user.ID.String() + "/",
+	}
+	for _, prefix := range prefixes {
+		if _, err := s.uploadService.DeletePrefix(ctx, prefix); err != nil {
+			return err
+		}
+	}
+	for _, key := range legacyTrackObjectKeys(tracks) {
+		if _, err := s.uploadService.DeleteObject(ctx, DeleteObjectInput{Key: key}); err != nil {
+			return err
+		}
+	}
+
+	userID := user.ID.String()
+	if err := s.refreshStore.RevokeAll(ctx, userID); err != nil {
+		return err
+	}
+	if s.revocations != nil {
+		if err := s.revocations.RevokeUserTokens(ctx, userID, time.Now()); err != nil {
+			return err
+		}
+	}
+	if s.loginStore != nil {
+		if err := s.loginStore.Reset(ctx, user.Email); err != nil {
+			return err
+		}
+	}
+
+	return s.userRepo.Purge(ctx, user.ID)
+}
+
+// legacyTrackObjectKeys returns the object keys of tracks uploaded before
+// keys were namespaced by user. Namespaced keys are skipped: the user's own
+// are removed by prefix and another user's must not be touched. Values that
+// are not keys in the track bucket, such as external URLs, are skipped too.
+func legacyTrackObjectKeys(tracks []model.Track) []string {
+	var keys []string
+	add := func(value string) {
+		value = strings.TrimSpace(value)
+		if !strings.HasPrefix(value, trackObjectPrefix) {
+			return
+		}
+		rest := strings.TrimPrefix(value, trackImageObjectPrefix)
+		if rest == value {
+			rest = strings.TrimPrefix(value, trackObjectPrefix)
+		}
+		if owner, _, ok := strings.Cut(rest, "/"); ok {
+			if _, err := uuid.Parse(owner); err == nil {
+				return
+			}
+		}
+		keys = append(keys, value)
+	}
+	for _, track := range tracks {
+		add(track.AudioURL)
+		if track.ImageURL != nil {
+			add(*track.ImageURL)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"wavefy-be/internal/model"
+	"wavefy-be/internal/repository"
+	"wavefy-be/internal/token"
+)
+
+var ErrScopeNotGranted = errors.New("scope not granted to your role")
+
+// apiKeyTouchInterval limits how often LastUsedAt is written for a busy key.
+const apiKeyTouchInterval = time.Minute
+
+type CreateAPIKeyInput struct {
+	Name      string
+	Scopes    []string
+	ExpiresAt *time.Time
+}
+
+type APIKeyService interface {
+	Create(ctx context.Context, userID uuid.UUID, input CreateAPIKeyInput) (*model.APIKey, string, error)
+	List(ctx context.Context, userID uuid.UUID) ([]model.APIKey, error)
+	Revoke(ctx context.Context, userID, keyID uuid.UUID) error
+	Authenticate(ctx context.Context, key string) (*token.APIKeyPrincipal, error)
+}
+
+type apiKeyService struct {
+	repo     repository.APIKeyRepository
+	userRepo repository.UserRepository
+	roleRepo repository.RoleRepository
+}
+
+func NewAPIKeyService(repo repository.APIKeyRepository, userRepo repository.UserRepository, roleRepo repository.RoleRepository) APIKeyService {
+	return &apiKeyService{repo: repo, userRepo: userRepo, roleRepo: roleRepo}
+}
+
+// Create issues a key limited to scopes, each of which must be a read scope
+// or a permission the user's role grants. The plain key is only returned
+// here.
+func (s *apiKeyService) Create(ctx context.Context, userID uuid.UUID, input CreateAPIKeyInput) (*model.APIKey, string, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" || len(name) > 100 || len(input.Scopes) == 0 {
+		return nil, "", ErrInvalidInput
+	}
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		return nil, "", ErrInvalidInput
+	}
+
+	granted, err := s.rolePermissions(ctx, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	scopes := make([]string, 0, len(input.Scopes))
+	seen := map[string]bool{}
+	for _, scope := range input.Scopes {
+		scope = strings.TrimSpace(scope)
+		if seen[scope] {
+			continue
+		}
+		if !granted[scope] && !containsString(model.OAuthReadScopes, scope) {
+			return nil, "", ErrScopeNotGranted
+		}
+		seen[scope] = true
+		scopes = append(scopes, scope)
+	}
+
+	plain, hash, prefix, err := token.GenerateAPIKey()
+	if err != nil {
+		return nil, "", err
+	}
+	key := &model.APIKey{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   hash,
+		Scopes:    scopes,
+		ExpiresAt: input.ExpiresAt,
+	}
+	if err := s.repo.Create(ctx, key); err != nil {
+		return nil, "", err
+	}
+	return key, plain, nil
+}
+
+func (s *apiKeyService) List(ctx context.Context, userID uuid.UUID) ([]model.APIKey, error) {
+	return s.repo.ListByUser(ctx, userID)
+}
+
+func (s *apiKeyService) Revoke(ctx context.Context, userID, keyID uuid.UUID) error {
+	deleted, err := s.repo.Delete(ctx, userID, keyID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Authenticate resolves a key sent to the API. The key acts with its scopes
+// that the owner's role still grants, so demoting the owner also narrows
+// their keys.
+func (s *apiKeyService) Authenticate(ctx context.Context, plain string) (*token.APIKeyPrincipal, error) {
+	if !token.IsAPIKey(plain) {
+		return nil, token.ErrInvalidAPIKey
+	}
+	key, err := s.repo.GetByHash(ctx, token.HashAPIKey(plain))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, token.ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+	now := time.Now()
+	if key.ExpiresAt != nil && !key.ExpiresAt.After(now) {
+		return nil, token.ErrInvalidAPIKey
+	}
+
+	user, err := s.userRepo.GetByID(ctx, key.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, token.ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+	if !user.IsActive || checkAccountStatus(user) != nil {
+		return nil, token.ErrInvalidAPIKey
+	}
+	role, err := s.roleRepo.GetByID(ctx, user.RoleID)
+	if err != nil {
+		return nil, err
+	}
+
+	granted := permissionSet(role)
+	permissions := make([]string, 0, len(key.Scopes))
+	scopes := make([]string, 0, len(key.Scopes))
+	for _, scope := range key.Scopes {
+		switch {
+		case granted[scope]:
+			permissions = append(permissions, scope)
+			scopes = append(scopes, scope)
+		case containsString(model.OAuthReadScopes, scope):
+			scopes = append(scopes, scope)
+		}
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > apiKeyTouchInterval {
+		if err := s.repo.TouchLastUsed(ctx, key.ID, now.UTC()); err != nil {
+			log.Printf("api key: touch %s: %v", key.ID, err)
+		}
+	}
+
+	return &token.APIKeyPrincipal{
+		KeyID:       key.ID.String(),
+		Subject:     user.ID.String(),
+		Role:        role.Name,
+		Permissions: permissions,
+		Scopes:      scopes,
+	}, nil
+}
+
+func (s *apiKeyService) rolePermissions(ctx context.Context, userID uuid.UUID) (map[string]bool, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	role, err := s.roleRepo.GetByID(ctx, user.RoleID)
+	if err != nil {
+		return nil, err
+	}
+	return permissionSet(role), nil
+}
+
+func permissionSet(role *model.Role) map[string]bool {
+	granted := make(map[string]bool, len(role.Permissions))
+	for _, permission := range role.Permissions {
+		granted[permission.Name] = true
+	}
+	return granted
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"wavefy-be/internal/mail"
+	"wavefy-be/internal/model"
+)
+
+var ErrDeletionNotScheduled = errors.New("account deletion is not scheduled")
+
+// RequestAccountDeletion schedules the account to be purged once the
+// deletion grace period has passed. Until then the user can still sign in
+// and cancel. Asking again keeps the original date.
+func (s *authService) RequestAccountDeletion(ctx context.Context, userID uuid.UUID, proof Reauth) (*model.User, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.reauthenticate(ctx, user, proof); err != nil {
+		return nil, err
+	}
+	if user.PurgeAt != nil {
+		return user, nil
+	}
+
+	purgeAt := time.Now().UTC().Add(s.cfg.DeletionGrace)
+	user.PurgeAt = &purgeAt
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	s.sendDeletionNotice(user)
+	return user, nil
+}
+
+func (s *authService) CancelAccountDeletion(ctx context.Context, userID uuid.UUID) (*model.User, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.PurgeAt == nil {
+		return nil, ErrDeletionNotScheduled
+	}
+	user.PurgeAt = nil
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// sendDeletionNotice tells the user when the account goes away and how to
+// keep it. Failures are only logged; the deletion stays scheduled.
+func (s *authService) sendDeletionNotice(user *model.User) {
+	if s.mailer == nil {
+		return
+	}
+	cancelURL := "http://localhost:3000/settings/account"
+	htmlBody, err := mail.RenderAccountDeletionHTML(cancelURL, *user.PurgeAt, s.cfg.DeletionGrace)
+	if err != nil {
+		log.Printf("auth: render deletion notice for %s: %v", user.ID, err)
+		return
+	}
+	if err := s.mailer.Send(user.Email, "Tài khoản Wavefy của bạn sẽ bị xoá", "", htmlBody); err != nil {
+		log.Printf("auth: send deletion notice to %s: %v", user.ID, err)
+	}
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"wavefy-be/internal/mail"
+	"wavefy-be/internal/model"
+)
+
+var ErrInvalidDeviceReport = errors.New("invalid device report token")
+
+// ReportDevice handles the "this wasn't me" link of a new device alert. The
+// reported device is forgotten, every session is signed out, and the
+// password, passkeys, linked provider accounts, API keys and partner app
+// grants are removed. Only the mailbox can get back in: the reset link sent
+// to it lists what was removed, and magic links still go to the same place.
+func (s *authService) ReportDevice(ctx context.Context, reportToken string, client ClientInfo) error {
+	if s.reportStore == nil {
+		return ErrInvalidDeviceReport
+	}
+	record, err := s.reportStore.Consume(ctx, reportToken)
+	if err != nil {
+		return ErrInvalidDeviceReport
+	}
+	userUUID, err := uuid.Parse(record.Subject)
+	if err != nil {
+		return ErrInvalidDeviceReport
+	}
+	user, err := s.userRepo.GetByID(ctx, userUUID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidDeviceReport
+		}
+		return err
+	}
+
+	if s.deviceRepo != nil {
+		if err := s.deviceRepo.DeleteByFingerprint(ctx, user.ID, record.Metadata["fingerprint"]); err != nil {
+			return err
+		}
+	}
+	user.PasswordHash = ""
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+	removed, err := s.removeSignInMethods(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.revokeAllTokens(ctx, user.ID); err != nil {
+		return err
+	}
+	s.recordResult(ctx, model.AuthEventDeviceReport, user, client, nil)
+
+	if s.resetStore != nil && s.mailer != nil {
+		if err := s.sendDeviceReportReset(ctx, user, removed); err != nil {
+			log.Printf("auth: send password reset after device report for user %s: %v", user.ID, err)
+		}
+	}
+	return nil
+}
+
+// removeSignInMethods deletes every way into the account other than the
+// password and the mailbox: passkeys, linked provider accounts, API keys and
+// partner app grants.
+func (s *authService) removeSignInMethods(ctx context.Context, userID uuid.UUID) (mail.DeviceReportRemovals, error) {
+	var removed mail.DeviceReportRemovals
+	var err error
+	if s.passkeyRepo != nil {
+		if removed.Passkeys, err = s.passkeyRepo.DeleteByUser(ctx, userID); err != nil {
+			return removed, err
+		}
+	}
+	if s.identityRepo != nil {
+		if removed.Identities, err = s.identityRepo.DeleteByUser(ctx, userID); err != nil {
+			return removed, err
+		}
+	}
+	removed.APIKeys, removed.Grants, err = s.revokeDelegatedAccess(ctx, userID)
+	return removed, err
+}
+
+func (s *authService) sendDeviceReportReset(ctx context.Context, user *model.User, removed mail.DeviceReportRemovals) error {
+	resetToken, _, err := s.resetStore.Issue(ctx, user.ID.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	subject := "Your account was secured"
+	resetURL := fmt.Sprintf("http://localhost:3000/reset-password?token=%s", resetToken)
+	htmlBody, err := mail.RenderDeviceReportResetHTML(resetURL, removed)
+	if err != nil {
+		_ = s.resetStore.Revoke(ctx, resetToken)
+		return err
+	}
+	if err := s.mailer.Send(user.Email, subject, "", htmlBody); err != nil {
+		_ = s.resetStore.Revoke(ctx, resetToken)
+		return err
+	}
+	return nil
+}
+
+// checkNewDevice remembers the device a session was opened from and mails
+// an alert when it is new for an account that already has known devices.
+// The first device is the one the account was created or first used on and
+// is remembered silently. It never fails the login.
+func (s *authService) checkNewDevice(ctx context.Context, user *model.User, client ClientInfo) {
+	if s.deviceRepo == nil {
+		return
+	}
+	fingerprint := deviceFingerprint(client)
+	now := time.Now().UTC()
+
+	device, err := s.deviceRepo.GetByFingerprint(ctx, user.ID, fingerprint)
+	if err == nil {
+		device.IP = client.IP
+		device.LastSeenAt = now
+		if err := s.deviceRepo.Update(ctx, device); err != nil {
+			log.Printf("auth: update known device of user %s: %v", user.ID, err)
+		}
+		return
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("auth: load known device of user %s: %v", user.ID, err)
+		return
+	}
+
+	known, err := s.deviceRepo.CountByUser(ctx, user.ID)
+	if err != nil {
+		log.Printf("auth: count known devices of user %s: %v", user.ID, err)
+		return
+	}
+	device = &model.KnownDevice{
+		ID:          uuid.New(),
+		UserID:      user.ID,
+		Fingerprint: fingerprint,
+		UserAgent:   truncate(client.UserAgent, 512),
+		IP:          client.IP,
+		LastSeenAt:  now,
+	}
+	if err := s.deviceRepo.Create(ctx, device); err != nil {
+		log.Printf("auth: store known device of user %s: %v", user.ID, err)
+		return
+	}
+	if known == 0 {
+		return
+	}
+	if err := s.sendNewDeviceAlert(ctx, user, device); err != nil {
+		log.Printf("auth: send new device alert to user %s: %v", user.ID, err)
+	}
+}
+
+func (s *authService) sendNewDeviceAlert(ctx context.Context, user *model.User, device *model.KnownDevice) error {
+	if s.reportStore == nil || s.mailer == nil {
+		return nil
+	}
+	reportToken, _, err := s.reportStore.Issue(ctx, user.ID.String(), map[string]string{"fingerprint": device.Fingerprint})
+	if err != nil {
+		return err
+	}
+
+	subject := "New sign-in to your account"
+	reportURL := fmt.Sprintf("http://localhost:3000/report-device?token=%s", reportToken)
+	htmlBody, err := mail.RenderNewDeviceAlertHTML(reportURL, device.UserAgent, device.IP, device.LastSeenAt, s.cfg.DeviceReportTTL)
+	if err != nil {
+		_ = s.reportStore.Revoke(ctx, reportToken)
+		return err
+	}
+	if err := s.mailer.Send(user.Email, subject, "", htmlBody); err != nil {
+		_ = s.reportStore.Revoke(ctx, reportToken)
+		return err
+	}
+	return nil
+}
+
+// deviceFingerprint identifies a device by its user agent and network: the
+// /24 of an IPv4 address or the /48 of an IPv6 address.
+func deviceFingerprint(client ClientInfo) string {
+	network := client.IP
+	if ip := net.ParseIP(client.IP); ip != nil {
+		if v4 := ip.To4(); v4 != nil {
+			network = v4.Mask(net.CIDRMask(24, 32)).String()
+		} else {
+			network = ip.Mask(net.CIDRMask(48, 128)).String()
+		}
+	}
+	sum := sha256.Sum256([]byte(client.UserAgent + "|" + network))
+	return hex.EncodeToString(sum[:])
+}
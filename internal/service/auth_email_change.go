@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"wavefy-be/internal/mail"
+	"wavefy-be/internal/model"
+	"wavefy-be/internal/token"
+)
+
+var (
+	ErrInvalidEmailChangeToken = errors.New("invalid email change token")
+	ErrSameEmail               = errors.New("new email is the current email")
+)
+
+// RequestEmailChange starts moving the account to newEmail. Nothing changes
+// until the link mailed to the new address is confirmed.
+func (s *authService) RequestEmailChange(ctx context.Context, userID uuid.UUID, proof Reauth, newEmail string) error {
+	newEmail = normalizeEmail(newEmail)
+	if newEmail == "" {
+		return ErrInvalidInput
+	}
+	if s.emailChangeStore == nil || s.mailer == nil {
+		return ErrMailNotConfigured
+	}
+
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if newEmail == user.Email {
+		return ErrSameEmail
+	}
+	if err := s.reauthenticate(ctx, user, proof); err != nil {
+		return err
+	}
+	if err := s.ensureEmailAvailable(ctx, newEmail); err != nil {
+		return err
+	}
+
+	changeToken, _, err := s.emailChangeStore.Issue(ctx, user.ID.String(), map[string]string{
+		"old_email": user.Email,
+		"new_email": newEmail,
+	})
+	if err != nil {
+		return err
+	}
+
+	confirmURL := fmt.Sprintf("http://localhost:3000/confirm-email-change?token=%s", changeToken)
+	htmlBody, err := mail.RenderEmailChangeConfirmHTML(confirmURL, newEmail)
+	if err != nil {
+		_ = s.emailChangeStore.Revoke(ctx, changeToken)
+		return err
+	}
+	if err := s.mailer.Send(newEmail, "Xác nhận email mới cho Wavefy", "", htmlBody); err != nil {
+		_ = s.emailChangeStore.Revoke(ctx, changeToken)
+		return err
+	}
+	return nil
+}
+
+// ConfirmEmailChange applies a requested change and tells the old address,
+// with a link to undo it.
+func (s *authService) ConfirmEmailChange(ctx context.Context, changeToken string) (*model.User, error) {
+	if s.emailChangeStore == nil {
+		return nil, ErrInvalidEmailChangeToken
+	}
+	record, err := s.emailChangeStore.Consume(ctx, changeToken)
+	if err != nil {
+		return nil, ErrInvalidEmailChangeToken
+	}
+	user, err := s.userForEmailToken(ctx, record, "old_email")
+	if err != nil {
+		return nil, err
+	}
+	newEmail := record.Metadata["new_email"]
+	if err := s.ensureEmailAvailable(ctx, newEmail); err != nil {
+		return nil, err
+	}
+
+	oldEmail := user.Email
+	user.Email = newEmail
+	markEmailVerified(user)
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	if err := s.sendEmailChangeNotice(ctx, user, oldEmail, newEmail); err != nil {
+		log.Printf("auth: email change notice for user %s: %v", user.ID, err)
+	}
+	return user, nil
+}
+
+// RevertEmailChange restores the previous address from the link sent to it.
+// The change is treated as a takeover, so every session is revoked.
+func (s *authService) RevertEmailChange(ctx context.Context, revertToken string) error {
+	if s.emailRevertStore == nil {
+		return ErrInvalidEmailChangeToken
+	}
+	record, err := s.emailRevertStore.Consume(ctx, revertToken)
+	if err != nil {
+		return ErrInvalidEmailChangeToken
+	}
+	user, err := s.userForEmailToken(ctx, record, "new_email")
+	if err != nil {
+		return err
+	}
+	oldEmail := record.Metadata["old_email"]
+	if err := s.ensureEmailAvailable(ctx, oldEmail); err != nil {
+		return err
+	}
+
+	user.Email = oldEmail
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+	return s.revokeAllTokens(ctx, user.ID)
+}
+
+func (s *authService) sendEmailChangeNotice(ctx context.Context, user *model.User, oldEmail, newEmail string) error {
+	if s.emailRevertStore == nil || s.mailer == nil {
+		return ErrMailNotConfigured
+	}
+	revertToken, _, err := s.emailRevertStore.Issue(ctx, user.ID.String(), map[string]string{
+		"old_email": oldEmail,
+		"new_email": newEmail,
+	})
+	if err != nil {
+		return err
+	}
+
+	revertURL := fmt.Sprintf("http://localhost:3000/revert-email-change?token=%s", revertToken)
+	htmlBody, err := mail.RenderEmailChangeNoticeHTML(revertURL, oldEmail, newEmail, s.cfg.EmailRevertTTL)
+	if err != nil {
+		_ = s.emailRevertStore.Revoke(ctx, revertToken)
+		return err
+	}
+	if err := s.mailer.Send(oldEmail, "Email tài khoản Wavefy đã thay đổi", "", htmlBody); err != nil {
+		_ = s.emailRevertStore.Revoke(ctx, revertToken)
+		return err
+	}
+	return nil
+}
+
+// userForEmailToken loads the token subject and checks the account still has
+// the address recorded under currentKey, so stale links do nothing.
+func (s *authService) userForEmailToken(ctx context.Context, record *token.SignedToken, currentKey string) (*model.User, error) {
+	userID, err := uuid.Parse(record.Subject)
+	if err != nil {
+		return nil, ErrInvalidEmailChangeToken
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidEmailChangeToken
+		}
+		return nil, err
+	}
+	if user.Email != record.Metadata[currentKey] || record.Metadata["old_email"] == "" || record.Metadata["new_email"] == "" {
+		return nil, ErrInvalidEmailChangeToken
+	}
+	return user, nil
+}
+
+func (s *authService) ensureEmailAvailable(ctx context.Context, email string) error {
+	if _, err := s.userRepo.GetByEmail(ctx, email); err == nil {
+		return ErrEmailExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/google/uuid"
+
+	"wavefy-be/internal/model"
+	"wavefy-be/internal/repository"
+)
+
+// Login methods recorded on login events. Provider logins use the provider
+// name.
+const (
+	loginMethodPassword  = "password"
+	loginMethodPasskey   = "passkey"
+	loginMethodMagicLink = "magic_link"
+	loginMethodMFA       = "mfa"
+)
+
+const (
+	defaultActivityLimit = 20
+	maxAuthEventLimit    = 200
+)
+
+// ListAuthEvents returns the audit log for administrators, newest first.
+func (s *authService) ListAuthEvents(ctx context.Context, filter repository.AuthEventFilter, limit, offset int) ([]model.AuthEvent, error) {
+	if s.eventRepo == nil {
+		return nil, nil
+	}
+	if filter.Email != "" {
+		filter.Email = normalizeEmail(filter.Email)
+	}
+	return s.eventRepo.List(ctx, filter, clampAuthEventLimit(limit), offset)
+}
+
+// ListActivity returns the recent authentication events of one user so they
+// can spot sign-ins they do not recognise. Support sessions show up by their
+// start; the requests made in them are only in the audit log.
+func (s *authService) ListActivity(ctx context.Context, userID uuid.UUID, limit int) ([]model.AuthEvent, error) {
+	if s.eventRepo == nil {
+		return nil, nil
+	}
+	if limit <= 0 {
+		limit = defaultActivityLimit
+	}
+	filter := repository.AuthEventFilter{UserID: &userID, SkipTypes: []string{model.AuthEventImpersonatedRequest}}
+	return s.eventRepo.List(ctx, filter, clampAuthEventLimit(limit), 0)
+}
+
+// recordLogin records the outcome of a login attempt. user is the account
+// the attempt resolved to, if any; email is what the client sent.
+func (s *authService) recordLogin(ctx context.Context, method, email string, user *model.User, client ClientInfo, err error) {
+	event := &model.AuthEvent{
+		Type:   model.AuthEventLogin,
+		Method: method,
+		Email:  normalizeEmail(email),
+	}
+	var mfaErr *MFARequiredError
+	switch {
+	case err == nil:
+		event.Outcome = model.AuthOutcomeSuccess
+	case errors.As(err, &mfaErr):
+		event.Outcome = model.AuthOutcomeMFARequired
+	default:
+		event.Outcome = model.AuthOutcomeFailure
+		event.Reason = err.Error()
+	}
+	s.recordEvent(ctx, event, user, client)
+}
+
+// recordResult records an event whose outcome is the error of the flow.
+func (s *authService) recordResult(ctx context.Context, eventType string, user *model.User, client ClientInfo, err error) {
+	event := &model.AuthEvent{Type: eventType, Outcome: model.AuthOutcomeSuccess}
+	if err != nil {
+		event.Outcome = model.AuthOutcomeFailure
+		event.Reason = err.Error()
+	}
+	s.recordEvent(ctx, event, user, client)
+}
+
+// recordLockout records that repeated failures locked the email out.
+func (s *authService) recordLockout(ctx context.Context, email string, user *model.User, client ClientInfo) {
+	s.recordEvent(ctx, &model.AuthEvent{
+		Type:    model.AuthEventLockout,
+		Email:   normalizeEmail(email),
+		Outcome: model.AuthOutcomeFailure,
+		Reason:  ErrTooManyAttempts.Error(),
+	}, user, client)
+}
+
+// recordEvent writes an audit event. Failing to record never fails the
+// request it describes.
+func (s *authService) recordEvent(ctx context.Context, event *model.AuthEvent, user *model.User, client ClientInfo) {
+	if s.eventRepo == nil {
+		return
+	}
+	event.ID = uuid.New()
+	if user != nil {
+		userID := user.ID
+		event.UserID = &userID
+		event.Email = user.Email
+	}
+	event.IP = client.IP
+	event.UserAgent = truncate(client.UserAgent, 512)
+	event.Reason = truncate(event.Reason, 255)
+	if err := s.eventRepo.Create(ctx, event); err != nil {
+		log.Printf("auth: record %s event: %v", event.Type, err)
+	}
+}
+
+func clampAuthEventLimit(limit int) int {
+	if limit <= 0 || limit > maxAuthEventLimit {
+		return maxAuthEventLimit
+	}
+	return limit
+}
+
+func truncate(value string, limit int) string {
+	if len(value) <= limit {
+		return value
+	}
+	return value[:limit]
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"wavefy-be/internal/model"
+	"wavefy-be/internal/oauth"
+)
+
+var (
+	ErrProviderNotConfigured = errors.New("provider not configured")
+	ErrProviderEmailRequired = errors.New("provider did not return a verified email")
+	ErrIdentityInUse         = errors.New("identity linked to another account")
+	ErrIdentityNotFound      = errors.New("identity not found")
+	ErrLastLoginMethod       = errors.New("cannot remove the last sign-in method")
+)
+
+// LoginWithProvider signs a user in with an external identity. A known
+// provider subject logs into its linked user; otherwise the identity is
+// linked to the account with the same verified email, or a new account
+// without a password is created.
+func (s *authService) LoginWithProvider(ctx context.Context, providerName string, credential oauth.Credential, client ClientInfo) (*model.User, *AuthToken, error) {
+	identity, err := s.authenticateProvider(ctx, providerName, credential)
+	if err != nil {
+		if err != ErrProviderNotConfigured {
+			s.recordLogin(ctx, strings.ToLower(providerName), "", nil, client, err)
+		}
+		return nil, nil, err
+	}
+
+	user, err := s.userForIdentity(ctx, identity)
+	if err != nil {
+		s.recordLogin(ctx, identity.Provider, identity.Email, nil, client, err)
+		return nil, nil, err
+	}
+
+	authToken, err := s.completeLogin(ctx, user, client)
+	s.recordLogin(ctx, identity.Provider, "", user, client, err)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, authToken, nil
+}
+
+func (s *authService) ListProviders() []string {
+	return s.providers.Names()
+}
+
+func (s *authService) ListIdentities(ctx context.Context, userID uuid.UUID) ([]model.Identity, error) {
+	return s.identityRepo.ListByUser(ctx, userID)
+}
+
+func (s *authService) LinkIdentity(ctx context.Context, userID uuid.UUID, providerName string, credential oauth.Credential) (*model.Identity, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	identity, err := s.authenticateProvider(ctx, providerName, credential)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.identityRepo.GetByProviderSubject(ctx, identity.Provider, identity.Subject)
+	if err == nil {
+		if existing.UserID != user.ID {
+			return nil, ErrIdentityInUse
+		}
+		return existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return s.createIdentity(ctx, user.ID, identity)
+}
+
+func (s *authService) UnlinkIdentity(ctx context.Context, userID, identityID uuid.UUID) error {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	identities, err := s.identityRepo.ListByUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, identity := range identities {
+		if identity.ID == identityID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return ErrIdentityNotFound
+	}
+
+	if user.PasswordHash == "" && len(identities) == 1 {
+		passkeys, err := s.passkeyRepo.ListByUser(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		if len(passkeys) == 0 {
+			return ErrLastLoginMethod
+		}
+	}
+
+	if _, err := s.identityRepo.Delete(ctx, user.ID, identityID); err != nil {
+		return err
+	}
+	return nil
+}
+
+// SetPassword sets the password of the current user. The current password
+// proves the user is present; accounts without one use a token from the
+// link RequestReauthentication mails, so a stolen access token cannot add a
+// password. Every other session is signed out and the caller gets fresh
+// tokens.
+func (s *authService) SetPassword(ctx context.Context, userID uuid.UUID, proof Reauth, newPassword string, client ClientInfo) (*model.User, *AuthToken, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.policy.Check("new_password", newPassword, user.Email); err != nil {
+		return nil, nil, err
+	}
+	if err := s.reauthenticate(ctx, user, proof); err != nil {
+		return nil, nil, err
+	}
+
+	hash, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		return nil, nil, err
+	}
+	user.PasswordHash = hash
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, nil, err
+	}
+	if err := s.revokeAllTokens(ctx, user.ID); err != nil {
+		return nil, nil, err
+	}
+	authToken, err := s.issueTokens(ctx, user, client)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, authToken, nil
+}
+
+func (s *authService) authenticateProvider(ctx context.Context, providerName string, credential oauth.Credential) (*oauth.Identity, error) {
+	provider, ok := s.providers.Get(providerName)
+	if !ok {
+		return nil, ErrProviderNotConfigured
+	}
+	identity, err := provider.Authenticate(ctx, credential)
+	if err != nil {
+		switch err {
+		case oauth.ErrInvalidCredential:
+			return nil, ErrInvalidCredentials
+		case oauth.ErrUnsupportedCredential:
+			return nil, ErrInvalidInput
+		default:
+			return nil, err
+		}
+	}
+	if identity.Subject == "" {
+		return nil, ErrInvalidCredentials
+	}
+	return identity, nil
+}
+
+func (s *authService) userForIdentity(ctx context.Context, identity *oauth.Identity) (*model.User, error) {
+	linked, err := s.identityRepo.GetByProviderSubject(ctx, identity.Provider, identity.Subject)
+	if err == nil {
+		return s.getUser(ctx, linked.UserID)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	email := normalizeEmail(identity.Email)
+	if email == "" || !identity.EmailVerified {
+		return nil, ErrProviderEmailRequired
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		role, roleErr := s.roleRepo.GetByName(ctx, "USER")
+		if roleErr != nil {
+			if errors.Is(roleErr, gorm.ErrRecordNotFound) {
+				return nil, ErrInvalidInput
+			}
+			return nil, roleErr
+		}
+		user = &model.User{
+			ID:        uuid.New(),
+			FirstName: identity.FirstName,
+			LastName:  identity.LastName,
+			Email:     email,
+			IsActive:  true,
+			Status:    model.UserStatusActive,
+			RoleID:    role.ID,
+			Role:      *role,
+		}
+		if err := s.userRepo.Create(ctx, user); err != nil {
+			return nil, err
+		}
+	} else if !user.IsActive {
+		// Nobody proved they own this address before, so a password set at
+		// registration may belong to someone else. Drop it before handing the
+		// account to the verified owner.
+		markEmailVerified(user)
+		user.PasswordHash = ""
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := s.createIdentity(ctx, user.ID, identity); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *authService) createIdentity(ctx context.Context, userID uuid.UUID, identity *oauth.Identity) (*model.Identity, error) {
+	record := &model.Identity{
+		ID:       uuid.New(),
+		UserID:   userID,
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Email:    strings.TrimSpace(identity.Email),
+	}
+	if err := s.identityRepo.Create(ctx, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
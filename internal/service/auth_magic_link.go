@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"wavefy-be/internal/mail"
+	"wavefy-be/internal/model"
+)
+
+var (
+	ErrMagicLinkNotConfigured = errors.New("magic link not configured")
+	ErrInvalidMagicLink       = errors.New("invalid magic link")
+)
+
+// RequestMagicLink mails a single-use login link. Unknown emails count as a
+// failed login and get the same answer as known ones.
+func (s *authService) RequestMagicLink(ctx context.Context, email string) error {
+	email = normalizeEmail(email)
+	if email == "" {
+		return ErrInvalidInput
+	}
+	if s.magicStore == nil || s.mailer == nil || s.cfg.MagicLinkSecret == "" {
+		return ErrMagicLinkNotConfigured
+	}
+
+	if s.loginStore != nil {
+		locked, err := s.loginStore.IsLocked(ctx, email)
+		if err != nil {
+			return err
+		}
+		if locked {
+			return ErrTooManyAttempts
+		}
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if s.loginStore != nil {
+			if _, locked, err := s.loginStore.RecordFailure(ctx, email); err != nil {
+				return err
+			} else if locked {
+				return ErrTooManyAttempts
+			}
+		}
+		return nil
+	}
+
+	loginToken, _, err := s.magicStore.Issue(ctx, user.ID.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	loginURL := fmt.Sprintf("http://localhost:3000/magic-link?token=%s", loginToken)
+	htmlBody, err := mail.RenderMagicLinkHTML(loginURL, s.cfg.MagicLinkTTL)
+	if err != nil {
+		_ = s.magicStore.Revoke(ctx, loginToken)
+		return err
+	}
+
+	subject := "Đăng nhập Wavefy"
+	if err := s.mailer.Send(user.Email, subject, "", htmlBody); err != nil {
+		_ = s.magicStore.Revoke(ctx, loginToken)
+		return err
+	}
+	return nil
+}
+
+// ConsumeMagicLink signs the user in with a link from RequestMagicLink.
+// Opening the link proves the user owns the address, so it also verifies
+// the email.
+func (s *authService) ConsumeMagicLink(ctx context.Context, loginToken string, client ClientInfo) (*model.User, *AuthToken, error) {
+	user, authToken, err := s.consumeMagicLink(ctx, loginToken, client)
+	s.recordLogin(ctx, loginMethodMagicLink, "", user, client, err)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, authToken, nil
+}
+
+func (s *authService) consumeMagicLink(ctx context.Context, loginToken string, client ClientInfo) (*model.User, *AuthToken, error) {
+	loginToken = strings.TrimSpace(loginToken)
+	if loginToken == "" {
+		return nil, nil, ErrInvalidInput
+	}
+	if s.magicStore == nil || s.cfg.MagicLinkSecret == "" {
+		return nil, nil, ErrMagicLinkNotConfigured
+	}
+
+	record, err := s.magicStore.Consume(ctx, loginToken)
+	if err != nil {
+		return nil, nil, ErrInvalidMagicLink
+	}
+	userUUID, err := uuid.Parse(record.Subject)
+	if err != nil {
+		return nil, nil, ErrInvalidMagicLink
+	}
+	user, err := s.userRepo.GetByID(ctx, userUUID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidMagicLink
+		}
+		return nil, nil, err
+	}
+
+	if s.loginStore != nil {
+		locked, err := s.loginStore.IsLocked(ctx, user.Email)
+		if err != nil {
+			return nil, nil, err
+		}
+		if locked {
+			return user, nil, ErrTooManyAttempts
+		}
+		_ = s.loginStore.Reset(ctx, user.Email)
+	}
+
+	if !user.IsActive {
+		// The password of an unverified account may have been set by someone
+		// who does not own the address. Drop it along with the verification.
+		markEmailVerified(user)
+		user.PasswordHash = ""
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return user, nil, err
+		}
+	}
+
+	authToken, err := s.completeLogin(ctx, user, client)
+	if err != nil {
+		return user, nil, err
+	}
+	return user, authToken, nil
+}
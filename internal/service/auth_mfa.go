@@ -0,0 +1,295 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"wavefy-be/internal/mfa"
+	"wavefy-be/internal/model"
+)
+
+var (
+	ErrMFANotConfigured    = errors.New("mfa not configured")
+	ErrMFAAlreadyEnabled   = errors.New("mfa already enabled")
+	ErrMFANotEnabled       = errors.New("mfa not enabled")
+	ErrInvalidMFACode      = errors.New("invalid mfa code")
+	ErrInvalidMFAChallenge = errors.New("invalid mfa challenge")
+)
+
+// MFARequiredError is returned by the login methods when the password (or
+// external credential) was accepted but the account has a second factor.
+// The challenge token must be exchanged through VerifyMFA.
+type MFARequiredError struct {
+	ChallengeToken string
+	ExpiresAt      time.Time
+}
+
+func (e *MFARequiredError) Error() string {
+	return "mfa required"
+}
+
+type TOTPSetup struct {
+	Secret string
+	URI    string
+}
+
+var totpCodePattern = regexp.MustCompile(`^[0-9]{6}$`)
+
+func (s *authService) SetupTOTP(ctx context.Context, userID uuid.UUID) (*TOTPSetup, error) {
+	if s.mfaCipher == nil {
+		return nil, ErrMFANotConfigured
+	}
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	enrollment, err := mfa.GenerateTOTP(s.cfg.MFAIssuer, user.Email)
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := s.mfaCipher.Encrypt(enrollment.Secret)
+	if err != nil {
+		return nil, err
+	}
+	// The secret is stored right away but only takes effect once a code from
+	// it has been confirmed.
+	user.TOTPSecret = encrypted
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return &TOTPSetup{Secret: enrollment.Secret, URI: enrollment.URI}, nil
+}
+
+func (s *authService) ConfirmTOTP(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	if s.mfaCipher == nil {
+		return nil, ErrMFANotConfigured
+	}
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrMFANotEnabled
+	}
+
+	ok, err := s.checkTOTP(ctx, user, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes, err := s.replaceRecoveryCodes(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	user.TOTPEnabled = true
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *authService) DisableTOTP(ctx context.Context, userID uuid.UUID, code string) error {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return ErrMFANotEnabled
+	}
+
+	ok, err := s.checkSecondFactor(ctx, user, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidMFACode
+	}
+
+	user.TOTPEnabled = false
+	user.TOTPSecret = ""
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+	return s.recoveryRepo.DeleteByUser(ctx, user.ID)
+}
+
+func (s *authService) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled {
+		return nil, ErrMFANotEnabled
+	}
+
+	ok, err := s.checkTOTP(ctx, user, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+	return s.replaceRecoveryCodes(ctx, user.ID)
+}
+
+func (s *authService) VerifyMFA(ctx context.Context, challengeToken, code string, client ClientInfo) (*model.User, *AuthToken, error) {
+	user, authToken, err := s.verifyMFA(ctx, challengeToken, code, client)
+	s.recordLogin(ctx, loginMethodMFA, "", user, client, err)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, authToken, nil
+}
+
+func (s *authService) verifyMFA(ctx context.Context, challengeToken, code string, client ClientInfo) (*model.User, *AuthToken, error) {
+	challengeToken = strings.TrimSpace(challengeToken)
+	if challengeToken == "" || strings.TrimSpace(code) == "" {
+		return nil, nil, ErrInvalidInput
+	}
+	if s.mfaStore == nil {
+		return nil, nil, ErrMFANotConfigured
+	}
+
+	userID, err := s.mfaStore.Verify(ctx, challengeToken)
+	if err != nil {
+		return nil, nil, ErrInvalidMFAChallenge
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, nil, ErrInvalidMFAChallenge
+	}
+	user, err := s.userRepo.GetByID(ctx, userUUID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidMFAChallenge
+		}
+		return nil, nil, err
+	}
+
+	ok, err := s.checkSecondFactor(ctx, user, code)
+	if err != nil {
+		return user, nil, err
+	}
+	if !ok {
+		locked, err := s.mfaStore.RecordFailure(ctx, challengeToken)
+		if err != nil {
+			return user, nil, err
+		}
+		if s.loginStore != nil {
+			if _, emailLocked, err := s.loginStore.RecordFailure(ctx, user.Email); err != nil {
+				return user, nil, err
+			} else if emailLocked {
+				locked = true
+			}
+		}
+		if locked {
+			s.recordLockout(ctx, user.Email, user, client)
+			return user, nil, ErrTooManyAttempts
+		}
+		return user, nil, ErrInvalidMFACode
+	}
+
+	_ = s.mfaStore.Revoke(ctx, challengeToken)
+	authToken, err := s.issueTokens(ctx, user, client)
+	if err != nil {
+		return user, nil, err
+	}
+	return user, authToken, nil
+}
+
+// completeLogin finishes a successful first-factor login: accounts with TOTP
+// get a challenge instead of tokens.
+func (s *authService) completeLogin(ctx context.Context, user *model.User, client ClientInfo) (*AuthToken, error) {
+	if err := checkAccountStatus(user); err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		if s.mfaStore == nil {
+			return nil, ErrMFANotConfigured
+		}
+		challenge, expiresAt, err := s.mfaStore.Create(ctx, user.ID.String())
+		if err != nil {
+			return nil, err
+		}
+		return nil, &MFARequiredError{ChallengeToken: challenge, ExpiresAt: expiresAt}
+	}
+	return s.issueTokens(ctx, user, client)
+}
+
+// checkSecondFactor accepts either a current TOTP code or an unused recovery
+// code.
+func (s *authService) checkSecondFactor(ctx context.Context, user *model.User, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	if totpCodePattern.MatchString(code) {
+		return s.checkTOTP(ctx, user, code)
+	}
+	if code == "" {
+		return false, nil
+	}
+	return s.recoveryRepo.Consume(ctx, user.ID, mfa.HashRecoveryCode(code))
+}
+
+func (s *authService) checkTOTP(ctx context.Context, user *model.User, code string) (bool, error) {
+	if s.mfaCipher == nil {
+		return false, ErrMFANotConfigured
+	}
+	code = strings.TrimSpace(code)
+	if !totpCodePattern.MatchString(code) || user.TOTPSecret == "" {
+		return false, nil
+	}
+	secret, err := s.mfaCipher.Decrypt(user.TOTPSecret)
+	if err != nil {
+		return false, err
+	}
+	step, ok := mfa.ValidateTOTP(secret, code, time.Now().UTC())
+	if !ok {
+		return false, nil
+	}
+	if s.mfaStore == nil {
+		return true, nil
+	}
+	return s.mfaStore.MarkStepUsed(ctx, user.ID.String(), step)
+}
+
+func (s *authService) replaceRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	codes, err := mfa.GenerateRecoveryCodes(mfa.RecoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hashes = append(hashes, mfa.HashRecoveryCode(code))
+	}
+	if err := s.recoveryRepo.Replace(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *authService) getUser(ctx context.Context, userID uuid.UUID) (*model.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
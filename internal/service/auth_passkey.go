@@ -0,0 +1,332 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"wavefy-be/config"
+	"wavefy-be/internal/model"
+)
+
+var (
+	ErrPasskeyNotConfigured  = errors.New("passkeys not configured")
+	ErrPasskeyNotFound       = errors.New("passkey not found")
+	ErrInvalidPasskey        = errors.New("invalid passkey")
+	ErrInvalidPasskeySession = errors.New("invalid passkey session")
+)
+
+// PasskeyCeremony is the first half of a passkey registration or login.
+// Options is handed to navigator.credentials.create/get as is and SessionID
+// must be sent back with the authenticator response.
+type PasskeyCeremony struct {
+	SessionID string
+	ExpiresAt time.Time
+	Options   interface{}
+}
+
+// webAuthnUser adapts a user and their stored passkeys to webauthn.User. The
+// user handle is the raw user id.
+type webAuthnUser struct {
+	user        *model.User
+	credentials []model.WebAuthnCredential
+}
+
+func (u *webAuthnUser) WebAuthnID() []byte {
+	id := u.user.ID
+	return id[:]
+}
+
+func (u *webAuthnUser) WebAuthnName() string {
+	return u.user.Email
+}
+
+func (u *webAuthnUser) WebAuthnDisplayName() string {
+	name := strings.TrimSpace(u.user.FirstName + " " + u.user.LastName)
+	if name == "" {
+		return u.user.Email
+	}
+	return name
+}
+
+func (u *webAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(u.credentials))
+	for _, c := range u.credentials {
+		credentials = append(credentials, toWebAuthnCredential(c))
+	}
+	return credentials
+}
+
+func newWebAuthn(cfg config.AuthConfig) *webauthn.WebAuthn {
+	if cfg.WebAuthnRPID == "" || len(cfg.WebAuthnRPOrigins) == 0 {
+		return nil
+	}
+	timeout := webauthn.TimeoutConfig{Enforce: true, Timeout: cfg.WebAuthnTimeout, TimeoutUVD: cfg.WebAuthnTimeout}
+	w, err := webauthn.New(&webauthn.Config{
+		RPID:          cfg.WebAuthnRPID,
+		RPDisplayName: cfg.WebAuthnRPName,
+		RPOrigins:     cfg.WebAuthnRPOrigins,
+		Timeouts: webauthn.TimeoutsConfig{
+			Login:        timeout,
+			Registration: timeout,
+		},
+	})
+	if err != nil {
+		return nil
+	}
+	return w
+}
+
+func (s *authService) BeginPasskeyRegistration(ctx context.Context, userID uuid.UUID) (*PasskeyCeremony, error) {
+	if s.webAuthn == nil || s.webAuthnStore == nil {
+		return nil, ErrPasskeyNotConfigured
+	}
+	user, err := s.loadWebAuthnUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	creation, session, err := s.webAuthn.BeginRegistration(user,
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementRequired),
+		webauthn.WithExclusions(webauthn.Credentials(user.WebAuthnCredentials()).CredentialDescriptors()),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return s.storePasskeySession(ctx, userID.String(), session, creation)
+}
+
+func (s *authService) FinishPasskeyRegistration(ctx context.Context, userID uuid.UUID, sessionID, name string, response []byte) (*model.WebAuthnCredential, error) {
+	if s.webAuthn == nil || s.webAuthnStore == nil {
+		return nil, ErrPasskeyNotConfigured
+	}
+	owner, session, err := s.loadPasskeySession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if owner != userID.String() {
+		return nil, ErrInvalidPasskeySession
+	}
+	user, err := s.loadWebAuthnUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(response)
+	if err != nil {
+		return nil, ErrInvalidPasskey
+	}
+	credential, err := s.webAuthn.CreateCredential(user, *session, parsed)
+	if err != nil {
+		return nil, ErrInvalidPasskey
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "Passkey"
+	}
+	record := fromWebAuthnCredential(credential)
+	record.ID = uuid.New()
+	record.UserID = userID
+	record.Name = name
+	if err := s.passkeyRepo.Create(ctx, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (s *authService) ListPasskeys(ctx context.Context, userID uuid.UUID) ([]model.WebAuthnCredential, error) {
+	return s.passkeyRepo.ListByUser(ctx, userID)
+}
+
+func (s *authService) DeletePasskey(ctx context.Context, userID, passkeyID uuid.UUID) error {
+	deleted, err := s.passkeyRepo.Delete(ctx, userID, passkeyID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrPasskeyNotFound
+	}
+	return nil
+}
+
+func (s *authService) BeginPasskeyLogin(ctx context.Context) (*PasskeyCeremony, error) {
+	if s.webAuthn == nil || s.webAuthnStore == nil {
+		return nil, ErrPasskeyNotConfigured
+	}
+	// User verification is required so a passkey counts as both factors and
+	// the login skips the TOTP challenge.
+	assertion, session, err := s.webAuthn.BeginDiscoverableLogin(
+		webauthn.WithUserVerification(protocol.VerificationRequired),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return s.storePasskeySession(ctx, "", session, assertion)
+}
+
+func (s *authService) FinishPasskeyLogin(ctx context.Context, sessionID string, response []byte, client ClientInfo) (*model.User, *AuthToken, error) {
+	user, authToken, err := s.finishPasskeyLogin(ctx, sessionID, response, client)
+	s.recordLogin(ctx, loginMethodPasskey, "", user, client, err)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, authToken, nil
+}
+
+func (s *authService) finishPasskeyLogin(ctx context.Context, sessionID string, response []byte, client ClientInfo) (*model.User, *AuthToken, error) {
+	if s.webAuthn == nil || s.webAuthnStore == nil {
+		return nil, nil, ErrPasskeyNotConfigured
+	}
+	owner, session, err := s.loadPasskeySession(ctx, sessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if owner != "" {
+		return nil, nil, ErrInvalidPasskeySession
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(response)
+	if err != nil {
+		return nil, nil, ErrInvalidPasskey
+	}
+
+	var found *webAuthnUser
+	handler := func(rawID, userHandle []byte) (webauthn.User, error) {
+		userID, err := uuid.FromBytes(userHandle)
+		if err != nil {
+			return nil, ErrInvalidPasskey
+		}
+		user, err := s.loadWebAuthnUser(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		found = user
+		return user, nil
+	}
+	_, credential, err := s.webAuthn.ValidatePasskeyLogin(handler, *session, parsed)
+	if err != nil {
+		return nil, nil, ErrInvalidPasskey
+	}
+	if credential.Authenticator.CloneWarning {
+		return nil, nil, ErrInvalidPasskey
+	}
+
+	record, err := s.passkeyRepo.GetByCredentialID(ctx, credential.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidPasskey
+		}
+		return nil, nil, err
+	}
+	now := time.Now().UTC()
+	record.SignCount = int64(credential.Authenticator.SignCount)
+	record.BackupState = credential.Flags.BackupState
+	record.LastUsedAt = &now
+	if err := s.passkeyRepo.Update(ctx, record); err != nil {
+		return nil, nil, err
+	}
+
+	user := found.user
+	if err := checkAccountStatus(user); err != nil {
+		return user, nil, err
+	}
+	if !user.IsActive {
+		return user, nil, ErrEmailNotVerified
+	}
+	authToken, err := s.issueTokens(ctx, user, client)
+	if err != nil {
+		return user, nil, err
+	}
+	return user, authToken, nil
+}
+
+func (s *authService) loadWebAuthnUser(ctx context.Context, userID uuid.UUID) (*webAuthnUser, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	credentials, err := s.passkeyRepo.ListByUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &webAuthnUser{user: user, credentials: credentials}, nil
+}
+
+func (s *authService) storePasskeySession(ctx context.Context, userID string, session *webauthn.SessionData, options interface{}) (*PasskeyCeremony, error) {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return nil, err
+	}
+	sessionID, expiresAt, err := s.webAuthnStore.Create(ctx, userID, data)
+	if err != nil {
+		return nil, err
+	}
+	return &PasskeyCeremony{SessionID: sessionID, ExpiresAt: expiresAt, Options: options}, nil
+}
+
+func (s *authService) loadPasskeySession(ctx context.Context, sessionID string) (string, *webauthn.SessionData, error) {
+	sessionID = strings.TrimSpace(sessionID)
+	if sessionID == "" {
+		return "", nil, ErrInvalidInput
+	}
+	userID, data, err := s.webAuthnStore.Consume(ctx, sessionID)
+	if err != nil {
+		return "", nil, ErrInvalidPasskeySession
+	}
+	var session webauthn.SessionData
+	if err := json.Unmarshal(data, &session); err != nil {
+		return "", nil, ErrInvalidPasskeySession
+	}
+	return userID, &session, nil
+}
+
+func toWebAuthnCredential(c model.WebAuthnCredential) webauthn.Credential {
+	var transports []protocol.AuthenticatorTransport
+	for _, t := range strings.Split(c.Transports, ",") {
+		if t != "" {
+			transports = append(transports, protocol.AuthenticatorTransport(t))
+		}
+	}
+	return webauthn.Credential{
+		ID:              c.CredentialID,
+		PublicKey:       c.PublicKey,
+		AttestationType: c.AttestationType,
+		Transport:       transports,
+		Flags: webauthn.CredentialFlags{
+			UserPresent:    true,
+			UserVerified:   c.UserVerified,
+			BackupEligible: c.BackupEligible,
+			BackupState:    c.BackupState,
+		},
+		Authenticator: webauthn.Authenticator{
+			AAGUID:    c.AAGUID,
+			SignCount: uint32(c.SignCount),
+		},
+	}
+}
+
+func fromWebAuthnCredential(c *webauthn.Credential) model.WebAuthnCredential {
+	transports := make([]string, 0, len(c.Transport))
+	for _, t := range c.Transport {
+		transports = append(transports, string(t))
+	}
+	return model.WebAuthnCredential{
+		CredentialID:    c.ID,
+		PublicKey:       c.PublicKey,
+		AttestationType: c.AttestationType,
+		Transports:      strings.Join(transports, ","),
+		AAGUID:          c.Authenticator.AAGUID,
+		SignCount:       int64(c.Authenticator.SignCount),
+		UserVerified:    c.Flags.UserVerified,
+		BackupEligible:  c.Flags.BackupEligible,
+		BackupState:     c.Flags.BackupState,
+	}
+}
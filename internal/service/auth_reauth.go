@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"wavefy-be/internal/mail"
+	"wavefy-be/internal/model"
+)
+
+var ErrInvalidReauthToken = errors.New("invalid reauthentication token")
+
+// Reauth proves the user is present before a sensitive change: either the
+// current password or a token from the link RequestReauthentication mails.
+// Accounts that sign in with a provider, a passkey or a magic link have no
+// password and can only use the link.
+type Reauth struct {
+	CurrentPassword string
+	Token           string
+}
+
+// RequestReauthentication mails a single-use link to the account's address.
+// The token in it stands in for the current password when setting a
+// password, changing the email or deleting the account.
+func (s *authService) RequestReauthentication(ctx context.Context, userID uuid.UUID) error {
+	if s.reauthStore == nil || s.mailer == nil {
+		return ErrMailNotConfigured
+	}
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	reauthToken, _, err := s.reauthStore.Issue(ctx, user.ID.String(), map[string]string{
+		"email": user.Email,
+	})
+	if err != nil {
+		return err
+	}
+
+	confirmURL := fmt.Sprintf("http://localhost:3000/reauth?token=%s", reauthToken)
+	htmlBody, err := mail.RenderReauthHTML(confirmURL, s.cfg.ReauthTTL)
+	if err != nil {
+		_ = s.reauthStore.Revoke(ctx, reauthToken)
+		return err
+	}
+	if err := s.mailer.Send(user.Email, "Xác nhận danh tính trên Wavefy", "", htmlBody); err != nil {
+		_ = s.reauthStore.Revoke(ctx, reauthToken)
+		return err
+	}
+	return nil
+}
+
+// reauthenticate checks proof for user. A token is spent even if the change
+// it was meant for fails later, so it cannot be replayed.
+func (s *authService) reauthenticate(ctx context.Context, user *model.User, proof Reauth) error {
+	if reauthToken := strings.TrimSpace(proof.Token); reauthToken != "" {
+		if s.reauthStore == nil {
+			return ErrInvalidReauthToken
+		}
+		record, err := s.reauthStore.Consume(ctx, reauthToken)
+		if err != nil {
+			return ErrInvalidReauthToken
+		}
+		// A link mailed before an email change must not outlive it.
+		if record.Subject != user.ID.String() || record.Metadata["email"] != user.Email {
+			return ErrInvalidReauthToken
+		}
+		return nil
+	}
+
+	if user.PasswordHash == "" || proof.CurrentPassword == "" {
+		return ErrInvalidCredentials
+	}
+	if ok, _, err := s.hasher.Verify(user.PasswordHash, proof.CurrentPassword); err != nil || !ok {
+		return ErrInvalidCredentials
+	}
+	return nil
+}
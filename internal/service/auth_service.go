@@ -4,17 +4,21 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
 	"strings"
 	"time"
 
+	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
-	"google.golang.org/api/idtoken"
 	"gorm.io/gorm"
 
 	"wavefy-be/config"
+	"wavefy-be/internal/captcha"
 	"wavefy-be/internal/mail"
+	"wavefy-be/internal/mfa"
 	"wavefy-be/internal/model"
+	"wavefy-be/internal/oauth"
+	"wavefy-be/internal/password"
 	"wavefy-be/internal/repository"
 	"wavefy-be/internal/token"
 )
@@ -29,15 +33,74 @@ var (
 	ErrGoogleNotConfigured = errors.New("google auth not configured")
 )
 
+// VerifyResendLimitedError is returned by ResendVerifyEmail while the address
+// is cooling down or has used up its daily sends.
+type VerifyResendLimitedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *VerifyResendLimitedError) Error() string {
+	return "verification email sent recently"
+}
+
+// CaptchaRequiredError is returned by Login, Register and ForgotPassword when
+// the request needs a solved CAPTCHA. Invalid is set when a token was sent
+// but the provider rejected it.
+type CaptchaRequiredError struct {
+	Provider string
+	SiteKey  string
+	Invalid  bool
+}
+
+func (e *CaptchaRequiredError) Error() string {
+	if e.Invalid {
+		return "invalid captcha"
+	}
+	return "captcha required"
+}
+
 type AuthService interface {
-	Register(ctx context.Context, input CreateUserInput) (*model.User, *AuthToken, error)
-	Login(ctx context.Context, input LoginInput) (*model.User, *AuthToken, error)
-	LoginWithGoogle(ctx context.Context, credential string) (*model.User, *AuthToken, error)
-	Refresh(ctx context.Context, refreshToken string) (*model.User, *AuthToken, error)
+	Register(ctx context.Context, input CreateUserInput, client ClientInfo) (*model.User, *AuthToken, error)
+	Login(ctx context.Context, input LoginInput, client ClientInfo) (*model.User, *AuthToken, error)
+	LoginWithGoogle(ctx context.Context, credential string, client ClientInfo) (*model.User, *AuthToken, error)
+	Refresh(ctx context.Context, refreshToken string, client ClientInfo) (*model.User, *AuthToken, error)
 	Logout(ctx context.Context, refreshToken string) error
-	ForgotPassword(ctx context.Context, email string) error
-	ResetPassword(ctx context.Context, token, password string) error
-	VerifyEmail(ctx context.Context, token string) error
+	LogoutAll(ctx context.Context, userID uuid.UUID) error
+	ListSessions(ctx context.Context, userID uuid.UUID) ([]token.Session, error)
+	RevokeSession(ctx context.Context, userID uuid.UUID, sessionID string) error
+	SetupTOTP(ctx context.Context, userID uuid.UUID) (*TOTPSetup, error)
+	ConfirmTOTP(ctx context.Context, userID uuid.UUID, code string) ([]string, error)
+	DisableTOTP(ctx context.Context, userID uuid.UUID, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) ([]string, error)
+	VerifyMFA(ctx context.Context, challengeToken, code string, client ClientInfo) (*model.User, *AuthToken, error)
+	BeginPasskeyRegistration(ctx context.Context, userID uuid.UUID) (*PasskeyCeremony, error)
+	FinishPasskeyRegistration(ctx context.Context, userID uuid.UUID, sessionID, name string, response []byte) (*model.WebAuthnCredential, error)
+	ListPasskeys(ctx context.Context, userID uuid.UUID) ([]model.WebAuthnCredential, error)
+	DeletePasskey(ctx context.Context, userID, passkeyID uuid.UUID) error
+	BeginPasskeyLogin(ctx context.Context) (*PasskeyCeremony, error)
+	FinishPasskeyLogin(ctx context.Context, sessionID string, response []byte, client ClientInfo) (*model.User, *AuthToken, error)
+	LoginWithProvider(ctx context.Context, provider string, credential oauth.Credential, client ClientInfo) (*model.User, *AuthToken, error)
+	ListProviders() []string
+	ListIdentities(ctx context.Context, userID uuid.UUID) ([]model.Identity, error)
+	LinkIdentity(ctx context.Context, userID uuid.UUID, provider string, credential oauth.Credential) (*model.Identity, error)
+	UnlinkIdentity(ctx context.Context, userID, identityID uuid.UUID) error
+	SetPassword(ctx context.Context, userID uuid.UUID, proof Reauth, newPassword string, client ClientInfo) (*model.User, *AuthToken, error)
+	RequestReauthentication(ctx context.Context, userID uuid.UUID) error
+	RequestEmailChange(ctx context.Context, userID uuid.UUID, proof Reauth, newEmail string) error
+	ConfirmEmailChange(ctx context.Context, token string) (*model.User, error)
+	RevertEmailChange(ctx context.Context, token string) error
+	RequestAccountDeletion(ctx context.Context, userID uuid.UUID, proof Reauth) (*model.User, error)
+	CancelAccountDeletion(ctx context.Context, userID uuid.UUID) (*model.User, error)
+	RequestMagicLink(ctx context.Context, email string) error
+	ConsumeMagicLink(ctx context.Context, token string, client ClientInfo) (*model.User, *AuthToken, error)
+	ForgotPassword(ctx context.Context, email string, client ClientInfo) error
+	ResetPassword(ctx context.Context, token, password string, client ClientInfo) error
+	VerifyEmail(ctx context.Context, token string, client ClientInfo) error
+	ResendVerifyEmail(ctx context.Context, email string) error
+	ReportDevice(ctx context.Context, token string, client ClientInfo) error
+	ListAuthEvents(ctx context.Context, filter repository.AuthEventFilter, limit, offset int) ([]model.AuthEvent, error)
+	Impersonate(ctx context.Context, actor Actor, userID uuid.UUID, reason string, client ClientInfo) (*model.User, *AuthToken, error)
+	ListActivity(ctx context.Context, userID uuid.UUID, limit int) ([]model.AuthEvent, error)
 }
 
 type LoginInput struct {
@@ -45,6 +108,16 @@ type LoginInput struct {
 	Password string
 }
 
+// ClientInfo identifies the device a request comes from. It is recorded on
+// the refresh token session so users can recognise their devices.
+// CaptchaToken is the solved challenge sent with the request, if any.
+type ClientInfo struct {
+	IP           string
+	UserAgent    string
+	DeviceName   string
+	CaptchaToken string
+}
+
 type AuthToken struct {
 	AccessToken  string
 	RefreshToken string
@@ -53,58 +126,110 @@ type AuthToken struct {
 }
 
 type authService struct {
-	userService  UserService
-	userRepo     repository.UserRepository
-	roleRepo     repository.RoleRepository
-	refreshStore token.RefreshTokenStore
-	resetStore   token.PasswordResetTokenStore
-	verifyStore  token.VerifyEmailTokenStore
-	loginStore   token.LoginAttemptStore
-	mailer       *mail.Service
-	cfg          config.AuthConfig
-	googleCfg    config.GoogleOAuthConfig
-}
-
-func NewAuthService(userService UserService, userRepo repository.UserRepository, roleRepo repository.RoleRepository, refreshStore token.RefreshTokenStore, resetStore token.PasswordResetTokenStore, verifyStore token.VerifyEmailTokenStore, loginStore token.LoginAttemptStore, mailer *mail.Service, cfg config.AuthConfig, googleCfg config.GoogleOAuthConfig) AuthService {
-	return &authService{
-		userService:  userService,
-		userRepo:     userRepo,
-		roleRepo:     roleRepo,
-		refreshStore: refreshStore,
-		resetStore:   resetStore,
-		verifyStore:  verifyStore,
-		loginStore:   loginStore,
-		mailer:       mailer,
-		cfg:          cfg,
-		googleCfg:    googleCfg,
+	sessionRevoker
+	userService      UserService
+	userRepo         repository.UserRepository
+	roleRepo         repository.RoleRepository
+	resetStore       token.SignedTokenStore
+	verifyStore      token.SignedTokenStore
+	magicStore       token.SignedTokenStore
+	emailChangeStore token.SignedTokenStore
+	emailRevertStore token.SignedTokenStore
+	reportStore      token.SignedTokenStore
+	reauthStore      token.SignedTokenStore
+	loginStore       token.LoginAttemptStore
+	clientAttempts   token.WindowCounter
+	verifyLimiter    token.VerifyEmailResendLimiter
+	mfaStore         token.MFAChallengeStore
+	recoveryRepo     repository.RecoveryCodeRepository
+	mfaCipher        *mfa.Cipher
+	passkeyRepo      repository.WebAuthnCredentialRepository
+	webAuthnStore    token.WebAuthnSessionStore
+	webAuthn         *webauthn.WebAuthn
+	identityRepo     repository.IdentityRepository
+	providers        *oauth.Registry
+	captcha          captcha.Verifier
+	eventRepo        repository.AuthEventRepository
+	deviceRepo       repository.KnownDeviceRepository
+	mailer           *mail.Service
+	cfg              config.AuthConfig
+	keys             *token.KeyRing
+	policy           *password.Policy
+	hasher           password.Hasher
+}
+
+func NewAuthService(userService UserService, userRepo repository.UserRepository, roleRepo repository.RoleRepository, refreshStore token.RefreshTokenStore, resetStore, verifyStore, magicStore, emailChangeStore, emailRevertStore, reportStore, reauthStore token.SignedTokenStore, loginStore token.LoginAttemptStore, clientAttempts token.WindowCounter, verifyLimiter token.VerifyEmailResendLimiter, revocations token.AccessTokenRevocationStore, mfaStore token.MFAChallengeStore, recoveryRepo repository.RecoveryCodeRepository, passkeyRepo repository.WebAuthnCredentialRepository, webAuthnStore token.WebAuthnSessionStore, identityRepo repository.IdentityRepository, providers *oauth.Registry, captchaVerifier captcha.Verifier, eventRepo repository.AuthEventRepository, deviceRepo repository.KnownDeviceRepository, apiKeyRepo repository.APIKeyRepository, grantRepo repository.OAuthGrantRepository, mailer *mail.Service, cfg config.AuthConfig, keys *token.KeyRing, policy *password.Policy, hasher password.Hasher) (AuthService, error) {
+	// TOTP is off when AUTH_MFA_SECRET is unset; a secret that cannot be used
+	// fails startup rather than silently disabling it.
+	var mfaCipher *mfa.Cipher
+	if cfg.MFASecret != "" {
+		var err error
+		if mfaCipher, err = mfa.NewCipher(cfg.MFASecret); err != nil {
+			return nil, err
+		}
 	}
+
+	return &authService{
+		sessionRevoker: sessionRevoker{
+			refreshStore: refreshStore,
+			revocations:  revocations,
+			apiKeyRepo:   apiKeyRepo,
+			grantRepo:    grantRepo,
+		},
+		userService:      userService,
+		userRepo:         userRepo,
+		roleRepo:         roleRepo,
+		resetStore:       resetStore,
+		verifyStore:      verifyStore,
+		magicStore:       magicStore,
+		emailChangeStore: emailChangeStore,
+		emailRevertStore: emailRevertStore,
+		reportStore:      reportStore,
+		reauthStore:      reauthStore,
+		loginStore:       loginStore,
+		clientAttempts:   clientAttempts,
+		verifyLimiter:    verifyLimiter,
+		mfaStore:         mfaStore,
+		recoveryRepo:     recoveryRepo,
+		mfaCipher:        mfaCipher,
+		passkeyRepo:      passkeyRepo,
+		webAuthnStore:    webAuthnStore,
+		webAuthn:         newWebAuthn(cfg),
+		identityRepo:     identityRepo,
+		providers:        providers,
+		captcha:          captchaVerifier,
+		eventRepo:        eventRepo,
+		deviceRepo:       deviceRepo,
+		mailer:           mailer,
+		cfg:              cfg,
+		keys:             keys,
+		policy:           policy,
+		hasher:           hasher,
+	}, nil
 }
 
-func (s *authService) Register(ctx context.Context, input CreateUserInput) (*model.User, *AuthToken, error) {
-	user, err := s.userService.Create(ctx, input)
-	if err != nil {
-		return nil, nil, err
+func (s *authService) Register(ctx context.Context, input CreateUserInput, client ClientInfo) (*model.User, *AuthToken, error) {
+	if normalizeEmail(input.Email) == "" {
+		return nil, nil, ErrInvalidInput
 	}
-	role, err := s.roleRepo.GetByID(ctx, user.RoleID)
-	if err != nil {
+	if err := s.checkCaptcha(ctx, input.Email, client); err != nil {
 		return nil, nil, err
 	}
-	user.Role = *role
-	accessToken, expiresAt, err := token.IssueAccessToken(s.cfg, user.ID.String(), role.Name)
+	s.recordClientAttempt(ctx, client)
+	user, err := s.userService.Create(ctx, input)
 	if err != nil {
 		return nil, nil, err
 	}
-	authToken := &AuthToken{
-		AccessToken: accessToken,
-		ExpiresAt:   expiresAt,
-		TokenType:   "Bearer",
-	}
-	refreshToken, err := s.refreshStore.Create(ctx, user.ID.String())
+	authToken, err := s.issueTokens(ctx, user, client)
 	if err != nil {
 		return nil, nil, err
 	}
-	authToken.RefreshToken = refreshToken
 
+	// The registration mail counts towards the resend limits so it cannot be
+	// followed by an immediate resend.
+	if s.verifyLimiter != nil {
+		_, _, _ = s.verifyLimiter.Allow(ctx, user.Email)
+	}
 	if err := s.sendVerifyEmail(ctx, user); err != nil {
 		return nil, nil, err
 	}
@@ -112,7 +237,18 @@ func (s *authService) Register(ctx context.Context, input CreateUserInput) (*mod
 	return user, authToken, nil
 }
 
-func (s *authService) Login(ctx context.Context, input LoginInput) (*model.User, *AuthToken, error) {
+func (s *authService) Login(ctx context.Context, input LoginInput, client ClientInfo) (*model.User, *AuthToken, error) {
+	user, authToken, err := s.login(ctx, input, client)
+	s.recordLogin(ctx, loginMethodPassword, input.Email, user, client, err)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, authToken, nil
+}
+
+// login returns the matched user along with the error once the email is
+// known, so the audit event can name the account.
+func (s *authService) login(ctx context.Context, input LoginInput, client ClientInfo) (*model.User, *AuthToken, error) {
 	email := strings.TrimSpace(strings.ToLower(input.Email))
 	if email == "" || input.Password == "" {
 		return nil, nil, ErrInvalidCredentials
@@ -127,16 +263,21 @@ func (s *authService) Login(ctx context.Context, input LoginInput) (*model.User,
 			return nil, nil, ErrTooManyAttempts
 		}
 	}
+	if err := s.checkCaptcha(ctx, email, client); err != nil {
+		return nil, nil, err
+	}
 
 	user, err := s.userRepo.GetByEmail(ctx, email)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.recordClientAttempt(ctx, client)
 			if s.loginStore != nil {
 				_, locked, err := s.loginStore.RecordFailure(ctx, email)
 				if err != nil {
 					return nil, nil, err
 				}
 				if locked {
+					s.recordLockout(ctx, email, nil, client)
 					return nil, nil, ErrTooManyAttempts
 				}
 			}
@@ -145,140 +286,74 @@ func (s *authService) Login(ctx context.Context, input LoginInput) (*model.User,
 		return nil, nil, err
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(input.Password)); err != nil {
+	ok, needsRehash, err := s.hasher.Verify(user.PasswordHash, input.Password)
+	if err != nil {
+		log.Printf("auth: verify password of user %s: %v", user.ID, err)
+	}
+	if !ok {
+		s.recordClientAttempt(ctx, client)
 		if s.loginStore != nil {
 			_, locked, err := s.loginStore.RecordFailure(ctx, email)
 			if err != nil {
-				return nil, nil, err
+				return user, nil, err
 			}
 			if locked {
-				return nil, nil, ErrTooManyAttempts
+				s.recordLockout(ctx, email, user, client)
+				return user, nil, ErrTooManyAttempts
 			}
 		}
-		return nil, nil, ErrInvalidCredentials
+		return user, nil, ErrInvalidCredentials
 	}
 
 	if s.loginStore != nil {
 		_ = s.loginStore.Reset(ctx, email)
 	}
-
-	if !user.IsActive {
-		if err := s.sendVerifyEmail(ctx, user); err != nil {
-			return nil, nil, err
-		}
-		return nil, nil, ErrEmailNotVerified
+	if needsRehash {
+		s.rehashPassword(ctx, user, input.Password)
 	}
 
-	role, err := s.roleRepo.GetByID(ctx, user.RoleID)
-	if err != nil {
-		return nil, nil, err
-	}
-	user.Role = *role
-	accessToken, expiresAt, err := token.IssueAccessToken(s.cfg, user.ID.String(), role.Name)
-	if err != nil {
-		return nil, nil, err
+	if err := checkAccountStatus(user); err != nil {
+		return user, nil, err
 	}
-	authToken := &AuthToken{
-		AccessToken: accessToken,
-		ExpiresAt:   expiresAt,
-		TokenType:   "Bearer",
+	if !user.IsActive {
+		return user, nil, ErrEmailNotVerified
 	}
-	refreshToken, err := s.refreshStore.Create(ctx, user.ID.String())
+
+	authToken, err := s.completeLogin(ctx, user, client)
 	if err != nil {
-		return nil, nil, err
+		return user, nil, err
 	}
-	authToken.RefreshToken = refreshToken
 	return user, authToken, nil
 }
 
-func (s *authService) LoginWithGoogle(ctx context.Context, credential string) (*model.User, *AuthToken, error) {
+// LoginWithGoogle is kept for the original Google sign-in endpoint and
+// goes through the "google" provider.
+func (s *authService) LoginWithGoogle(ctx context.Context, credential string, client ClientInfo) (*model.User, *AuthToken, error) {
 	credential = strings.TrimSpace(credential)
 	if credential == "" {
 		return nil, nil, ErrInvalidInput
 	}
-	if strings.TrimSpace(s.googleCfg.ClientID) == "" {
+	user, authToken, err := s.LoginWithProvider(ctx, oauth.ProviderGoogle, oauth.Credential{IDToken: credential}, client)
+	switch err {
+	case ErrProviderNotConfigured:
 		return nil, nil, ErrGoogleNotConfigured
-	}
-
-	payload, err := idtoken.Validate(ctx, credential, s.googleCfg.ClientID)
-	if err != nil {
-		return nil, nil, ErrInvalidCredentials
-	}
-
-	email := normalizeEmail(getStringClaim(payload.Claims, "email"))
-	if email == "" {
-		return nil, nil, ErrInvalidCredentials
-	}
-	if !getBoolClaim(payload.Claims, "email_verified") {
+	case ErrProviderEmailRequired:
 		return nil, nil, ErrInvalidCredentials
 	}
+	return user, authToken, err
+}
 
-	user, err := s.userRepo.GetByEmail(ctx, email)
+func (s *authService) Refresh(ctx context.Context, refreshToken string, client ClientInfo) (*model.User, *AuthToken, error) {
+	userID, newRefresh, err := s.refreshStore.Rotate(ctx, refreshToken, client.sessionMeta())
 	if err != nil {
-		if !errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, nil, err
-		}
-
-		role, roleErr := s.roleRepo.GetByName(ctx, "USER")
-		if roleErr != nil {
-			if errors.Is(roleErr, gorm.ErrRecordNotFound) {
-				return nil, nil, ErrInvalidInput
+		if errors.Is(err, token.ErrRefreshTokenReused) {
+			log.Printf("auth: refresh token reuse detected for user %s, token family revoked", userID)
+			event := &model.AuthEvent{Type: model.AuthEventRefresh, Outcome: model.AuthOutcomeFailure, Reason: "refresh token reused"}
+			if reusedBy, parseErr := uuid.Parse(userID); parseErr == nil {
+				event.UserID = &reusedBy
 			}
-			return nil, nil, roleErr
+			s.recordEvent(ctx, event, nil, client)
 		}
-
-		passwordSeed := uuid.NewString()
-		hash, hashErr := bcrypt.GenerateFromPassword([]byte(passwordSeed), bcrypt.DefaultCost)
-		if hashErr != nil {
-			return nil, nil, hashErr
-		}
-
-		user = &model.User{
-			ID:           uuid.New(),
-			FirstName:    strings.TrimSpace(getStringClaim(payload.Claims, "given_name")),
-			LastName:     strings.TrimSpace(getStringClaim(payload.Claims, "family_name")),
-			Email:        email,
-			PasswordHash: string(hash),
-			IsActive:     true,
-			RoleID:       role.ID,
-			Role:         *role,
-		}
-		if createErr := s.userRepo.Create(ctx, user); createErr != nil {
-			return nil, nil, createErr
-		}
-	} else if !user.IsActive {
-		user.IsActive = true
-		if updateErr := s.userRepo.Update(ctx, user); updateErr != nil {
-			return nil, nil, updateErr
-		}
-	}
-
-	role, err := s.roleRepo.GetByID(ctx, user.RoleID)
-	if err != nil {
-		return nil, nil, err
-	}
-	user.Role = *role
-
-	accessToken, expiresAt, err := token.IssueAccessToken(s.cfg, user.ID.String(), role.Name)
-	if err != nil {
-		return nil, nil, err
-	}
-	authToken := &AuthToken{
-		AccessToken: accessToken,
-		ExpiresAt:   expiresAt,
-		TokenType:   "Bearer",
-	}
-	refreshToken, err := s.refreshStore.Create(ctx, user.ID.String())
-	if err != nil {
-		return nil, nil, err
-	}
-	authToken.RefreshToken = refreshToken
-	return user, authToken, nil
-}
-
-func (s *authService) Refresh(ctx context.Context, refreshToken string) (*model.User, *AuthToken, error) {
-	userID, err := s.refreshStore.Verify(ctx, refreshToken)
-	if err != nil {
 		return nil, nil, ErrInvalidCredentials
 	}
 
@@ -294,14 +369,15 @@ func (s *authService) Refresh(ctx context.Context, refreshToken string) (*model.
 		}
 		return nil, nil, err
 	}
-
-	role, err := s.roleRepo.GetByID(ctx, user.RoleID)
-	if err != nil {
+	// A suspended or banned account loses the session; it signs in again
+	// once reinstated.
+	if err := checkAccountStatus(user); err != nil {
+		_ = s.refreshStore.Revoke(ctx, newRefresh)
+		s.recordResult(ctx, model.AuthEventRefresh, user, client, err)
 		return nil, nil, err
 	}
-	user.Role = *role
 
-	accessToken, expiresAt, err := token.IssueAccessToken(s.cfg, user.ID.String(), role.Name)
+	accessToken, expiresAt, err := s.issueAccessToken(ctx, user)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -310,13 +386,8 @@ func (s *authService) Refresh(ctx context.Context, refreshToken string) (*model.
 		ExpiresAt:   expiresAt,
 		TokenType:   "Bearer",
 	}
-
-	newRefresh, err := s.refreshStore.Create(ctx, user.ID.String())
-	if err != nil {
-		return nil, nil, err
-	}
-	_ = s.refreshStore.Revoke(ctx, refreshToken)
 	authToken.RefreshToken = newRefresh
+	s.recordResult(ctx, model.AuthEventRefresh, user, client, nil)
 	return user, authToken, nil
 }
 
@@ -327,7 +398,28 @@ func (s *authService) Logout(ctx context.Context, refreshToken string) error {
 	return s.refreshStore.Revoke(ctx, refreshToken)
 }
 
-func (s *authService) ForgotPassword(ctx context.Context, email string) error {
+func (s *authService) LogoutAll(ctx context.Context, userID uuid.UUID) error {
+	return s.revokeAllTokens(ctx, userID)
+}
+
+func (s *authService) ListSessions(ctx context.Context, userID uuid.UUID) ([]token.Session, error) {
+	return s.refreshStore.ListSessions(ctx, userID.String())
+}
+
+func (s *authService) RevokeSession(ctx context.Context, userID uuid.UUID, sessionID string) error {
+	if strings.TrimSpace(sessionID) == "" {
+		return ErrInvalidInput
+	}
+	if err := s.refreshStore.RevokeSession(ctx, userID.String(), sessionID); err != nil {
+		if errors.Is(err, token.ErrSessionNotFound) {
+			return ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *authService) ForgotPassword(ctx context.Context, email string, client ClientInfo) error {
 	email = strings.TrimSpace(strings.ToLower(email))
 	if email == "" {
 		return ErrInvalidInput
@@ -335,16 +427,32 @@ func (s *authService) ForgotPassword(ctx context.Context, email string) error {
 	if s.resetStore == nil || s.mailer == nil {
 		return ErrMailNotConfigured
 	}
+	if err := s.checkCaptcha(ctx, email, client); err != nil {
+		return err
+	}
+	s.recordClientAttempt(ctx, client)
 
 	user, err := s.userRepo.GetByEmail(ctx, email)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.recordEvent(ctx, &model.AuthEvent{
+				Type:    model.AuthEventPasswordResetRequest,
+				Email:   email,
+				Outcome: model.AuthOutcomeFailure,
+				Reason:  "unknown email",
+			}, nil, client)
 			return nil
 		}
 		return err
 	}
 
-	resetToken, err := s.resetStore.Create(ctx, user.ID.String())
+	err = s.sendPasswordReset(ctx, user)
+	s.recordResult(ctx, model.AuthEventPasswordResetRequest, user, client, err)
+	return err
+}
+
+func (s *authService) sendPasswordReset(ctx context.Context, user *model.User) error {
+	resetToken, _, err := s.resetStore.Issue(ctx, user.ID.String(), nil)
 	if err != nil {
 		return err
 	}
@@ -363,78 +471,249 @@ func (s *authService) ForgotPassword(ctx context.Context, email string) error {
 	return nil
 }
 
-func (s *authService) ResetPassword(ctx context.Context, resetToken, password string) error {
-	if strings.TrimSpace(resetToken) == "" || password == "" {
-		return ErrInvalidInput
+func (s *authService) ResetPassword(ctx context.Context, resetToken, newPassword string, client ClientInfo) error {
+	user, err := s.resetPassword(ctx, resetToken, newPassword)
+	s.recordResult(ctx, model.AuthEventPasswordReset, user, client, err)
+	return err
+}
+
+func (s *authService) resetPassword(ctx context.Context, resetToken, newPassword string) (*model.User, error) {
+	if strings.TrimSpace(resetToken) == "" {
+		return nil, ErrInvalidInput
 	}
 	if s.resetStore == nil {
-		return ErrInvalidResetToken
+		return nil, ErrInvalidResetToken
 	}
 
-	userID, err := s.resetStore.Verify(ctx, resetToken)
+	record, err := s.resetStore.Verify(ctx, resetToken)
 	if err != nil {
-		return ErrInvalidResetToken
+		return nil, ErrInvalidResetToken
 	}
 
-	userUUID, err := uuid.Parse(userID)
+	userUUID, err := uuid.Parse(record.Subject)
 	if err != nil {
-		return ErrInvalidResetToken
+		return nil, ErrInvalidResetToken
 	}
 
 	user, err := s.userRepo.GetByID(ctx, userUUID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return ErrInvalidResetToken
+			return nil, ErrInvalidResetToken
 		}
-		return err
+		return nil, err
 	}
 
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	// The policy is checked before the token is used up so a rejected
+	// password can be retried with the same link.
+	if err := s.policy.Check("password", newPassword, user.Email); err != nil {
+		return user, err
+	}
+	if _, err := s.resetStore.Consume(ctx, resetToken); err != nil {
+		return user, ErrInvalidResetToken
+	}
+
+	hash, err := s.hasher.Hash(newPassword)
 	if err != nil {
-		return err
+		return user, err
 	}
-	user.PasswordHash = string(hash)
+	user.PasswordHash = hash
 	if err := s.userRepo.Update(ctx, user); err != nil {
-		return err
+		return user, err
 	}
 
-	_ = s.resetStore.Revoke(ctx, resetToken)
-	return nil
+	return user, s.revokeAllTokens(ctx, user.ID)
+}
+
+func (s *authService) VerifyEmail(ctx context.Context, verifyToken string, client ClientInfo) error {
+	user, err := s.verifyEmail(ctx, verifyToken)
+	s.recordResult(ctx, model.AuthEventEmailVerify, user, client, err)
+	return err
 }
 
-func (s *authService) VerifyEmail(ctx context.Context, verifyToken string) error {
+func (s *authService) verifyEmail(ctx context.Context, verifyToken string) (*model.User, error) {
 	if strings.TrimSpace(verifyToken) == "" {
-		return ErrInvalidVerifyToken
+		return nil, ErrInvalidVerifyToken
 	}
 	if s.verifyStore == nil {
-		return ErrInvalidVerifyToken
+		return nil, ErrInvalidVerifyToken
 	}
 
-	userID, err := s.verifyStore.Verify(ctx, verifyToken)
+	record, err := s.verifyStore.Consume(ctx, verifyToken)
 	if err != nil {
-		return ErrInvalidVerifyToken
+		return nil, ErrInvalidVerifyToken
 	}
 
-	userUUID, err := uuid.Parse(userID)
+	userUUID, err := uuid.Parse(record.Subject)
 	if err != nil {
-		return ErrInvalidVerifyToken
+		return nil, ErrInvalidVerifyToken
 	}
 
 	user, err := s.userRepo.GetByID(ctx, userUUID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return ErrInvalidVerifyToken
+			return nil, ErrInvalidVerifyToken
+		}
+		return nil, err
+	}
+
+	markEmailVerified(user)
+	return user, s.userRepo.Update(ctx, user)
+}
+
+// checkCaptcha asks for a solved challenge once the email has failed to log
+// in cfg.CaptchaAfter times, or the client's IP has that many failed logins,
+// registrations and reset requests within cfg.CaptchaWindow. Counting by IP
+// catches scripts that try many fresh addresses, which no single email
+// would show. Scripted guessing slows down well before the account is locked.
+func (s *authService) checkCaptcha(ctx context.Context, email string, client ClientInfo) error {
+	if s.captcha == nil {
+		return nil
+	}
+	if s.cfg.CaptchaAfter > 0 {
+		due, err := s.captchaDue(ctx, normalizeEmail(email), client.IP)
+		if err != nil {
+			return err
+		}
+		if !due {
+			return nil
+		}
+	}
+
+	challenge := &CaptchaRequiredError{Provider: s.captcha.Provider(), SiteKey: s.captcha.SiteKey()}
+	if strings.TrimSpace(client.CaptchaToken) == "" {
+		return challenge
+	}
+	if err := s.captcha.Verify(ctx, client.CaptchaToken, client.IP); err != nil {
+		if errors.Is(err, captcha.ErrInvalidToken) {
+			challenge.Invalid = true
+			return challenge
 		}
 		return err
 	}
+	return nil
+}
 
-	user.IsActive = true
+func (s *authService) captchaDue(ctx context.Context, email, ip string) (bool, error) {
+	threshold := int64(s.cfg.CaptchaAfter)
+	if email != "" && s.loginStore != nil {
+		failures, err := s.loginStore.Failures(ctx, email)
+		if err != nil {
+			return false, err
+		}
+		if failures >= threshold {
+			return true, nil
+		}
+	}
+	if ip != "" && s.clientAttempts != nil {
+		attempts, err := s.clientAttempts.Count(ctx, ip)
+		if err != nil {
+			return false, err
+		}
+		if attempts >= threshold {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// recordClientAttempt counts a failed login, a registration or a reset
+// request against the client's IP for checkCaptcha. It never fails the
+// request.
+func (s *authService) recordClientAttempt(ctx context.Context, client ClientInfo) {
+	if s.captcha == nil || s.clientAttempts == nil || client.IP == "" {
+		return
+	}
+	if _, _, err := s.clientAttempts.Increment(ctx, client.IP, s.cfg.CaptchaWindow); err != nil {
+		log.Printf("auth: count attempt from %s: %v", client.IP, err)
+	}
+}
+
+// rehashPassword upgrades a stored hash made with an older algorithm or
+// weaker parameters. It runs after a successful login, the only time the
+// plain password is known, and never fails the login.
+func (s *authService) rehashPassword(ctx context.Context, user *model.User, plain string) {
+	hash, err := s.hasher.Hash(plain)
+	if err != nil {
+		log.Printf("auth: rehash password of user %s: %v", user.ID, err)
+		return
+	}
+	user.PasswordHash = hash
 	if err := s.userRepo.Update(ctx, user); err != nil {
+		log.Printf("auth: store rehashed password of user %s: %v", user.ID, err)
+	}
+}
+
+// ResendVerifyEmail mails a new verification link to an unverified account.
+// Unknown and already verified addresses get the same answer so the endpoint
+// does not reveal which accounts exist.
+func (s *authService) ResendVerifyEmail(ctx context.Context, email string) error {
+	email = normalizeEmail(email)
+	if email == "" {
+		return ErrInvalidInput
+	}
+	if s.verifyStore == nil || s.mailer == nil {
+		return ErrMailNotConfigured
+	}
+
+	if s.verifyLimiter != nil {
+		allowed, retryAfter, err := s.verifyLimiter.Allow(ctx, email)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return &VerifyResendLimitedError{RetryAfter: retryAfter}
+		}
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
 		return err
 	}
+	if user.IsActive {
+		return nil
+	}
+	return s.sendVerifyEmail(ctx, user)
+}
 
-	_ = s.verifyStore.Revoke(ctx, verifyToken)
-	return nil
+func (s *authService) issueTokens(ctx context.Context, user *model.User, client ClientInfo) (*AuthToken, error) {
+	accessToken, expiresAt, err := s.issueAccessToken(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := s.refreshStore.Create(ctx, user.ID.String(), client.sessionMeta())
+	if err != nil {
+		return nil, err
+	}
+	s.checkNewDevice(ctx, user, client)
+	return &AuthToken{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresAt:    expiresAt,
+		TokenType:    "Bearer",
+	}, nil
+}
+
+// issueAccessToken reloads the user's role so the token carries its current
+// permissions. Suspended and banned accounts get no token, which also ends
+// their refreshes.
+func (s *authService) issueAccessToken(ctx context.Context, user *model.User) (string, time.Time, error) {
+	if err := checkAccountStatus(user); err != nil {
+		return "", time.Time{}, err
+	}
+	role, err := s.roleRepo.GetByID(ctx, user.RoleID)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	user.Role = *role
+
+	permissions := make([]string, 0, len(role.Permissions))
+	for _, permission := range role.Permissions {
+		permissions = append(permissions, permission.Name)
+	}
+	return token.IssueAccessToken(s.cfg, s.keys, user.ID.String(), role.Name, permissions)
 }
 
 func (s *authService) sendVerifyEmail(ctx context.Context, user *model.User) error {
@@ -442,7 +721,7 @@ func (s *authService) sendVerifyEmail(ctx context.Context, user *model.User) err
 		return ErrMailNotConfigured
 	}
 
-	verifyToken, err := s.verifyStore.Create(ctx, user.ID.String())
+	verifyToken, _, err := s.verifyStore.Issue(ctx, user.ID.String(), nil)
 	if err != nil {
 		return err
 	}
@@ -462,34 +741,12 @@ func (s *authService) sendVerifyEmail(ctx context.Context, user *model.User) err
 	return nil
 }
 
-func getStringClaim(claims map[string]interface{}, key string) string {
-	if claims == nil {
-		return ""
-	}
-	value, ok := claims[key]
-	if !ok {
-		return ""
-	}
-	str, ok := value.(string)
-	if !ok {
-		return ""
-	}
-	return str
-}
-
-func getBoolClaim(claims map[string]interface{}, key string) bool {
-	if claims == nil {
-		return false
-	}
-	value, ok := claims[key]
-	if !ok {
-		return false
-	}
-	boolean, ok := value.(bool)
-	if !ok {
-		return false
+func (c ClientInfo) sessionMeta() token.SessionMeta {
+	return token.SessionMeta{
+		DeviceName: c.DeviceName,
+		UserAgent:  c.UserAgent,
+		IP:         c.IP,
 	}
-	return boolean
 }
 
 func normalizeEmail(email string) string {
@@ -0,0 +1,501 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"wavefy-be/config"
+	"wavefy-be/internal/captcha"
+	"wavefy-be/internal/model"
+	"wavefy-be/internal/password"
+	"wavefy-be/internal/repository"
+	"wavefy-be/internal/token"
+)
+
+// fakeUserRepo keeps users in memory. Methods the tests do not reach are
+// left to the embedded interface and panic if called.
+type fakeUserRepo struct {
+	repository.UserRepository
+	mu    sync.Mutex
+	users map[uuid.UUID]model.User
+}
+
+func newFakeUserRepo() *fakeUserRepo {
+	return &fakeUserRepo{users: map[uuid.UUID]model.User{}}
+}
+
+func (r *fakeUserRepo) Create(ctx context.Context, user *model.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users[user.ID] = *user
+	return nil
+}
+
+func (r *fakeUserRepo) GetByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok || user.DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &user, nil
+}
+
+func (r *fakeUserRepo) GetByEmail(ctx context.Context, email string) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range r.users {
+		if user.Email == email {
+			return &user, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserRepo) Update(ctx context.Context, user *model.User) error {
+	return r.Create(ctx, user)
+}
+
+func (r *fakeUserRepo) ListBlocked(ctx context.Context) ([]model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var blocked []model.User
+	for _, user := range r.users {
+		if user.Status == model.UserStatusSuspended || user.Status == model.UserStatusBanned {
+			blocked = append(blocked, user)
+		}
+	}
+	return blocked, nil
+}
+
+// Delete soft-deletes the user like the GORM repository.
+func (r *fakeUserRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if ok {
+		user.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+		r.users[id] = user
+	}
+	return nil
+}
+
+// stored returns the user's row, soft-deleted or not.
+func (r *fakeUserRepo) stored(id uuid.UUID) model.User {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.users[id]
+}
+
+type fakeRoleRepo struct {
+	repository.RoleRepository
+	roles map[uuid.UUID]model.Role
+}
+
+func (r *fakeRoleRepo) GetByID(ctx context.Context, id uuid.UUID) (*model.Role, error) {
+	role, ok := r.roles[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &role, nil
+}
+
+func (r *fakeRoleRepo) GetByName(ctx context.Context, name string) (*model.Role, error) {
+	for _, role := range r.roles {
+		if role.Name == name {
+			return &role, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+type authFixture struct {
+	service     AuthService
+	userService UserService
+	stores      *token.Stores
+	reauth      token.SignedTokenStore
+	users       *fakeUserRepo
+	cfg         config.AuthConfig
+	keys        *token.KeyRing
+	user        *model.User
+}
+
+const fixturePassword = "Correct-horse-1"
+
+// newAuthFixture builds an authService on the in-memory token stores with one
+// verified user, so the auth flows run without Redis or a database.
+func newAuthFixture(t *testing.T) *authFixture {
+	t.Helper()
+	cfg := config.AuthConfig{
+		JWTSecret:       "test-secret",
+		AccessTokenTTL:  time.Hour,
+		AccessTokenIss:  "wavefy-test",
+		RefreshTokenTTL: 24 * time.Hour,
+		DeletionGrace:   30 * 24 * time.Hour,
+	}
+	keys, err := token.LoadKeyRing(cfg)
+	if err != nil {
+		t.Fatalf("load key ring: %v", err)
+	}
+	passwordCfg := config.PasswordConfig{MinLength: 8, HashAlgorithm: password.AlgorithmBcrypt, BcryptCost: 4}
+	policy, err := password.NewPolicy(passwordCfg)
+	if err != nil {
+		t.Fatalf("password policy: %v", err)
+	}
+	hasher := password.NewHasher(passwordCfg)
+
+	role := model.Role{ID: uuid.New(), Name: "USER"}
+	roles := &fakeRoleRepo{roles: map[uuid.UUID]model.Role{role.ID: role}}
+	users := newFakeUserRepo()
+	hash, err := hasher.Hash(fixturePassword)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+	user := &model.User{
+		ID:           uuid.New(),
+		Email:        "listener@example.com",
+		PasswordHash: hash,
+		IsActive:     true,
+		Status:       model.UserStatusActive,
+		RoleID:       role.ID,
+	}
+	if err := users.Create(context.Background(), user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	stores := token.NewMemoryStores(cfg)
+	reauthStore := token.NewSignedTokenStore(stores.Tokens, token.PurposeReauth, "reauth-secret", token.TokenPolicy{TTL: time.Minute, MaxUses: 1, MaxAttempts: 5})
+	userService := NewUserService(users, roles, stores.Refresh, stores.Revocations, stores.Statuses, nil, nil, policy, hasher, cfg.DeletionGrace)
+	service, err := NewAuthService(userService, users, roles, stores.Refresh, nil, nil, nil, nil, nil, nil, reauthStore, stores.LoginAttempts, stores.ClientAttempts, stores.VerifyResend, stores.Revocations, stores.MFAChallenges, nil, nil, stores.WebAuthnSessions, nil, nil, nil, nil, nil, nil, nil, nil, cfg, keys, policy, hasher)
+	if err != nil {
+		t.Fatalf("new auth service: %v", err)
+	}
+	return &authFixture{service: service, userService: userService, stores: stores, reauth: reauthStore, users: users, cfg: cfg, keys: keys, user: user}
+}
+
+func (f *authFixture) login(t *testing.T) *AuthToken {
+	t.Helper()
+	_, authToken, err := f.service.Login(context.Background(), LoginInput{Email: f.user.Email, Password: fixturePassword}, ClientInfo{IP: "203.0.113.7"})
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	return authToken
+}
+
+func TestAuthServiceRefreshReplayEndsSession(t *testing.T) {
+	f := newAuthFixture(t)
+	ctx := context.Background()
+	first := f.login(t)
+
+	_, second, err := f.service.Refresh(ctx, first.RefreshToken, ClientInfo{})
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Fatal("refresh token was not rotated")
+	}
+
+	if _, _, err := f.service.Refresh(ctx, first.RefreshToken, ClientInfo{}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("replayed refresh: got %v, want ErrInvalidCredentials", err)
+	}
+	if _, _, err := f.service.Refresh(ctx, second.RefreshToken, ClientInfo{}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("refresh after replay: got %v, want ErrInvalidCredentials", err)
+	}
+	sessions, err := f.service.ListSessions(ctx, f.user.ID)
+	if err != nil {
+		t.Fatalf("list sessions: %v", err)
+	}
+	if len(sessions) != 0 {
+		t.Fatalf("sessions after replay = %d, want 0", len(sessions))
+	}
+}
+
+func TestAuthServiceLocksAfterRepeatedFailures(t *testing.T) {
+	f := newAuthFixture(t)
+	ctx := context.Background()
+	wrong := LoginInput{Email: f.user.Email, Password: "wrong-password"}
+
+	var err error
+	for i := 0; i < 10; i++ {
+		_, _, err = f.service.Login(ctx, wrong, ClientInfo{})
+	}
+	if !errors.Is(err, ErrTooManyAttempts) {
+		t.Fatalf("10th failure: got %v, want ErrTooManyAttempts", err)
+	}
+
+	right := LoginInput{Email: f.user.Email, Password: fixturePassword}
+	if _, _, err := f.service.Login(ctx, right, ClientInfo{}); !errors.Is(err, ErrTooManyAttempts) {
+		t.Fatalf("login while locked: got %v, want ErrTooManyAttempts", err)
+	}
+}
+
+func TestAuthServiceLogoutAllRevokesTokens(t *testing.T) {
+	f := newAuthFixture(t)
+	ctx := context.Background()
+	authToken := f.login(t)
+
+	claims, err := token.ParseAccessToken(f.cfg, f.keys, authToken.AccessToken)
+	if err != nil {
+		t.Fatalf("parse access token: %v", err)
+	}
+
+	if err := f.service.LogoutAll(ctx, f.user.ID); err != nil {
+		t.Fatalf("logout all: %v", err)
+	}
+	revoked, err := f.stores.Revocations.IsRevoked(ctx, claims)
+	if err != nil {
+		t.Fatalf("is revoked: %v", err)
+	}
+	if !revoked {
+		t.Fatal("access token issued in the same second is still accepted after logout everywhere")
+	}
+	// A sign-in right after the sign-out everywhere is not caught by it.
+	fresh, err := token.ParseAccessToken(f.cfg, f.keys, f.login(t).AccessToken)
+	if err != nil {
+		t.Fatalf("parse access token: %v", err)
+	}
+	if revoked, err := f.stores.Revocations.IsRevoked(ctx, fresh); err != nil || revoked {
+		t.Fatalf("token issued after logout everywhere: revoked=%v err=%v", revoked, err)
+	}
+	if _, _, err := f.service.Refresh(ctx, authToken.RefreshToken, ClientInfo{}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("refresh after logout everywhere: got %v, want ErrInvalidCredentials", err)
+	}
+}
+
+func TestAuthServiceReauthenticate(t *testing.T) {
+	f := newAuthFixture(t)
+	ctx := context.Background()
+	auth := f.service.(*authService)
+
+	passwordless := *f.user
+	passwordless.PasswordHash = ""
+	issue := func(subject, email string) string {
+		t.Helper()
+		reauthToken, _, err := f.reauth.Issue(ctx, subject, map[string]string{"email": email})
+		if err != nil {
+			t.Fatalf("issue reauth token: %v", err)
+		}
+		return reauthToken
+	}
+	valid := issue(f.user.ID.String(), f.user.Email)
+
+	tests := []struct {
+		name  string
+		user  *model.User
+		proof Reauth
+		want  error
+	}{
+		{"password", f.user, Reauth{CurrentPassword: fixturePassword}, nil},
+		{"wrong password", f.user, Reauth{CurrentPassword: "wrong-password"}, ErrInvalidCredentials},
+		{"no password on account", &passwordless, Reauth{CurrentPassword: ""}, ErrInvalidCredentials},
+		{"emailed token without password", &passwordless, Reauth{Token: valid}, nil},
+		{"token reused", &passwordless, Reauth{Token: valid}, ErrInvalidReauthToken},
+		{"token for another user", &passwordless, Reauth{Token: issue(uuid.NewString(), f.user.Email)}, ErrInvalidReauthToken},
+		{"token for an old address", &passwordless, Reauth{Token: issue(f.user.ID.String(), "old@example.com")}, ErrInvalidReauthToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := auth.reauthenticate(ctx, tt.user, tt.proof); !errors.Is(err, tt.want) {
+				t.Fatalf("reauthenticate: got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthServiceSetPasswordNeedsProof(t *testing.T) {
+	f := newAuthFixture(t)
+	ctx := context.Background()
+	other := f.login(t)
+
+	// A provider, passkey or magic-link account has no password to confirm.
+	passwordless := *f.user
+	passwordless.PasswordHash = ""
+	if err := f.users.Update(ctx, &passwordless); err != nil {
+		t.Fatalf("clear password: %v", err)
+	}
+
+	const newPassword = "Battery-staple-2"
+	if _, _, err := f.service.SetPassword(ctx, f.user.ID, Reauth{}, newPassword, ClientInfo{}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("set password without proof: got %v, want ErrInvalidCredentials", err)
+	}
+
+	reauthToken, _, err := f.reauth.Issue(ctx, f.user.ID.String(), map[string]string{"email": f.user.Email})
+	if err != nil {
+		t.Fatalf("issue reauth token: %v", err)
+	}
+	_, issued, err := f.service.SetPassword(ctx, f.user.ID, Reauth{Token: reauthToken}, newPassword, ClientInfo{})
+	if err != nil {
+		t.Fatalf("set password: %v", err)
+	}
+	if _, _, err := f.service.Refresh(ctx, other.RefreshToken, ClientInfo{}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("refresh of another session: got %v, want ErrInvalidCredentials", err)
+	}
+	if _, _, err := f.service.Refresh(ctx, issued.RefreshToken, ClientInfo{}); err != nil {
+		t.Fatalf("refresh of the new session: %v", err)
+	}
+}
+
+// ownedRows counts the rows a user owns in one of the repositories the
+// device report clears.
+type ownedRows map[uuid.UUID]int64
+
+func (r ownedRows) deleteByUser(userID uuid.UUID) (int64, error) {
+	n := r[userID]
+	delete(r, userID)
+	return n, nil
+}
+
+type fakePasskeyRepo struct {
+	repository.WebAuthnCredentialRepository
+	ownedRows
+}
+
+func (r fakePasskeyRepo) DeleteByUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return r.deleteByUser(userID)
+}
+
+type fakeIdentityRepo struct {
+	repository.IdentityRepository
+	ownedRows
+}
+
+func (r fakeIdentityRepo) DeleteByUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return r.deleteByUser(userID)
+}
+
+type fakeAPIKeyRepo struct {
+	repository.APIKeyRepository
+	ownedRows
+}
+
+func (r fakeAPIKeyRepo) DeleteByUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return r.deleteByUser(userID)
+}
+
+type fakeGrantRepo struct {
+	repository.OAuthGrantRepository
+	ownedRows
+}
+
+func (r fakeGrantRepo) DeleteByUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return r.deleteByUser(userID)
+}
+
+func TestAuthServiceReportDeviceRemovesSignInMethods(t *testing.T) {
+	f := newAuthFixture(t)
+	ctx := context.Background()
+	auth := f.service.(*authService)
+	auth.reportStore = token.NewSignedTokenStore(f.stores.Tokens, token.PurposeDeviceReport, "report-secret", token.TokenPolicy{TTL: time.Minute, MaxUses: 1, MaxAttempts: 5})
+	passkeys := ownedRows{f.user.ID: 2}
+	identities := ownedRows{f.user.ID: 1}
+	apiKeys := ownedRows{f.user.ID: 3}
+	grants := ownedRows{f.user.ID: 1}
+	auth.passkeyRepo = fakePasskeyRepo{ownedRows: passkeys}
+	auth.identityRepo = fakeIdentityRepo{ownedRows: identities}
+	auth.apiKeyRepo = fakeAPIKeyRepo{ownedRows: apiKeys}
+	auth.grantRepo = fakeGrantRepo{ownedRows: grants}
+
+	authToken := f.login(t)
+	reportToken, _, err := auth.reportStore.Issue(ctx, f.user.ID.String(), map[string]string{"fingerprint": "unknown"})
+	if err != nil {
+		t.Fatalf("issue report token: %v", err)
+	}
+	if err := f.service.ReportDevice(ctx, reportToken, ClientInfo{}); err != nil {
+		t.Fatalf("report device: %v", err)
+	}
+
+	for name, rows := range map[string]ownedRows{"passkeys": passkeys, "identities": identities, "api keys": apiKeys, "grants": grants} {
+		if len(rows) != 0 {
+			t.Errorf("%s of the reported account were kept", name)
+		}
+	}
+	user, _ := f.users.GetByID(ctx, f.user.ID)
+	if user.PasswordHash != "" {
+		t.Error("password was kept after the device report")
+	}
+	if _, _, err := f.service.Refresh(ctx, authToken.RefreshToken, ClientInfo{}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("refresh after device report: got %v, want ErrInvalidCredentials", err)
+	}
+	if err := f.service.ReportDevice(ctx, reportToken, ClientInfo{}); !errors.Is(err, ErrInvalidDeviceReport) {
+		t.Fatalf("second report with the same link: got %v, want ErrInvalidDeviceReport", err)
+	}
+}
+
+// fakeCaptcha accepts the token "solved" and rejects anything else.
+type fakeCaptcha struct{}
+
+func (fakeCaptcha) Provider() string { return "fake" }
+func (fakeCaptcha) SiteKey() string  { return "site-key" }
+func (fakeCaptcha) Verify(ctx context.Context, captchaToken, remoteIP string) error {
+	if captchaToken != "solved" {
+		return captcha.ErrInvalidToken
+	}
+	return nil
+}
+
+func TestAuthServiceCaptchaByClientIP(t *testing.T) {
+	f := newAuthFixture(t)
+	ctx := context.Background()
+	auth := f.service.(*authService)
+	auth.captcha = fakeCaptcha{}
+	auth.cfg.CaptchaAfter = 3
+	auth.cfg.CaptchaWindow = time.Minute
+
+	if _, _, err := f.service.Register(ctx, CreateUserInput{Email: " ", Password: fixturePassword}, ClientInfo{IP: "198.51.100.1"}); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("register without email: got %v, want ErrInvalidInput", err)
+	}
+
+	stuffer := ClientInfo{IP: "198.51.100.2"}
+	for i := 0; i < 3; i++ {
+		input := LoginInput{Email: fmt.Sprintf("victim%d@example.com", i), Password: "guess"}
+		if _, _, err := f.service.Login(ctx, input, stuffer); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("failed login %d: got %v, want ErrInvalidCredentials", i, err)
+		}
+	}
+	var challenge *CaptchaRequiredError
+	fresh := LoginInput{Email: "victim9@example.com", Password: "guess"}
+	if _, _, err := f.service.Login(ctx, fresh, stuffer); !errors.As(err, &challenge) {
+		t.Fatalf("login from a client with 3 failures: got %v, want a CAPTCHA challenge", err)
+	}
+	stuffer.CaptchaToken = "solved"
+	if _, _, err := f.service.Login(ctx, fresh, stuffer); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("login with a solved CAPTCHA: got %v, want ErrInvalidCredentials", err)
+	}
+
+	right := LoginInput{Email: f.user.Email, Password: fixturePassword}
+	if _, _, err := f.service.Login(ctx, right, ClientInfo{IP: "198.51.100.3"}); err != nil {
+		t.Fatalf("login from another client: %v", err)
+	}
+}
+
+func TestAuthServiceDeletePasswordlessAccount(t *testing.T) {
+	f := newAuthFixture(t)
+	ctx := context.Background()
+	f.service.(*authService).cfg.DeletionGrace = 24 * time.Hour
+	f.user.PasswordHash = ""
+	if err := f.users.Update(ctx, f.user); err != nil {
+		t.Fatalf("clear password: %v", err)
+	}
+
+	if _, err := f.service.RequestAccountDeletion(ctx, f.user.ID, Reauth{}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("deletion without proof: got %v, want ErrInvalidCredentials", err)
+	}
+	reauthToken, _, err := f.reauth.Issue(ctx, f.user.ID.String(), map[string]string{"email": f.user.Email})
+	if err != nil {
+		t.Fatalf("issue reauth token: %v", err)
+	}
+	user, err := f.service.RequestAccountDeletion(ctx, f.user.ID, Reauth{Token: reauthToken})
+	if err != nil {
+		t.Fatalf("deletion with emailed token: %v", err)
+	}
+	if user.PurgeAt == nil || time.Until(*user.PurgeAt) <= 0 {
+		t.Fatalf("purge date = %v, want one grace period ahead", user.PurgeAt)
+	}
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var ErrForbidden = errors.New("forbidden")
+
+// Actor is the authenticated caller a service call is made on behalf of.
+// Permissions are the ones embedded in the caller's access token.
+type Actor struct {
+	UserID      uuid.UUID
+	Role        string
+	Permissions []string
+}
+
+func (a Actor) Has(permission string) bool {
+	for _, p := range a.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// CanManage reports whether the actor may modify a resource owned by
+// ownerID: its owner, or anyone holding permission.
+func (a Actor) CanManage(ownerID uuid.UUID, permission string) bool {
+	if a.Has(permission) {
+		return true
+	}
+	return a.UserID != uuid.Nil && a.UserID == ownerID
+}
@@ -0,0 +1,492 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"wavefy-be/config"
+	"wavefy-be/internal/mail"
+	"wavefy-be/internal/model"
+	"wavefy-be/internal/repository"
+	"wavefy-be/internal/token"
+)
+
+// Exports are stored under exports/<user id>/, a prefix the track upload
+// routes cannot reach, so only the presigned link in the email can read them.
+// DeleteExpiredExports removes them once that link has expired, and the
+// account purge removes the prefix.
+const (
+	dataExportPrefix    = "exports/"
+	dataExportTimeout   = 10 * time.Minute
+	dataExportEventPage = 500
+)
+
+// DataExportLimitedError is returned by RequestExport while the user's
+// previous export is still cooling down.
+type DataExportLimitedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *DataExportLimitedError) Error() string {
+	return "data export requested recently"
+}
+
+// DataExportService builds a copy of everything held about a user: a ZIP of
+// JSON files uploaded to R2 and mailed as a presigned download link.
+type DataExportService interface {
+	RequestExport(ctx context.Context, userID uuid.UUID) error
+}
+
+type dataExportService struct {
+	userRepo      repository.UserRepository
+	roleRepo      repository.RoleRepository
+	trackRepo     repository.TrackRepository
+	eventRepo     repository.AuthEventRepository
+	identityRepo  repository.IdentityRepository
+	passkeyRepo   repository.WebAuthnCredentialRepository
+	deviceRepo    repository.KnownDeviceRepository
+	apiKeyRepo    repository.APIKeyRepository
+	grantRepo     repository.OAuthGrantRepository
+	refreshStore  token.RefreshTokenStore
+	limiter       token.DataExportLimiter
+	uploadService UploadService
+	mailer        *mail.Service
+	cfg           config.AuthConfig
+}
+
+func NewDataExportService(userRepo repository.UserRepository, roleRepo repository.RoleRepository, trackRepo repository.TrackRepository, eventRepo repository.AuthEventRepository, identityRepo repository.IdentityRepository, passkeyRepo repository.WebAuthnCredentialRepository, deviceRepo repository.KnownDeviceRepository, apiKeyRepo repository.APIKeyRepository, grantRepo repository.OAuthGrantRepository, refreshStore token.RefreshTokenStore, limiter token.DataExportLimiter, uploadService UploadService, mailer *mail.Service, cfg config.AuthConfig) DataExportService {
+	return &dataExportService{
+		userRepo:      userRepo,
+		roleRepo:      roleRepo,
+		trackRepo:     trackRepo,
+		eventRepo:     eventRepo,
+		identityRepo:  identityRepo,
+		passkeyRepo:   passkeyRepo,
+		deviceRepo:    deviceRepo,
+		apiKeyRepo:    apiKeyRepo,
+		grantRepo:     grantRepo,
+		refreshStore:  refreshStore,
+		limiter:       limiter,
+		uploadService: uploadService,
+		mailer:        mailer,
+		cfg:           cfg,
+	}
+}
+
+// RequestExport starts building the user's export in the background and
+// returns once it is queued. The link is mailed to the account's address.
+func (s *dataExportService) RequestExport(ctx context.Context, userID uuid.UUID) error {
+	if s.mailer == nil {
+		return ErrMailNotConfigured
+	}
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound
+		}
+		return err
+	}
+
+	allowed, retryAfter, err := s.limiter.Allow(ctx, userID.String())
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return &DataExportLimitedError{RetryAfter: retryAfter}
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), dataExportTimeout)
+		defer cancel()
+		if err := s.export(ctx, userID); err != nil {
+			log.Printf("data export: user %s: %v", userID, err)
+			if err := s.limiter.Release(ctx, userID.String()); err != nil {
+				log.Printf("data export: release %s: %v", userID, err)
+			}
+		}
+	}()
+	return nil
+}
+
+func (s *dataExportService) export(ctx context.Context, userID uuid.UUID) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	archive, err := s.buildArchive(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	// Only the latest export is kept.
+	prefix := dataExportPrefix + user.ID.String() + "/"
+	if _, err := s.uploadService.DeletePrefix(ctx, prefix); err != nil {
+		return err
+	}
+	key := prefix + time.Now().UTC().Format("20060102T150405Z") + ".zip"
+	if err := s.uploadService.PutObject(ctx, PutObjectInput{Key: key, ContentType: "application/zip", Body: archive}); err != nil {
+		return err
+	}
+
+	expiresInSec := int(s.cfg.ExportLinkTTL.Seconds())
+	link, err := s.uploadService.PresignGet(ctx, PresignGetInput{Key: key, ExpiresInSec: &expiresInSec})
+	if err != nil {
+		return err
+	}
+	htmlBody, err := mail.RenderDataExportHTML(link.URL, s.cfg.ExportLinkTTL)
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(user.Email, "Dữ liệu Wavefy của bạn đã sẵn sàng", "", htmlBody)
+}
+
+// DeleteExpiredExports deletes the exports whose download link has expired
+// and returns how many there were. It also catches an export that finished
+// after its account was purged.
+func DeleteExpiredExports(ctx context.Context, uploadService UploadService, linkTTL time.Duration) (int, error) {
+	return uploadService.DeletePrefixBefore(ctx, dataExportPrefix, time.Now().Add(-linkTTL))
+}
+
+type exportFile struct {
+	name string
+	data interface{}
+}
+
+func (s *dataExportService) buildArchive(ctx context.Context, user *model.User) ([]byte, error) {
+	role, err := s.roleRepo.GetByID(ctx, user.RoleID)
+	if err != nil {
+		return nil, err
+	}
+	tracks, err := s.trackRepo.ListByArtist(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.listEvents(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	identities, err := s.identityRepo.ListByUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	passkeys, err := s.passkeyRepo.ListByUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	devices, err := s.deviceRepo.ListByUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	apiKeys, err := s.apiKeyRepo.ListByUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	grants, err := s.grantRepo.ListByUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := s.refreshStore.ListSessions(ctx, user.ID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	files := []exportFile{
+		{"profile.json", exportProfileOf(user, role)},
+		{"tracks.json", exportTracksOf(tracks)},
+		{"auth_events.json", exportEventsOf(events)},
+		{"sessions.json", exportSessionsOf(sessions)},
+		{"devices.json", exportDevicesOf(devices)},
+		{"identities.json", exportIdentitiesOf(identities)},
+		{"passkeys.json", exportPasskeysOf(passkeys)},
+		{"api_keys.json", exportAPIKeysOf(apiKeys)},
+		{"oauth_grants.json", exportGrantsOf(grants)},
+	}
+	manifest := exportManifest{
+		GeneratedAt: time.Now().UTC(),
+		UserID:      user.ID.String(),
+		Notes: []string{
+			"Listening history is not recorded per user; only aggregate play counts per track are kept, and those appear in tracks.json.",
+			"Passwords, MFA secrets, recovery codes, passkey public keys and API key hashes are never exported.",
+		},
+	}
+	for _, file := range files {
+		manifest.Files = append(manifest.Files, file.name)
+	}
+	files = append([]exportFile{{"manifest.json", manifest}}, files...)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, file := range files {
+		w, err := zw.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *dataExportService) listEvents(ctx context.Context, userID uuid.UUID) ([]model.AuthEvent, error) {
+	var all []model.AuthEvent
+	filter := repository.AuthEventFilter{UserID: &userID}
+	for offset := 0; ; offset += dataExportEventPage {
+		events, err := s.eventRepo.List(ctx, filter, dataExportEventPage, offset)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, events...)
+		if len(events) < dataExportEventPage {
+			return all, nil
+		}
+	}
+}
+
+type exportManifest struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	UserID      string    `json:"user_id"`
+	Files       []string  `json:"files"`
+	Notes       []string  `json:"notes"`
+}
+
+type exportProfile struct {
+	ID          string     `json:"id"`
+	Email       string     `json:"email"`
+	FirstName   string     `json:"first_name"`
+	LastName    string     `json:"last_name"`
+	IsActive    bool       `json:"is_active"`
+	Status      string     `json:"status"`
+	MFAEnabled  bool       `json:"mfa_enabled"`
+	HasPassword bool       `json:"has_password"`
+	Role        string     `json:"role"`
+	Permissions []string   `json:"permissions"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	PurgeAt     *time.Time `json:"purge_at,omitempty"`
+}
+
+func exportProfileOf(user *model.User, role *model.Role) exportProfile {
+	return exportProfile{
+		ID:          user.ID.String(),
+		Email:       user.Email,
+		FirstName:   user.FirstName,
+		LastName:    user.LastName,
+		IsActive:    user.IsActive,
+		Status:      user.Status,
+		MFAEnabled:  user.TOTPEnabled,
+		HasPassword: user.PasswordHash != "",
+		Role:        role.Name,
+		Permissions: permissionNames(role),
+		CreatedAt:   user.CreatedAt,
+		UpdatedAt:   user.UpdatedAt,
+		PurgeAt:     user.PurgeAt,
+	}
+}
+
+type exportTrack struct {
+	ID          string     `json:"id"`
+	Title       string     `json:"title"`
+	AudioURL    string     `json:"audio_url"`
+	ImageURL    *string    `json:"image_url"`
+	AlbumID     *string    `json:"album_id"`
+	DurationSec int        `json:"duration_sec"`
+	IsPublic    bool       `json:"is_public"`
+	PlayCount   int64      `json:"play_count"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+}
+
+func exportTracksOf(tracks []model.Track) []exportTrack {
+	out := make([]exportTrack, 0, len(tracks))
+	for _, track := range tracks {
+		item := exportTrack{
+			ID:          track.ID.String(),
+			Title:       track.Title,
+			AudioURL:    track.AudioURL,
+			ImageURL:    track.ImageURL,
+			DurationSec: track.DurationSec,
+			IsPublic:    track.IsPublic,
+			PlayCount:   track.PlayCount,
+			CreatedAt:   track.CreatedAt,
+			UpdatedAt:   track.UpdatedAt,
+		}
+		if track.AlbumID != nil {
+			albumID := track.AlbumID.String()
+			item.AlbumID = &albumID
+		}
+		if track.DeletedAt.Valid {
+			deletedAt := track.DeletedAt.Time
+			item.DeletedAt = &deletedAt
+		}
+		out = append(out, item)
+	}
+	return out
+}
+
+type exportEvent struct {
+	Type      string    `json:"type"`
+	Method    string    `json:"method,omitempty"`
+	Outcome   string    `json:"outcome"`
+	Reason    string    `json:"reason,omitempty"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func exportEventsOf(events []model.AuthEvent) []exportEvent {
+	out := make([]exportEvent, 0, len(events))
+	for _, event := range events {
+		out = append(out, exportEvent{
+			Type:      event.Type,
+			Method:    event.Method,
+			Outcome:   event.Outcome,
+			Reason:    event.Reason,
+			IP:        event.IP,
+			UserAgent: event.UserAgent,
+			CreatedAt: event.CreatedAt,
+		})
+	}
+	return out
+}
+
+type exportSession struct {
+	DeviceName string    `json:"device_name"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+}
+
+func exportSessionsOf(sessions []token.Session) []exportSession {
+	out := make([]exportSession, 0, len(sessions))
+	for _, session := range sessions {
+		out = append(out, exportSession{
+			DeviceName: session.DeviceName,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+		})
+	}
+	return out
+}
+
+type exportDevice struct {
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func exportDevicesOf(devices []model.KnownDevice) []exportDevice {
+	out := make([]exportDevice, 0, len(devices))
+	for _, device := range devices {
+		out = append(out, exportDevice{
+			UserAgent:  device.UserAgent,
+			IP:         device.IP,
+			LastSeenAt: device.LastSeenAt,
+			CreatedAt:  device.CreatedAt,
+		})
+	}
+	return out
+}
+
+type exportIdentity struct {
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func exportIdentitiesOf(identities []model.Identity) []exportIdentity {
+	out := make([]exportIdentity, 0, len(identities))
+	for _, identity := range identities {
+		out = append(out, exportIdentity{
+			Provider:  identity.Provider,
+			Subject:   identity.Subject,
+			Email:     identity.Email,
+			CreatedAt: identity.CreatedAt,
+		})
+	}
+	return out
+}
+
+type exportPasskey struct {
+	Name       string     `json:"name"`
+	Transports string     `json:"transports"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func exportPasskeysOf(passkeys []model.WebAuthnCredential) []exportPasskey {
+	out := make([]exportPasskey, 0, len(passkeys))
+	for _, passkey := range passkeys {
+		out = append(out, exportPasskey{
+			Name:       passkey.Name,
+			Transports: passkey.Transports,
+			LastUsedAt: passkey.LastUsedAt,
+			CreatedAt:  passkey.CreatedAt,
+		})
+	}
+	return out
+}
+
+type exportAPIKey struct {
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func exportAPIKeysOf(keys []model.APIKey) []exportAPIKey {
+	out := make([]exportAPIKey, 0, len(keys))
+	for _, key := range keys {
+		out = append(out, exportAPIKey{
+			Name:       key.Name,
+			Prefix:     key.Prefix,
+			Scopes:     key.Scopes,
+			ExpiresAt:  key.ExpiresAt,
+			LastUsedAt: key.LastUsedAt,
+			CreatedAt:  key.CreatedAt,
+		})
+	}
+	return out
+}
+
+type exportGrant struct {
+	Client    string    `json:"client"`
+	ClientID  string    `json:"client_id"`
+	Scopes    []string  `json:"scopes"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func exportGrantsOf(grants []model.OAuthGrant) []exportGrant {
+	out := make([]exportGrant, 0, len(grants))
+	for _, grant := range grants {
+		out = append(out, exportGrant{
+			Client:    grant.Client.Name,
+			ClientID:  grant.Client.ClientID,
+			Scopes:    grant.Scopes,
+			CreatedAt: grant.CreatedAt,
+			UpdatedAt: grant.UpdatedAt,
+		})
+	}
+	return out
+}
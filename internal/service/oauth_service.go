@@ -0,0 +1,683 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"wavefy-be/config"
+	"wavefy-be/internal/model"
+	"wavefy-be/internal/repository"
+	"wavefy-be/internal/token"
+)
+
+// OAuth error codes from RFC 6749 section 5.2 and 4.1.2.1.
+const (
+	OAuthErrInvalidRequest          = "invalid_request"
+	OAuthErrInvalidClient           = "invalid_client"
+	OAuthErrInvalidGrant            = "invalid_grant"
+	OAuthErrInvalidScope            = "invalid_scope"
+	OAuthErrUnsupportedGrantType    = "unsupported_grant_type"
+	OAuthErrUnsupportedResponseType = "unsupported_response_type"
+	OAuthErrAccessDenied            = "access_denied"
+)
+
+const (
+	oauthGrantAuthorizationCode = "authorization_code"
+	oauthGrantRefreshToken      = "refresh_token"
+	pkceMethodS256              = "S256"
+)
+
+// OAuthError is returned by the authorization server with the error code the
+// partner app receives.
+type OAuthError struct {
+	Code        string
+	Description string
+}
+
+func (e *OAuthError) Error() string {
+	if e.Description == "" {
+		return e.Code
+	}
+	return e.Code + ": " + e.Description
+}
+
+func oauthError(code, description string) *OAuthError {
+	return &OAuthError{Code: code, Description: description}
+}
+
+type CreateOAuthClientInput struct {
+	Name         string
+	RedirectURIs []string
+	Scopes       []string
+	Confidential bool
+}
+
+// AuthorizationRequest holds the query parameters of an authorization code
+// request. PKCE with S256 is required for every client.
+type AuthorizationRequest struct {
+	ResponseType        string
+	ClientID            string
+	RedirectURI         string
+	Scope               string
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+}
+
+// AuthorizationPrompt is what the consent screen shows. Consented is set when
+// the user already granted every requested scope to the client.
+type AuthorizationPrompt struct {
+	Client      *model.OAuthClient
+	Scopes      []string
+	RedirectURI string
+	Consented   bool
+}
+
+// OAuthClientCredentials identifies the partner app calling the token,
+// revocation and introspection endpoints. Public clients send no secret.
+type OAuthClientCredentials struct {
+	ClientID     string
+	ClientSecret string
+}
+
+type OAuthTokenRequest struct {
+	Client       OAuthClientCredentials
+	GrantType    string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	RefreshToken string
+	Scope        string
+}
+
+type OAuthToken struct {
+	AccessToken  string
+	RefreshToken string
+	Scopes       []string
+	ExpiresAt    time.Time
+}
+
+// OAuthIntrospection describes a token for RFC 7662. Only Active is set for
+// tokens that are invalid or belong to another client.
+type OAuthIntrospection struct {
+	Active    bool
+	TokenType string
+	ClientID  string
+	Subject   string
+	Scopes    []string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+type OAuthService interface {
+	CreateClient(ctx context.Context, ownerID uuid.UUID, input CreateOAuthClientInput) (*model.OAuthClient, string, error)
+	ListClients(ctx context.Context) ([]model.OAuthClient, error)
+	DeleteClient(ctx context.Context, id uuid.UUID) error
+	Authorize(ctx context.Context, userID uuid.UUID, req AuthorizationRequest) (*AuthorizationPrompt, error)
+	Decide(ctx context.Context, userID uuid.UUID, req AuthorizationRequest, approved bool) (string, error)
+	Token(ctx context.Context, req OAuthTokenRequest) (*OAuthToken, error)
+	Revoke(ctx context.Context, creds OAuthClientCredentials, value string) error
+	Introspect(ctx context.Context, creds OAuthClientCredentials, value string) (*OAuthIntrospection, error)
+	ListGrants(ctx context.Context, userID uuid.UUID) ([]model.OAuthGrant, error)
+	RevokeGrant(ctx context.Context, userID uuid.UUID, clientID string) error
+}
+
+type oauthService struct {
+	clientRepo   repository.OAuthClientRepository
+	grantRepo    repository.OAuthGrantRepository
+	userRepo     repository.UserRepository
+	roleRepo     repository.RoleRepository
+	codeStore    token.SignedTokenStore
+	refreshStore token.SignedTokenStore
+	revocations  token.AccessTokenRevocationStore
+	cfg          config.AuthConfig
+	keys         *token.KeyRing
+}
+
+func NewOAuthService(clientRepo repository.OAuthClientRepository, grantRepo repository.OAuthGrantRepository, userRepo repository.UserRepository, roleRepo repository.RoleRepository, codeStore, refreshStore token.SignedTokenStore, revocations token.AccessTokenRevocationStore, cfg config.AuthConfig, keys *token.KeyRing) OAuthService {
+	return &oauthService{
+		clientRepo:   clientRepo,
+		grantRepo:    grantRepo,
+		userRepo:     userRepo,
+		roleRepo:     roleRepo,
+		codeStore:    codeStore,
+		refreshStore: refreshStore,
+		revocations:  revocations,
+		cfg:          cfg,
+		keys:         keys,
+	}
+}
+
+// CreateClient registers a partner app. Confidential clients get a secret,
+// returned only here.
+func (s *oauthService) CreateClient(ctx context.Context, ownerID uuid.UUID, input CreateOAuthClientInput) (*model.OAuthClient, string, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" || len(name) > 100 || len(input.RedirectURIs) == 0 {
+		return nil, "", ErrInvalidInput
+	}
+	redirectURIs := make([]string, 0, len(input.RedirectURIs))
+	for _, raw := range input.RedirectURIs {
+		raw = strings.TrimSpace(raw)
+		if !validRedirectURI(raw) {
+			return nil, "", ErrInvalidInput
+		}
+		redirectURIs = append(redirectURIs, raw)
+	}
+	scopes, ok := normalizeScopes(input.Scopes, model.OAuthScopes)
+	if !ok || len(scopes) == 0 {
+		return nil, "", ErrInvalidInput
+	}
+
+	clientID, err := token.GenerateOAuthClientID()
+	if err != nil {
+		return nil, "", err
+	}
+	client := &model.OAuthClient{
+		ID:           uuid.New(),
+		ClientID:     clientID,
+		Name:         name,
+		RedirectURIs: redirectURIs,
+		Scopes:       scopes,
+		OwnerID:      ownerID,
+		Confidential: input.Confidential,
+	}
+	var secret string
+	if input.Confidential {
+		secret, client.SecretHash, err = token.GenerateOAuthClientSecret()
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	if err := s.clientRepo.Create(ctx, client); err != nil {
+		return nil, "", err
+	}
+	return client, secret, nil
+}
+
+func (s *oauthService) ListClients(ctx context.Context) ([]model.OAuthClient, error) {
+	return s.clientRepo.List(ctx)
+}
+
+// DeleteClient removes a partner app with its grants, so its refresh tokens
+// stop working, and revokes the access tokens it was issued.
+func (s *oauthService) DeleteClient(ctx context.Context, id uuid.UUID) error {
+	client, err := s.clientRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound
+		}
+		return err
+	}
+	deleted, err := s.clientRepo.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrNotFound
+	}
+	return s.revocations.RevokeClientTokens(ctx, client.ClientID, "", time.Now())
+}
+
+// Authorize validates an authorization request for the consent screen.
+// Errors are returned to the signed-in user rather than redirected, since
+// the redirect URI may be the thing that is wrong.
+func (s *oauthService) Authorize(ctx context.Context, userID uuid.UUID, req AuthorizationRequest) (*AuthorizationPrompt, error) {
+	client, redirectURI, scopes, err := s.validateAuthorization(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	prompt := &AuthorizationPrompt{Client: client, Scopes: scopes, RedirectURI: redirectURI}
+	grant, err := s.grantRepo.Get(ctx, userID, client.ID)
+	if err == nil {
+		_, prompt.Consented = normalizeScopes(scopes, grant.Scopes)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return prompt, nil
+}
+
+// Decide records the user's answer on the consent screen and returns where
+// to send the browser: back to the partner app with a single-use code, or
+// with access_denied.
+func (s *oauthService) Decide(ctx context.Context, userID uuid.UUID, req AuthorizationRequest, approved bool) (string, error) {
+	client, redirectURI, scopes, err := s.validateAuthorization(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	if !approved {
+		return buildRedirect(redirectURI, map[string]string{"error": OAuthErrAccessDenied, "state": req.State})
+	}
+
+	grant := &model.OAuthGrant{ID: uuid.New(), UserID: userID, ClientID: client.ID, Scopes: scopes}
+	existing, err := s.grantRepo.Get(ctx, userID, client.ID)
+	if err == nil {
+		grant.Scopes = mergeScopes(existing.Scopes, scopes)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+	if err := s.grantRepo.Save(ctx, grant); err != nil {
+		return "", err
+	}
+
+	// redirect_uri records what the request sent, which is empty when the
+	// client's only registered URI was used; the token request has to repeat
+	// it only if it was sent (RFC 6749 section 4.1.3). grant names the grant
+	// the code was issued under, so a replayed code can revoke it.
+	code, _, err := s.codeStore.Issue(ctx, userID.String(), map[string]string{
+		"client_id":      client.ClientID,
+		"grant":          client.ID.String(),
+		"redirect_uri":   req.RedirectURI,
+		"scope":          strings.Join(scopes, " "),
+		"code_challenge": req.CodeChallenge,
+	})
+	if err != nil {
+		return "", err
+	}
+	return buildRedirect(redirectURI, map[string]string{"code": code, "state": req.State})
+}
+
+// Token serves the token endpoint for the authorization_code and
+// refresh_token grants. Codes and refresh tokens are single use; a refresh
+// returns a new refresh token. A code presented a second time revokes the
+// grant it was issued under, and with it the tokens the first exchange got
+// (RFC 6749 section 4.1.2).
+func (s *oauthService) Token(ctx context.Context, req OAuthTokenRequest) (*OAuthToken, error) {
+	client, err := s.authenticateClient(ctx, req.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	switch req.GrantType {
+	case oauthGrantAuthorizationCode:
+		if req.Code == "" || req.CodeVerifier == "" {
+			return nil, oauthError(OAuthErrInvalidRequest, "code and code_verifier are required")
+		}
+		code, err := s.codeStore.Consume(ctx, req.Code)
+		if err != nil {
+			if errors.Is(err, token.ErrInvalidToken) {
+				return nil, oauthError(OAuthErrInvalidGrant, "invalid authorization code")
+			}
+			return nil, err
+		}
+		if code.Uses > 1 {
+			if err := s.revokeCodeGrant(ctx, code); err != nil {
+				return nil, err
+			}
+			return nil, oauthError(OAuthErrInvalidGrant, "authorization code was already used")
+		}
+		if code.Metadata["client_id"] != client.ClientID || !sameRedirectURI(code.Metadata["redirect_uri"], req.RedirectURI) {
+			return nil, oauthError(OAuthErrInvalidGrant, "authorization code was issued for another client or redirect_uri")
+		}
+		if !token.VerifyPKCE(req.CodeVerifier, code.Metadata["code_challenge"]) {
+			return nil, oauthError(OAuthErrInvalidGrant, "code_verifier does not match")
+		}
+		return s.issueTokens(ctx, client, code.Subject, strings.Fields(code.Metadata["scope"]))
+
+	case oauthGrantRefreshToken:
+		if req.RefreshToken == "" {
+			return nil, oauthError(OAuthErrInvalidRequest, "refresh_token is required")
+		}
+		refresh, err := s.refreshStore.Consume(ctx, req.RefreshToken)
+		if err != nil {
+			if errors.Is(err, token.ErrInvalidToken) {
+				return nil, oauthError(OAuthErrInvalidGrant, "invalid refresh token")
+			}
+			return nil, err
+		}
+		if refresh.Metadata["client_id"] != client.ClientID {
+			return nil, oauthError(OAuthErrInvalidGrant, "refresh token was issued for another client")
+		}
+		scopes := strings.Fields(refresh.Metadata["scope"])
+		if req.Scope != "" {
+			narrowed, ok := normalizeScopes(strings.Fields(req.Scope), scopes)
+			if !ok {
+				return nil, oauthError(OAuthErrInvalidScope, "scope exceeds the original grant")
+			}
+			scopes = narrowed
+		}
+		return s.issueTokens(ctx, client, refresh.Subject, scopes)
+
+	default:
+		return nil, oauthError(OAuthErrUnsupportedGrantType, "")
+	}
+}
+
+// Revoke implements RFC 7009. Unknown tokens and tokens of other clients are
+// ignored, as the RFC asks.
+func (s *oauthService) Revoke(ctx context.Context, creds OAuthClientCredentials, value string) error {
+	client, err := s.authenticateClient(ctx, creds)
+	if err != nil {
+		return err
+	}
+	if value == "" {
+		return oauthError(OAuthErrInvalidRequest, "token is required")
+	}
+
+	if claims, err := token.ParseAccessToken(s.cfg, s.keys, value); err == nil {
+		if claims.ClientID != client.ClientID || s.revocations == nil {
+			return nil
+		}
+		return s.revocations.RevokeToken(ctx, claims.ID, claims.ExpiresAt.Time)
+	}
+
+	refresh, err := s.refreshStore.Verify(ctx, value)
+	if err != nil {
+		if errors.Is(err, token.ErrInvalidToken) {
+			return nil
+		}
+		return err
+	}
+	if refresh.Metadata["client_id"] != client.ClientID {
+		return nil
+	}
+	return s.refreshStore.Revoke(ctx, value)
+}
+
+// Introspect implements RFC 7662 for the client a token was issued to.
+func (s *oauthService) Introspect(ctx context.Context, creds OAuthClientCredentials, value string) (*OAuthIntrospection, error) {
+	client, err := s.authenticateClient(ctx, creds)
+	if err != nil {
+		return nil, err
+	}
+	if value == "" {
+		return nil, oauthError(OAuthErrInvalidRequest, "token is required")
+	}
+
+	if claims, err := token.ParseAccessToken(s.cfg, s.keys, value); err == nil {
+		if claims.ClientID != client.ClientID {
+			return &OAuthIntrospection{}, nil
+		}
+		if s.revocations != nil {
+			revoked, err := s.revocations.IsRevoked(ctx, claims)
+			if err != nil {
+				return nil, err
+			}
+			if revoked {
+				return &OAuthIntrospection{}, nil
+			}
+		}
+		return &OAuthIntrospection{
+			Active:    true,
+			TokenType: "access_token",
+			ClientID:  claims.ClientID,
+			Subject:   claims.Subject,
+			Scopes:    strings.Fields(claims.Scope),
+			IssuedAt:  claims.IssuedAt.Time,
+			ExpiresAt: claims.ExpiresAt.Time,
+		}, nil
+	}
+
+	refresh, err := s.refreshStore.Verify(ctx, value)
+	if err != nil {
+		if errors.Is(err, token.ErrInvalidToken) {
+			return &OAuthIntrospection{}, nil
+		}
+		return nil, err
+	}
+	if refresh.Metadata["client_id"] != client.ClientID {
+		return &OAuthIntrospection{}, nil
+	}
+	return &OAuthIntrospection{
+		Active:    true,
+		TokenType: "refresh_token",
+		ClientID:  client.ClientID,
+		Subject:   refresh.Subject,
+		Scopes:    strings.Fields(refresh.Metadata["scope"]),
+		ExpiresAt: refresh.ExpiresAt,
+	}, nil
+}
+
+func (s *oauthService) ListGrants(ctx context.Context, userID uuid.UUID) ([]model.OAuthGrant, error) {
+	return s.grantRepo.ListByUser(ctx, userID)
+}
+
+// RevokeGrant withdraws the user's consent for a partner app. Its refresh
+// and access tokens stop working at once.
+func (s *oauthService) RevokeGrant(ctx context.Context, userID uuid.UUID, clientID string) error {
+	client, err := s.clientRepo.GetByClientID(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound
+		}
+		return err
+	}
+	deleted, err := s.grantRepo.Delete(ctx, userID, client.ID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrNotFound
+	}
+	return s.revocations.RevokeClientTokens(ctx, client.ClientID, userID.String(), time.Now())
+}
+
+// revokeCodeGrant revokes the grant a replayed authorization code was issued
+// under. Deleting the grant stops the refresh tokens; the access tokens are
+// revoked by client and user.
+func (s *oauthService) revokeCodeGrant(ctx context.Context, code *token.SignedToken) error {
+	userID, err := uuid.Parse(code.Subject)
+	if err != nil {
+		return oauthError(OAuthErrInvalidGrant, "")
+	}
+	grantClientID, err := uuid.Parse(code.Metadata["grant"])
+	if err != nil {
+		return oauthError(OAuthErrInvalidGrant, "")
+	}
+	if _, err := s.grantRepo.Delete(ctx, userID, grantClientID); err != nil {
+		return err
+	}
+	return s.revocations.RevokeClientTokens(ctx, code.Metadata["client_id"], code.Subject, time.Now())
+}
+
+func (s *oauthService) validateAuthorization(ctx context.Context, req AuthorizationRequest) (*model.OAuthClient, string, []string, error) {
+	if req.ClientID == "" {
+		return nil, "", nil, oauthError(OAuthErrInvalidRequest, "client_id is required")
+	}
+	client, err := s.clientRepo.GetByClientID(ctx, req.ClientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", nil, oauthError(OAuthErrInvalidClient, "unknown client")
+		}
+		return nil, "", nil, err
+	}
+
+	redirectURI := req.RedirectURI
+	if redirectURI == "" && len(client.RedirectURIs) == 1 {
+		redirectURI = client.RedirectURIs[0]
+	}
+	if !containsString(client.RedirectURIs, redirectURI) {
+		return nil, "", nil, oauthError(OAuthErrInvalidRequest, "redirect_uri is not registered for this client")
+	}
+
+	if req.ResponseType != "code" {
+		return nil, "", nil, oauthError(OAuthErrUnsupportedResponseType, "only response_type=code is supported")
+	}
+	if req.CodeChallenge == "" || req.CodeChallengeMethod != pkceMethodS256 {
+		return nil, "", nil, oauthError(OAuthErrInvalidRequest, "code_challenge with code_challenge_method=S256 is required")
+	}
+
+	scopes, ok := normalizeScopes(strings.Fields(req.Scope), client.Scopes)
+	if !ok || len(scopes) == 0 {
+		return nil, "", nil, oauthError(OAuthErrInvalidScope, "scope must be a subset of the client's scopes")
+	}
+	return client, redirectURI, scopes, nil
+}
+
+// authenticateClient checks the client_id and, for confidential clients, the
+// secret.
+func (s *oauthService) authenticateClient(ctx context.Context, creds OAuthClientCredentials) (*model.OAuthClient, error) {
+	if creds.ClientID == "" {
+		return nil, oauthError(OAuthErrInvalidClient, "client authentication failed")
+	}
+	client, err := s.clientRepo.GetByClientID(ctx, creds.ClientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, oauthError(OAuthErrInvalidClient, "client authentication failed")
+		}
+		return nil, err
+	}
+	if client.Confidential {
+		hash := token.HashOAuthClientSecret(creds.ClientSecret)
+		if creds.ClientSecret == "" || subtle.ConstantTimeCompare([]byte(hash), []byte(client.SecretHash)) != 1 {
+			return nil, oauthError(OAuthErrInvalidClient, "client authentication failed")
+		}
+	}
+	return client, nil
+}
+
+// issueTokens issues an access and refresh token pair for subject. Scopes are
+// cut down to what the user's grant still holds, and the token carries the
+// role's permissions only where a scope of the same name was granted.
+func (s *oauthService) issueTokens(ctx context.Context, client *model.OAuthClient, subject string, scopes []string) (*OAuthToken, error) {
+	userID, err := uuid.Parse(subject)
+	if err != nil {
+		return nil, oauthError(OAuthErrInvalidGrant, "")
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, oauthError(OAuthErrInvalidGrant, "user no longer exists")
+		}
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, oauthError(OAuthErrInvalidGrant, "user is not active")
+	}
+	if err := checkAccountStatus(user); err != nil {
+		return nil, oauthError(OAuthErrInvalidGrant, err.Error())
+	}
+
+	grant, err := s.grantRepo.Get(ctx, user.ID, client.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, oauthError(OAuthErrInvalidGrant, "consent was revoked")
+		}
+		return nil, err
+	}
+	scopes = intersectScopes(scopes, grant.Scopes)
+	if len(scopes) == 0 {
+		return nil, oauthError(OAuthErrInvalidGrant, "consent was revoked")
+	}
+
+	role, err := s.roleRepo.GetByID(ctx, user.RoleID)
+	if err != nil {
+		return nil, err
+	}
+	permissions := intersectScopes(scopes, permissionNames(role))
+
+	accessToken, expiresAt, err := token.IssueDelegatedAccessToken(s.cfg, s.keys, user.ID.String(), role.Name, permissions, client.ClientID, scopes)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, _, err := s.refreshStore.Issue(ctx, user.ID.String(), map[string]string{
+		"client_id": client.ClientID,
+		"scope":     strings.Join(scopes, " "),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &OAuthToken{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		Scopes:       scopes,
+		ExpiresAt:    expiresAt,
+	}, nil
+}
+
+// sameRedirectURI compares the redirect_uri of a token request with the one
+// its authorization request sent, if it sent one.
+func sameRedirectURI(authorized, requested string) bool {
+	return authorized == "" || authorized == requested
+}
+
+// validRedirectURI accepts absolute URIs without a fragment. Plain http is
+// only allowed for loopback hosts, for local development and native apps.
+func validRedirectURI(raw string) bool {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" || parsed.Fragment != "" {
+		return false
+	}
+	if parsed.Scheme == "http" {
+		host := parsed.Hostname()
+		return host == "localhost" || host == "127.0.0.1" || host == "::1"
+	}
+	return true
+}
+
+func buildRedirect(redirectURI string, params map[string]string) (string, error) {
+	parsed, err := url.Parse(redirectURI)
+	if err != nil {
+		return "", err
+	}
+	query := parsed.Query()
+	for key, value := range params {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	parsed.RawQuery = query.Encode()
+	return parsed.String(), nil
+}
+
+// normalizeScopes trims and deduplicates scopes, reporting whether all of
+// them are in allowed.
+func normalizeScopes(scopes, allowed []string) ([]string, bool) {
+	normalized := make([]string, 0, len(scopes))
+	seen := map[string]bool{}
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if scope == "" || seen[scope] {
+			continue
+		}
+		if !containsString(allowed, scope) {
+			return nil, false
+		}
+		seen[scope] = true
+		normalized = append(normalized, scope)
+	}
+	return normalized, true
+}
+
+func intersectScopes(scopes, allowed []string) []string {
+	kept := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if containsString(allowed, scope) {
+			kept = append(kept, scope)
+		}
+	}
+	return kept
+}
+
+func mergeScopes(existing, added []string) []string {
+	merged := append([]string{}, existing...)
+	for _, scope := range added {
+		if !containsString(merged, scope) {
+			merged = append(merged, scope)
+		}
+	}
+	return merged
+}
+
+func permissionNames(role *model.Role) []string {
+	names := make([]string, 0, len(role.Permissions))
+	for _, permission := range role.Permissions {
+		names = append(names, permission.Name)
+	}
+	return names
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
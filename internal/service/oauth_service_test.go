@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"wavefy-be/internal/model"
+	"wavefy-be/internal/repository"
+	"wavefy-be/internal/token"
+)
+
+type fakeOAuthClientRepo struct {
+	repository.OAuthClientRepository
+	clients map[string]model.OAuthClient
+}
+
+func (r *fakeOAuthClientRepo) GetByID(ctx context.Context, id uuid.UUID) (*model.OAuthClient, error) {
+	for _, client := range r.clients {
+		if client.ID == id {
+			return &client, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeOAuthClientRepo) Delete(ctx context.Context, id uuid.UUID) (bool, error) {
+	for clientID, client := range r.clients {
+		if client.ID == id {
+			delete(r.clients, clientID)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeOAuthClientRepo) GetByClientID(ctx context.Context, clientID string) (*model.OAuthClient, error) {
+	client, ok := r.clients[clientID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &client, nil
+}
+
+// memoryGrantRepo keeps OAuth grants in memory for the token endpoint tests.
+type memoryGrantRepo struct {
+	repository.OAuthGrantRepository
+	mu     sync.Mutex
+	grants map[[2]uuid.UUID]model.OAuthGrant
+}
+
+func (r *memoryGrantRepo) Get(ctx context.Context, userID, clientID uuid.UUID) (*model.OAuthGrant, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	grant, ok := r.grants[[2]uuid.UUID{userID, clientID}]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &grant, nil
+}
+
+func (r *memoryGrantRepo) Save(ctx context.Context, grant *model.OAuthGrant) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.grants[[2]uuid.UUID{grant.UserID, grant.ClientID}] = *grant
+	return nil
+}
+
+func (r *memoryGrantRepo) Delete(ctx context.Context, userID, clientID uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := [2]uuid.UUID{userID, clientID}
+	_, ok := r.grants[key]
+	delete(r.grants, key)
+	return ok, nil
+}
+
+const (
+	testRedirectURI  = "https://app.example.com/callback"
+	testCodeVerifier = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+)
+
+type oauthFixture struct {
+	*authFixture
+	service OAuthService
+	clients *fakeOAuthClientRepo
+}
+
+// newOAuthFixture serves the authorization server with two public partner
+// apps, "app" and "other", each with one registered redirect URI.
+func newOAuthFixture(t *testing.T) *oauthFixture {
+	t.Helper()
+	f := newAuthFixture(t)
+	f.cfg.OAuthAccessTTL = time.Hour
+	roles := &fakeRoleRepo{roles: map[uuid.UUID]model.Role{f.user.RoleID: {ID: f.user.RoleID, Name: "USER"}}}
+	clients := &fakeOAuthClientRepo{clients: map[string]model.OAuthClient{}}
+	for _, clientID := range []string{"app", "other"} {
+		clients.clients[clientID] = model.OAuthClient{
+			ID:           uuid.New(),
+			ClientID:     clientID,
+			RedirectURIs: []string{testRedirectURI},
+			Scopes:       []string{model.OAuthScopeTracksRead},
+		}
+	}
+	grants := &memoryGrantRepo{grants: map[[2]uuid.UUID]model.OAuthGrant{}}
+	codeStore := token.NewSignedTokenStore(f.stores.Tokens, token.PurposeOAuthCode, "oauth-secret", token.TokenPolicy{TTL: time.Minute, MaxAttempts: 5})
+	refreshStore := token.NewSignedTokenStore(f.stores.Tokens, token.PurposeOAuthRefresh, "oauth-secret", token.TokenPolicy{TTL: time.Hour, MaxUses: 1, MaxAttempts: 5})
+	service := NewOAuthService(clients, grants, f.users, roles, codeStore, refreshStore, f.stores.Revocations, f.cfg, f.keys)
+	return &oauthFixture{authFixture: f, service: service, clients: clients}
+}
+
+// authorize approves a request of clientID and returns the code from the
+// redirect.
+func (f *oauthFixture) authorize(t *testing.T, clientID, redirectURI string) string {
+	t.Helper()
+	sum := sha256.Sum256([]byte(testCodeVerifier))
+	location, err := f.service.Decide(context.Background(), f.user.ID, AuthorizationRequest{
+		ResponseType:        "code",
+		ClientID:            clientID,
+		RedirectURI:         redirectURI,
+		Scope:               model.OAuthScopeTracksRead,
+		CodeChallenge:       base64.RawURLEncoding.EncodeToString(sum[:]),
+		CodeChallengeMethod: pkceMethodS256,
+	}, true)
+	if err != nil {
+		t.Fatalf("decide: %v", err)
+	}
+	parsed, err := url.Parse(location)
+	if err != nil {
+		t.Fatalf("parse redirect: %v", err)
+	}
+	code := parsed.Query().Get("code")
+	if code == "" {
+		t.Fatalf("redirect %q carries no code", location)
+	}
+	return code
+}
+
+func (f *oauthFixture) exchange(clientID, code, redirectURI, verifier string) (*OAuthToken, error) {
+	return f.service.Token(context.Background(), OAuthTokenRequest{
+		Client:       OAuthClientCredentials{ClientID: clientID},
+		GrantType:    oauthGrantAuthorizationCode,
+		Code:         code,
+		RedirectURI:  redirectURI,
+		CodeVerifier: verifier,
+	})
+}
+
+func (f *oauthFixture) refresh(clientID, refreshToken string) (*OAuthToken, error) {
+	return f.service.Token(context.Background(), OAuthTokenRequest{
+		Client:       OAuthClientCredentials{ClientID: clientID},
+		GrantType:    oauthGrantRefreshToken,
+		RefreshToken: refreshToken,
+	})
+}
+
+func TestOAuthTokenAuthorizationCode(t *testing.T) {
+	tests := []struct {
+		name string
+		// authorizeURI is the redirect_uri of the authorization request.
+		authorizeURI string
+		clientID     string
+		redirectURI  string
+		verifier     string
+		want         string
+	}{
+		{name: "redirect_uri repeated", authorizeURI: testRedirectURI, clientID: "app", redirectURI: testRedirectURI, verifier: testCodeVerifier},
+		{name: "redirect_uri omitted in both requests", clientID: "app", verifier: testCodeVerifier},
+		{name: "redirect_uri sent only to the token endpoint", clientID: "app", redirectURI: testRedirectURI, verifier: testCodeVerifier},
+		{name: "redirect_uri dropped at the token endpoint", authorizeURI: testRedirectURI, clientID: "app", verifier: testCodeVerifier, want: OAuthErrInvalidGrant},
+		{name: "redirect_uri mismatch", authorizeURI: testRedirectURI, clientID: "app", redirectURI: "https://app.example.com/other", verifier: testCodeVerifier, want: OAuthErrInvalidGrant},
+		{name: "wrong code_verifier", authorizeURI: testRedirectURI, clientID: "app", redirectURI: testRedirectURI, verifier: strings.Repeat("a", 43), want: OAuthErrInvalidGrant},
+		{name: "code of another client", authorizeURI: testRedirectURI, clientID: "other", redirectURI: testRedirectURI, verifier: testCodeVerifier, want: OAuthErrInvalidGrant},
+		{name: "missing code_verifier", authorizeURI: testRedirectURI, clientID: "app", redirectURI: testRedirectURI, want: OAuthErrInvalidRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newOAuthFixture(t)
+			code := f.authorize(t, "app", tt.authorizeURI)
+			issued, err := f.exchange(tt.clientID, code, tt.redirectURI, tt.verifier)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("exchange: %v", err)
+				}
+				if issued.AccessToken == "" || issued.RefreshToken == "" {
+					t.Fatal("exchange returned no tokens")
+				}
+				return
+			}
+			assertOAuthError(t, err, tt.want)
+		})
+	}
+}
+
+func TestOAuthTokenCodeReuse(t *testing.T) {
+	f := newOAuthFixture(t)
+	code := f.authorize(t, "app", testRedirectURI)
+	issued, err := f.exchange("app", code, testRedirectURI, testCodeVerifier)
+	if err != nil {
+		t.Fatalf("first exchange: %v", err)
+	}
+	_, err = f.exchange("app", code, testRedirectURI, testCodeVerifier)
+	assertOAuthError(t, err, OAuthErrInvalidGrant)
+
+	// The replay revokes what the first exchange was issued.
+	_, err = f.refresh("app", issued.RefreshToken)
+	assertOAuthError(t, err, OAuthErrInvalidGrant)
+	f.assertAccessRevoked(t, issued.AccessToken, true)
+}
+
+func TestOAuthDeleteClientRevokesTokens(t *testing.T) {
+	f := newOAuthFixture(t)
+	issued, err := f.exchange("app", f.authorize(t, "app", testRedirectURI), testRedirectURI, testCodeVerifier)
+	if err != nil {
+		t.Fatalf("exchange app: %v", err)
+	}
+	kept, err := f.exchange("other", f.authorize(t, "other", testRedirectURI), testRedirectURI, testCodeVerifier)
+	if err != nil {
+		t.Fatalf("exchange other: %v", err)
+	}
+
+	if err := f.service.DeleteClient(context.Background(), f.clients.clients["app"].ID); err != nil {
+		t.Fatalf("delete client: %v", err)
+	}
+	f.assertAccessRevoked(t, issued.AccessToken, true)
+	f.assertAccessRevoked(t, kept.AccessToken, false)
+}
+
+func (f *oauthFixture) assertAccessRevoked(t *testing.T, accessToken string, want bool) {
+	t.Helper()
+	claims, err := token.ParseAccessToken(f.cfg, f.keys, accessToken)
+	if err != nil {
+		t.Fatalf("parse access token: %v", err)
+	}
+	revoked, err := f.stores.Revocations.IsRevoked(context.Background(), claims)
+	if err != nil {
+		t.Fatalf("is revoked: %v", err)
+	}
+	if revoked != want {
+		t.Fatalf("access token revoked = %v, want %v", revoked, want)
+	}
+}
+
+func TestOAuthTokenRefresh(t *testing.T) {
+	tests := []struct {
+		name     string
+		clientID string
+		reuse    bool
+		want     string
+	}{
+		{name: "same client", clientID: "app"},
+		{name: "another client", clientID: "other", want: OAuthErrInvalidGrant},
+		{name: "reused refresh token", clientID: "app", reuse: true, want: OAuthErrInvalidGrant},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newOAuthFixture(t)
+			code := f.authorize(t, "app", testRedirectURI)
+			issued, err := f.exchange("app", code, testRedirectURI, testCodeVerifier)
+			if err != nil {
+				t.Fatalf("exchange: %v", err)
+			}
+			if tt.reuse {
+				if _, err := f.refresh("app", issued.RefreshToken); err != nil {
+					t.Fatalf("first refresh: %v", err)
+				}
+			}
+			refreshed, err := f.refresh(tt.clientID, issued.RefreshToken)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("refresh: %v", err)
+				}
+				if refreshed.RefreshToken == issued.RefreshToken {
+					t.Fatal("refresh token was not rotated")
+				}
+				return
+			}
+			assertOAuthError(t, err, tt.want)
+		})
+	}
+}
+
+func assertOAuthError(t *testing.T, err error, code string) {
+	t.Helper()
+	var oauthErr *OAuthError
+	if !errors.As(err, &oauthErr) || oauthErr.Code != code {
+		t.Fatalf("got %v, want %s", err, code)
+	}
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"wavefy-be/internal/model"
+	"wavefy-be/internal/repository"
+	"wavefy-be/internal/token"
+)
+
+var (
+	ErrRoleExists        = errors.New("role already exists")
+	ErrRoleProtected     = errors.New("role is protected")
+	ErrUnknownPermission = errors.New("unknown permission")
+)
+
+// protectedRole always holds every permission; it is maintained by the
+// migration seed and cannot be edited through the API.
+const protectedRole = "ADMIN"
+
+var roleNamePattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]{1,49}$`)
+
+type CreateRoleInput struct {
+	Name        string
+	Description string
+	Permissions []string
+}
+
+type RoleService interface {
+	List(ctx context.Context) ([]model.Role, error)
+	Create(ctx context.Context, input CreateRoleInput) (*model.Role, error)
+	SetPermissions(ctx context.Context, roleID uuid.UUID, permissions []string) (*model.Role, error)
+	ListPermissions(ctx context.Context) ([]model.Permission, error)
+	AssignRole(ctx context.Context, userID uuid.UUID, roleName string) (*model.User, error)
+}
+
+type roleService struct {
+	repo           repository.RoleRepository
+	permissionRepo repository.PermissionRepository
+	userRepo       repository.UserRepository
+	revocations    token.AccessTokenRevocationStore
+}
+
+func NewRoleService(repo repository.RoleRepository, permissionRepo repository.PermissionRepository, userRepo repository.UserRepository, revocations token.AccessTokenRevocationStore) RoleService {
+	return &roleService{repo: repo, permissionRepo: permissionRepo, userRepo: userRepo, revocations: revocations}
+}
+
+func (s *roleService) List(ctx context.Context) ([]model.Role, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *roleService) Create(ctx context.Context, input CreateRoleInput) (*model.Role, error) {
+	name := strings.ToUpper(strings.TrimSpace(input.Name))
+	if !roleNamePattern.MatchString(name) {
+		return nil, ErrInvalidInput
+	}
+	if _, err := s.repo.GetByName(ctx, name); err == nil {
+		return nil, ErrRoleExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	permissions, err := s.resolvePermissions(ctx, input.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	role := &model.Role{
+		ID:          uuid.New(),
+		Name:        name,
+		Description: strings.TrimSpace(input.Description),
+		Permissions: permissions,
+	}
+	if err := s.repo.Create(ctx, role); err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+// SetPermissions replaces the permissions of a role. Tokens of its users are
+// revoked so the change applies on their next refresh.
+func (s *roleService) SetPermissions(ctx context.Context, roleID uuid.UUID, names []string) (*model.Role, error) {
+	role, err := s.repo.GetByID(ctx, roleID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if role.Name == protectedRole {
+		return nil, ErrRoleProtected
+	}
+
+	permissions, err := s.resolvePermissions(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.ReplacePermissions(ctx, role, permissions); err != nil {
+		return nil, err
+	}
+	role.Permissions = permissions
+
+	userIDs, err := s.userRepo.ListIDsByRole(ctx, role.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, userID := range userIDs {
+		if err := s.revokeTokens(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+	return role, nil
+}
+
+func (s *roleService) ListPermissions(ctx context.Context) ([]model.Permission, error) {
+	return s.permissionRepo.List(ctx)
+}
+
+func (s *roleService) AssignRole(ctx context.Context, userID uuid.UUID, roleName string) (*model.User, error) {
+	name := strings.ToUpper(strings.TrimSpace(roleName))
+	if name == "" {
+		return nil, ErrInvalidInput
+	}
+	role, err := s.repo.GetByName(ctx, name)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	if user.RoleID != role.ID {
+		if err := s.userRepo.UpdateRole(ctx, user.ID, role.ID); err != nil {
+			return nil, err
+		}
+		if err := s.revokeTokens(ctx, user.ID); err != nil {
+			return nil, err
+		}
+	}
+	user.RoleID = role.ID
+	user.Role = *role
+	return user, nil
+}
+
+func (s *roleService) resolvePermissions(ctx context.Context, names []string) ([]model.Permission, error) {
+	unique := make([]string, 0, len(names))
+	seen := map[string]bool{}
+	for _, name := range names {
+		name = strings.TrimSpace(strings.ToLower(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		unique = append(unique, name)
+	}
+	permissions, err := s.permissionRepo.GetByNames(ctx, unique)
+	if err != nil {
+		return nil, err
+	}
+	if len(permissions) != len(unique) {
+		return nil, ErrUnknownPermission
+	}
+	return permissions, nil
+}
+
+func (s *roleService) revokeTokens(ctx context.Context, userID uuid.UUID) error {
+	if s.revocations == nil {
+		return nil
+	}
+	return s.revocations.RevokeUserTokens(ctx, userID.String(), time.Now())
+}
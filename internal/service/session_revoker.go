@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"wavefy-be/internal/repository"
+	"wavefy-be/internal/token"
+)
+
+// sessionRevoker ends every way a user is signed in. The auth and user
+// services share it so a password change, a suspension and a sign-out
+// everywhere all revoke the same things.
+type sessionRevoker struct {
+	refreshStore token.RefreshTokenStore
+	revocations  token.AccessTokenRevocationStore
+	apiKeyRepo   repository.APIKeyRepository
+	grantRepo    repository.OAuthGrantRepository
+}
+
+// revokeAllTokens signs the user out everywhere: every refresh token session
+// is dropped, access tokens issued so far stop being accepted, and API keys
+// and partner app grants are deleted. A partner app's refresh tokens only
+// work while its grant exists, so they end with it.
+func (s *sessionRevoker) revokeAllTokens(ctx context.Context, userID uuid.UUID) error {
+	if err := s.refreshStore.RevokeAll(ctx, userID.String()); err != nil {
+		return err
+	}
+	if _, _, err := s.revokeDelegatedAccess(ctx, userID); err != nil {
+		return err
+	}
+	if s.revocations != nil {
+		return s.revocations.RevokeUserTokens(ctx, userID.String(), time.Now())
+	}
+	return nil
+}
+
+// revokeDelegatedAccess deletes the user's API keys and partner app grants
+// and returns how many of each there were.
+func (s *sessionRevoker) revokeDelegatedAccess(ctx context.Context, userID uuid.UUID) (apiKeys, grants int64, err error) {
+	if s.apiKeyRepo != nil {
+		if apiKeys, err = s.apiKeyRepo.DeleteByUser(ctx, userID); err != nil {
+			return 0, 0, err
+		}
+	}
+	if s.grantRepo != nil {
+		if grants, err = s.grantRepo.DeleteByUser(ctx, userID); err != nil {
+			return apiKeys, 0, err
+		}
+	}
+	return apiKeys, grants, nil
+}
@@ -32,11 +32,11 @@ type UpdateTrackInput struct {
 }
 
 type TrackService interface {
-	Create(ctx context.Context, input CreateTrackInput) (*model.Track, error)
+	Create(ctx context.Context, actor Actor, input CreateTrackInput) (*model.Track, error)
 	Get(ctx context.Context, id uuid.UUID) (*model.Track, error)
 	List(ctx context.Context, limit, offset int) ([]model.Track, error)
-	Update(ctx context.Context, id uuid.UUID, input UpdateTrackInput) (*model.Track, error)
-	Delete(ctx context.Context, id uuid.UUID) error
+	Update(ctx context.Context, actor Actor, id uuid.UUID, input UpdateTrackInput) (*model.Track, error)
+	Delete(ctx context.Context, actor Actor, id uuid.UUID) error
 }
 
 type trackService struct {
@@ -48,10 +48,19 @@ func NewTrackService(repo repository.TrackRepository, userRepo repository.UserRe
 	return &trackService{repo: repo, userRepo: userRepo}
 }
 
-func (s *trackService) Create(ctx context.Context, input CreateTrackInput) (*model.Track, error) {
-	artistID, err := uuid.Parse(strings.TrimSpace(input.ArtistUserID))
-	if err != nil {
-		return nil, ErrInvalidInput
+// Create adds a track for an artist. Without an artist id the track belongs
+// to the actor; creating one for someone else requires tracks:manage.
+func (s *trackService) Create(ctx context.Context, actor Actor, input CreateTrackInput) (*model.Track, error) {
+	artistID := actor.UserID
+	if value := strings.TrimSpace(input.ArtistUserID); value != "" {
+		parsed, err := uuid.Parse(value)
+		if err != nil {
+			return nil, ErrInvalidInput
+		}
+		artistID = parsed
+	}
+	if !actor.CanManage(artistID, model.PermissionTracksManage) {
+		return nil, ErrForbidden
 	}
 
 	artist, err := s.userRepo.GetByID(ctx, artistID)
@@ -137,7 +146,7 @@ func (s *trackService) List(ctx context.Context, limit, offset int) ([]model.Tra
 	return s.repo.List(ctx, limit, offset)
 }
 
-func (s *trackService) Update(ctx context.Context, id uuid.UUID, input UpdateTrackInput) (*model.Track, error) {
+func (s *trackService) Update(ctx context.Context, actor Actor, id uuid.UUID, input UpdateTrackInput) (*model.Track, error) {
 	track, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -145,6 +154,9 @@ func (s *trackService) Update(ctx context.Context, id uuid.UUID, input UpdateTra
 		}
 		return nil, err
 	}
+	if !actor.CanManage(track.ArtistUserID, model.PermissionTracksManage) {
+		return nil, ErrForbidden
+	}
 
 	if input.Title != nil {
 		title := strings.TrimSpace(*input.Title)
@@ -202,12 +214,16 @@ func (s *trackService) Update(ctx context.Context, id uuid.UUID, input UpdateTra
 	return track, nil
 }
 
-func (s *trackService) Delete(ctx context.Context, id uuid.UUID) error {
-	if _, err := s.repo.GetByID(ctx, id); err != nil {
+func (s *trackService) Delete(ctx context.Context, actor Actor, id uuid.UUID) error {
+	track, err := s.repo.GetByID(ctx, id)
+	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return ErrNotFound
 		}
 		return err
 	}
+	if !actor.CanManage(track.ArtistUserID, model.PermissionTracksManage) {
+		return ErrForbidden
+	}
 	return s.repo.Delete(ctx, id)
 }
@@ -1,14 +1,17 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 
 	"wavefy-be/config"
 )
@@ -54,6 +57,9 @@ type UploadService interface {
 	PresignPut(ctx context.Context, input PresignPutInput) (*PresignPutOutput, error)
 	PresignGet(ctx context.Context, input PresignGetInput) (*PresignGetOutput, error)
 	DeleteObject(ctx context.Context, input DeleteObjectInput) (*DeleteObjectOutput, error)
+	DeletePrefix(ctx context.Context, prefix string) (int, error)
+	DeletePrefixBefore(ctx context.Context, prefix string, before time.Time) (int, error)
+	PutObject(ctx context.Context, input PutObjectInput) error
 }
 
 type uploadService struct {
@@ -212,3 +218,90 @@ func (s *uploadService) DeleteObject(ctx context.Context, input DeleteObjectInpu
 		Bucket: s.bucket,
 	}, nil
 }
+
+// DeletePrefix deletes every object whose key starts with prefix and returns
+// how many were deleted.
+func (s *uploadService) DeletePrefix(ctx context.Context, prefix string) (int, error) {
+	return s.deleteMatching(ctx, prefix, func(types.Object) bool { return true })
+}
+
+// DeletePrefixBefore deletes the objects under prefix last modified before
+// before and returns how many were deleted.
+func (s *uploadService) DeletePrefixBefore(ctx context.Context, prefix string, before time.Time) (int, error) {
+	return s.deleteMatching(ctx, prefix, func(object types.Object) bool {
+		return object.LastModified != nil && object.LastModified.Before(before)
+	})
+}
+
+func (s *uploadService) deleteMatching(ctx context.Context, prefix string, match func(types.Object) bool) (int, error) {
+	if s.client == nil || strings.TrimSpace(s.bucket) == "" {
+		return 0, ErrStorageNotConfigured
+	}
+	if strings.TrimSpace(prefix) == "" {
+		return 0, ErrInvalidInput
+	}
+
+	deleted := 0
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return deleted, err
+		}
+		objects := make([]types.ObjectIdentifier, 0, len(page.Contents))
+		for _, object := range page.Contents {
+			if match(object) {
+				objects = append(objects, types.ObjectIdentifier{Key: object.Key})
+			}
+		}
+		if len(objects) == 0 {
+			continue
+		}
+		out, err := s.client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(s.bucket),
+			Delete: &types.Delete{Objects: objects, Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			return deleted, err
+		}
+		if len(out.Errors) > 0 {
+			return deleted, fmt.Errorf("delete %s: %s", aws.ToString(out.Errors[0].Key), aws.ToString(out.Errors[0].Message))
+		}
+		deleted += len(objects)
+	}
+	return deleted, nil
+}
+
+type PutObjectInput struct {
+	Key         string
+	ContentType string
+	Body        []byte
+}
+
+// PutObject uploads an object from the server, for files the API builds
+// itself rather than ones clients upload through a presigned URL.
+func (s *uploadService) PutObject(ctx context.Context, input PutObjectInput) error {
+	if s.client == nil || strings.TrimSpace(s.bucket) == "" {
+		return ErrStorageNotConfigured
+	}
+
+	key := strings.TrimSpace(input.Key)
+	if key == "" {
+		return ErrInvalidInput
+	}
+
+	putInput := &s3.PutObjectInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(key),
+		Body:          bytes.NewReader(input.Body),
+		ContentLength: aws.Int64(int64(len(input.Body))),
+	}
+	if contentType := strings.TrimSpace(input.ContentType); contentType != "" {
+		putInput.ContentType = aws.String(contentType)
+	}
+	_, err := s.client.PutObject(ctx, putInput)
+	return err
+}
@@ -4,19 +4,24 @@ import (
 	"context"
 	"errors"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 
 	"wavefy-be/internal/model"
+	"wavefy-be/internal/password"
 	"wavefy-be/internal/repository"
+	"wavefy-be/internal/token"
 )
 
 var (
 	ErrInvalidInput = errors.New("invalid input")
 	ErrEmailExists  = errors.New("email already exists")
 	ErrNotFound     = errors.New("not found")
+	// ErrEmailChangeNeedsConfirmation is returned when users edit their own
+	// email through Update instead of the confirmed change flow.
+	ErrEmailChangeNeedsConfirmation = errors.New("email change needs confirmation")
 )
 
 type CreateUserInput struct {
@@ -26,27 +31,52 @@ type CreateUserInput struct {
 
 type UserService interface {
 	Create(ctx context.Context, input CreateUserInput) (*model.User, error)
-	Get(ctx context.Context, id uuid.UUID) (*model.User, error)
+	Get(ctx context.Context, actor Actor, id uuid.UUID) (*model.User, error)
 	List(ctx context.Context, limit, offset int) ([]model.User, error)
-	Update(ctx context.Context, id uuid.UUID, input UpdateUserInput) (*model.User, error)
-	Delete(ctx context.Context, id uuid.UUID) error
+	Update(ctx context.Context, actor Actor, id uuid.UUID, input UpdateUserInput) (*model.User, error)
+	Delete(ctx context.Context, actor Actor, id uuid.UUID) error
+	Suspend(ctx context.Context, actor Actor, id uuid.UUID, input SuspendUserInput) (*model.User, error)
+	Reinstate(ctx context.Context, actor Actor, id uuid.UUID) (*model.User, error)
 }
 
 type userService struct {
+	sessionRevoker
 	repo     repository.UserRepository
 	roleRepo repository.RoleRepository
+	statuses token.AccountStatusStore
+	policy   *password.Policy
+	hasher   password.Hasher
+	// deletionGrace is how long an account deleted by an admin is kept
+	// before it is purged.
+	deletionGrace time.Duration
 }
 
-func NewUserService(repo repository.UserRepository, roleRepo repository.RoleRepository) UserService {
-	return &userService{repo: repo, roleRepo: roleRepo}
+func NewUserService(repo repository.UserRepository, roleRepo repository.RoleRepository, refreshStore token.RefreshTokenStore, revocations token.AccessTokenRevocationStore, statuses token.AccountStatusStore, apiKeyRepo repository.APIKeyRepository, grantRepo repository.OAuthGrantRepository, policy *password.Policy, hasher password.Hasher, deletionGrace time.Duration) UserService {
+	return &userService{
+		sessionRevoker: sessionRevoker{
+			refreshStore: refreshStore,
+			revocations:  revocations,
+			apiKeyRepo:   apiKeyRepo,
+			grantRepo:    grantRepo,
+		},
+		repo:          repo,
+		roleRepo:      roleRepo,
+		statuses:      statuses,
+		policy:        policy,
+		hasher:        hasher,
+		deletionGrace: deletionGrace,
+	}
 }
 
 func (s *userService) Create(ctx context.Context, input CreateUserInput) (*model.User, error) {
 	input.Email = strings.TrimSpace(strings.ToLower(input.Email))
 
-	if input.Email == "" || input.Password == "" {
+	if input.Email == "" {
 		return nil, ErrInvalidInput
 	}
+	if err := s.policy.Check("password", input.Password, input.Email); err != nil {
+		return nil, err
+	}
 
 	if _, err := s.repo.GetByEmail(ctx, input.Email); err == nil {
 		return nil, ErrEmailExists
@@ -54,7 +84,7 @@ func (s *userService) Create(ctx context.Context, input CreateUserInput) (*model
 		return nil, err
 	}
 
-	hash, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	hash, err := s.hasher.Hash(input.Password)
 	if err != nil {
 		return nil, err
 	}
@@ -62,8 +92,9 @@ func (s *userService) Create(ctx context.Context, input CreateUserInput) (*model
 	user := &model.User{
 		ID:           uuid.New(),
 		Email:        input.Email,
-		PasswordHash: string(hash),
+		PasswordHash: hash,
 		IsActive:     false,
+		Status:       model.UserStatusPending,
 	}
 
 	role, err := s.roleRepo.GetByName(ctx, "USER")
@@ -90,7 +121,12 @@ type UpdateUserInput struct {
 	Password  *string
 }
 
-func (s *userService) Get(ctx context.Context, id uuid.UUID) (*model.User, error) {
+// Get returns a user profile; users can only read their own unless they hold
+// users:read.
+func (s *userService) Get(ctx context.Context, actor Actor, id uuid.UUID) (*model.User, error) {
+	if !actor.CanManage(id, model.PermissionUsersRead) {
+		return nil, ErrForbidden
+	}
 	user, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -105,7 +141,10 @@ func (s *userService) List(ctx context.Context, limit, offset int) ([]model.User
 	return s.repo.List(ctx, limit, offset)
 }
 
-func (s *userService) Update(ctx context.Context, id uuid.UUID, input UpdateUserInput) (*model.User, error) {
+func (s *userService) Update(ctx context.Context, actor Actor, id uuid.UUID, input UpdateUserInput) (*model.User, error) {
+	if !actor.CanManage(id, model.PermissionUsersManage) {
+		return nil, ErrForbidden
+	}
 	user, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -121,6 +160,9 @@ func (s *userService) Update(ctx context.Context, id uuid.UUID, input UpdateUser
 		user.LastName = strings.TrimSpace(*input.LastName)
 	}
 	if input.Email != nil {
+		if actor.UserID == user.ID {
+			return nil, ErrEmailChangeNeedsConfirmation
+		}
 		email := strings.TrimSpace(strings.ToLower(*input.Email))
 		if email == "" {
 			return nil, ErrInvalidInput
@@ -133,29 +175,56 @@ func (s *userService) Update(ctx context.Context, id uuid.UUID, input UpdateUser
 		user.Email = email
 	}
 	if input.Password != nil {
-		if *input.Password == "" {
-			return nil, ErrInvalidInput
+		if err := s.policy.Check("password", *input.Password, user.Email); err != nil {
+			return nil, err
 		}
-		hash, err := bcrypt.GenerateFromPassword([]byte(*input.Password), bcrypt.DefaultCost)
+		hash, err := s.hasher.Hash(*input.Password)
 		if err != nil {
 			return nil, err
 		}
-		user.PasswordHash = string(hash)
+		user.PasswordHash = hash
 	}
 
 	if err := s.repo.Update(ctx, user); err != nil {
 		return nil, err
 	}
+	// A new password signs the user out everywhere, as a reset does.
+	if input.Password != nil {
+		if err := s.revokeAllTokens(ctx, user.ID); err != nil {
+			return nil, err
+		}
+	}
 
 	return user, nil
 }
 
-func (s *userService) Delete(ctx context.Context, id uuid.UUID) error {
-	if _, err := s.repo.GetByID(ctx, id); err != nil {
+// Delete soft-deletes a user. The account is purged once the deletion grace
+// period has passed, the same as one whose owner asked for deletion.
+func (s *userService) Delete(ctx context.Context, actor Actor, id uuid.UUID) error {
+	if !actor.Has(model.PermissionUsersManage) {
+		return ErrForbidden
+	}
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return ErrNotFound
 		}
 		return err
 	}
-	return s.repo.Delete(ctx, id)
+	purgeAt := time.Now().UTC().Add(s.deletionGrace)
+	if user.PurgeAt == nil || user.PurgeAt.After(purgeAt) {
+		user.PurgeAt = &purgeAt
+		if err := s.repo.Update(ctx, user); err != nil {
+			return err
+		}
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	if s.revocations != nil {
+		if err := s.revocations.RevokeUserTokens(ctx, id.String(), time.Now()); err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"wavefy-be/internal/model"
+	"wavefy-be/internal/token"
+)
+
+func TestUserServiceDeleteKeepsGracePeriod(t *testing.T) {
+	f := newAuthFixture(t)
+	admin := Actor{UserID: uuid.New(), Permissions: []string{model.PermissionUsersManage}}
+
+	before := time.Now().UTC()
+	if err := f.userService.Delete(context.Background(), admin, f.user.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	stored := f.users.stored(f.user.ID)
+	if !stored.DeletedAt.Valid {
+		t.Fatal("user was not soft-deleted")
+	}
+	if stored.PurgeAt == nil || stored.PurgeAt.Before(before.Add(f.cfg.DeletionGrace)) {
+		t.Fatalf("purge at %v, want the end of the %v grace period", stored.PurgeAt, f.cfg.DeletionGrace)
+	}
+}
+
+func TestUserServiceSuspendSignsOut(t *testing.T) {
+	f := newAuthFixture(t)
+	ctx := context.Background()
+	session := f.login(t)
+	admin := Actor{UserID: uuid.New(), Permissions: []string{model.PermissionUsersManage}}
+
+	if _, err := f.userService.Suspend(ctx, admin, f.user.ID, SuspendUserInput{Reason: "spam", Ban: true}); err != nil {
+		t.Fatalf("suspend: %v", err)
+	}
+	if _, err := f.userService.Reinstate(ctx, admin, f.user.ID); err != nil {
+		t.Fatalf("reinstate: %v", err)
+	}
+	if _, _, err := f.service.Refresh(ctx, session.RefreshToken, ClientInfo{}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("refresh of a session from before the ban: got %v, want ErrInvalidCredentials", err)
+	}
+}
+
+func TestSyncAccountBlocks(t *testing.T) {
+	f := newAuthFixture(t)
+	ctx := context.Background()
+
+	banned := *f.user
+	banned.Status = model.UserStatusBanned
+	banned.StatusReason = "spam"
+	if err := f.users.Update(ctx, &banned); err != nil {
+		t.Fatalf("ban: %v", err)
+	}
+
+	// A restarted memory backend starts without the block.
+	statuses := token.NewMemoryAccountStatusStore()
+	if n, err := SyncAccountBlocks(ctx, f.users, statuses); err != nil || n != 1 {
+		t.Fatalf("sync: n=%d err=%v", n, err)
+	}
+	block, err := statuses.Get(ctx, f.user.ID.String())
+	if err != nil {
+		t.Fatalf("get block: %v", err)
+	}
+	if block == nil || block.Status != model.UserStatusBanned {
+		t.Fatalf("block = %+v, want banned", block)
+	}
+}
+
+func TestUserServiceUpdatePasswordSignsOut(t *testing.T) {
+	f := newAuthFixture(t)
+	ctx := context.Background()
+	session := f.login(t)
+	admin := Actor{UserID: uuid.New(), Permissions: []string{model.PermissionUsersManage}}
+
+	newPassword := "Battery-staple-2"
+	if _, err := f.userService.Update(ctx, admin, f.user.ID, UpdateUserInput{Password: &newPassword}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	claims, err := token.ParseAccessToken(f.cfg, f.keys, session.AccessToken)
+	if err != nil {
+		t.Fatalf("parse access token: %v", err)
+	}
+	if revoked, err := f.stores.Revocations.IsRevoked(ctx, claims); err != nil || !revoked {
+		t.Fatalf("access token after password change: revoked=%v err=%v", revoked, err)
+	}
+	if _, _, err := f.service.Refresh(ctx, session.RefreshToken, ClientInfo{}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("refresh after password change: got %v, want ErrInvalidCredentials", err)
+	}
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"wavefy-be/internal/model"
+	"wavefy-be/internal/repository"
+	"wavefy-be/internal/token"
+)
+
+// ErrNotSuspended is returned when reinstating an account that is neither
+// suspended nor banned.
+var ErrNotSuspended = errors.New("account is not suspended")
+
+// AccountStatusError is returned instead of tokens when a suspended or banned
+// account signs in or refreshes.
+type AccountStatusError struct {
+	Status string
+	Reason string
+	Until  *time.Time
+}
+
+func (e *AccountStatusError) Error() string {
+	return "account " + e.Status
+}
+
+type SuspendUserInput struct {
+	Reason string
+	// Until ends the suspension; nil suspends until reinstated. Ignored for
+	// bans.
+	Until *time.Time
+	Ban   bool
+}
+
+// Suspend suspends or bans a user. Sign-ins are refused at once and the user
+// is signed out everywhere: sessions, access tokens, API keys and partner app
+// grants are revoked, so nothing issued before survives a reinstatement.
+func (s *userService) Suspend(ctx context.Context, actor Actor, id uuid.UUID, input SuspendUserInput) (*model.User, error) {
+	if !actor.Has(model.PermissionUsersManage) {
+		return nil, ErrForbidden
+	}
+	if actor.UserID == id {
+		return nil, ErrForbidden
+	}
+	input.Reason = strings.TrimSpace(input.Reason)
+	if input.Reason == "" || len(input.Reason) > 500 {
+		return nil, ErrInvalidInput
+	}
+	if input.Ban {
+		input.Until = nil
+	} else if input.Until != nil && !input.Until.After(time.Now()) {
+		return nil, ErrInvalidInput
+	}
+
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	user.Status = model.UserStatusSuspended
+	if input.Ban {
+		user.Status = model.UserStatusBanned
+	}
+	user.StatusReason = input.Reason
+	user.SuspendUntil = nil
+	if input.Until != nil {
+		until := input.Until.UTC()
+		user.SuspendUntil = &until
+	}
+	if err := s.repo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	if s.statuses != nil {
+		block := token.AccountBlock{Status: user.Status, Reason: user.StatusReason, Until: user.SuspendUntil}
+		if err := s.statuses.Block(ctx, user.ID.String(), block); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.revokeAllTokens(ctx, user.ID); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Reinstate lifts a suspension or ban.
+func (s *userService) Reinstate(ctx context.Context, actor Actor, id uuid.UUID) (*model.User, error) {
+	if !actor.Has(model.PermissionUsersManage) {
+		return nil, ErrForbidden
+	}
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if user.Status != model.UserStatusSuspended && user.Status != model.UserStatusBanned {
+		return nil, ErrNotSuspended
+	}
+
+	user.Status = model.UserStatusPending
+	if user.IsActive {
+		user.Status = model.UserStatusActive
+	}
+	user.StatusReason = ""
+	user.SuspendUntil = nil
+	if err := s.repo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	if s.statuses != nil {
+		if err := s.statuses.Unblock(ctx, user.ID.String()); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+// SyncAccountBlocks writes the block of every suspended and banned account
+// to statuses and returns how many there were. The database is the source of
+// truth; this puts back blocks lost when Redis is flushed or the memory
+// backend restarts.
+func SyncAccountBlocks(ctx context.Context, userRepo repository.UserRepository, statuses token.AccountStatusStore) (int, error) {
+	users, err := userRepo.ListBlocked(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, user := range users {
+		block := token.AccountBlock{Status: user.Status, Reason: user.StatusReason, Until: user.SuspendUntil}
+		if err := statuses.Block(ctx, user.ID.String(), block); err != nil {
+			return 0, err
+		}
+	}
+	return len(users), nil
+}
+
+// checkAccountStatus refuses suspended and banned accounts. A suspension
+// whose end has passed no longer counts.
+func checkAccountStatus(user *model.User) error {
+	switch user.Status {
+	case model.UserStatusBanned:
+		return &AccountStatusError{Status: user.Status, Reason: user.StatusReason}
+	case model.UserStatusSuspended:
+		if user.SuspendUntil != nil && !user.SuspendUntil.After(time.Now()) {
+			return nil
+		}
+		return &AccountStatusError{Status: user.Status, Reason: user.StatusReason, Until: user.SuspendUntil}
+	}
+	return nil
+}
+
+// markEmailVerified sets IsActive and lets an account that was waiting for
+// verification be used; suspensions and bans are kept.
+func markEmailVerified(user *model.User) {
+	user.IsActive = true
+	if user.Status == "" || user.Status == model.UserStatusPending {
+		user.Status = model.UserStatusActive
+	}
+}
@@ -1,24 +1,108 @@
 package token
 
 import (
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 
 	"wavefy-be/config"
 )
 
+var (
+	ErrInvalidAccessToken = errors.New("invalid token")
+	ErrInvalidTokenIssuer = errors.New("invalid token issuer")
+)
+
+func init() {
+	// Access tokens are checked against per-user revocation times, so iat
+	// keeps milliseconds: a token issued in the same second as a sign-out
+	// everywhere must still be told apart from one issued after it.
+	jwt.TimePrecision = time.Millisecond
+}
+
+// MaxAccessTokenTTL is the longest an access token of any kind stays valid:
+// session, partner app or impersonation.
+func MaxAccessTokenTTL(cfg config.AuthConfig) time.Duration {
+	ttl := cfg.AccessTokenTTL
+	for _, other := range []time.Duration{cfg.OAuthAccessTTL, cfg.ImpersonateTTL} {
+		if other > ttl {
+			ttl = other
+		}
+	}
+	return ttl
+}
+
+// AccessTokenClaims carries the role name and the permissions it resolved to
+// when the token was issued, so authorization does not hit the database.
+// Tokens issued to a partner app through OAuth also name the client and the
+// scopes the user consented to. Impersonation tokens name the staff member
+// acting as the subject in Actor.
 type AccessTokenClaims struct {
-	Role string `json:"role"`
+	Role        string       `json:"role"`
+	Permissions []string     `json:"perms,omitempty"`
+	ClientID    string       `json:"client_id,omitempty"`
+	Scope       string       `json:"scope,omitempty"`
+	Actor       *ActorClaims `json:"act,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func IssueAccessToken(cfg config.AuthConfig, subject, role string) (string, time.Time, error) {
-	now := time.Now().UTC()
-	expiresAt := now.Add(cfg.AccessTokenTTL)
+// ActorClaims is the "act" claim of RFC 8693: who is acting for the subject.
+type ActorClaims struct {
+	Subject string `json:"sub"`
+}
+
+// Delegated reports whether the token was issued to a partner app.
+func (c *AccessTokenClaims) Delegated() bool {
+	return c.ClientID != ""
+}
+
+// Impersonated reports whether the token lets someone else act as the
+// subject.
+func (c *AccessTokenClaims) Impersonated() bool {
+	return c.Actor != nil && c.Actor.Subject != ""
+}
+
+func IssueAccessToken(cfg config.AuthConfig, keys *KeyRing, subject, role string, permissions []string) (string, time.Time, error) {
+	return issueAccessToken(cfg, keys, AccessTokenClaims{Role: role, Permissions: permissions}, subject, cfg.AccessTokenTTL)
+}
+
+// IssueDelegatedAccessToken issues a token a partner app uses on behalf of
+// subject, limited to scopes.
+func IssueDelegatedAccessToken(cfg config.AuthConfig, keys *KeyRing, subject, role string, permissions []string, clientID string, scopes []string) (string, time.Time, error) {
+	claims := AccessTokenClaims{
+		Role:        role,
+		Permissions: permissions,
+		ClientID:    clientID,
+		Scope:       strings.Join(scopes, " "),
+	}
+	return issueAccessToken(cfg, keys, claims, subject, cfg.OAuthAccessTTL)
+}
+
+// IssueImpersonationToken issues a short-lived token that acts as subject
+// with the subject's role, on behalf of actorID.
+func IssueImpersonationToken(cfg config.AuthConfig, keys *KeyRing, subject, role string, permissions []string, actorID string) (string, time.Time, error) {
 	claims := AccessTokenClaims{
-		Role: role,
+		Role:        role,
+		Permissions: permissions,
+		Actor:       &ActorClaims{Subject: actorID},
+	}
+	return issueAccessToken(cfg, keys, claims, subject, cfg.ImpersonateTTL)
+}
+
+func issueAccessToken(cfg config.AuthConfig, keys *KeyRing, claims AccessTokenClaims, subject string, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now().UTC()
+	expiresAt := now.Add(ttl)
+	claims = AccessTokenClaims{
+		Role:        claims.Role,
+		Permissions: claims.Permissions,
+		ClientID:    claims.ClientID,
+		Scope:       claims.Scope,
+		Actor:       claims.Actor,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
 			Subject:   subject,
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(now),
@@ -26,11 +110,26 @@ func IssueAccessToken(cfg config.AuthConfig, subject, role string) (string, time
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signed, err := token.SignedString([]byte(cfg.JWTSecret))
+	signed, err := keys.Sign(claims)
 	if err != nil {
 		return "", time.Time{}, err
 	}
 
 	return signed, expiresAt, nil
 }
+
+// ParseAccessToken verifies the signature against the key ring and checks the
+// issuer of an access token.
+func ParseAccessToken(cfg config.AuthConfig, keys *KeyRing, tokenStr string) (*AccessTokenClaims, error) {
+	claims := &AccessTokenClaims{}
+	parsed, err := jwt.ParseWithClaims(tokenStr, claims, keys.Keyfunc, jwt.WithValidMethods(keys.ValidMethods()))
+	if err != nil || !parsed.Valid {
+		return nil, ErrInvalidAccessToken
+	}
+
+	if cfg.AccessTokenIss != "" && claims.Issuer != cfg.AccessTokenIss {
+		return nil, ErrInvalidTokenIssuer
+	}
+
+	return claims, nil
+}
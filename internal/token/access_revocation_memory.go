@@ -0,0 +1,82 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+type memoryAccessRevocationStore struct {
+	kv  *memoryKV
+	ttl time.Duration
+}
+
+// NewMemoryAccessTokenRevocationStore mirrors the Redis revocation list in
+// process memory, for a single instance and tests.
+func NewMemoryAccessTokenRevocationStore(ttl time.Duration) AccessTokenRevocationStore {
+	return &memoryAccessRevocationStore{kv: newMemoryKV(), ttl: ttl}
+}
+
+func (s *memoryAccessRevocationStore) RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	if tokenID == "" {
+		return errors.New("invalid token id")
+	}
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	s.kv.mu.Lock()
+	defer s.kv.mu.Unlock()
+	s.kv.set("jti:"+tokenID, "1", ttl)
+	return nil
+}
+
+func (s *memoryAccessRevocationStore) RevokeUserTokens(ctx context.Context, userID string, before time.Time) error {
+	if userID == "" {
+		return errors.New("invalid user id")
+	}
+	s.kv.mu.Lock()
+	s.kv.put("user:"+userID, &memoryKVEntry{count: before.UnixMilli(), expiresAt: s.kv.expiry(s.ttl)})
+	s.kv.mu.Unlock()
+	waitPastMillis(before)
+	return nil
+}
+
+func (s *memoryAccessRevocationStore) RevokeClientTokens(ctx context.Context, clientID, userID string, before time.Time) error {
+	if clientID == "" {
+		return errors.New("invalid client id")
+	}
+	key := "client:" + clientID
+	if userID != "" {
+		key = "grant:" + clientID + ":" + userID
+	}
+	s.kv.mu.Lock()
+	s.kv.put(key, &memoryKVEntry{count: before.UnixMilli(), expiresAt: s.kv.expiry(s.ttl)})
+	s.kv.mu.Unlock()
+	waitPastMillis(before)
+	return nil
+}
+
+func (s *memoryAccessRevocationStore) IsRevoked(ctx context.Context, claims *AccessTokenClaims) (bool, error) {
+	if claims == nil {
+		return false, nil
+	}
+	s.kv.mu.Lock()
+	defer s.kv.mu.Unlock()
+
+	if claims.ID != "" {
+		if _, ok := s.kv.get("jti:" + claims.ID); ok {
+			return true, nil
+		}
+	}
+	markers := []string{"user:" + claims.Subject}
+	if claims.Delegated() {
+		markers = append(markers, "client:"+claims.ClientID, "grant:"+claims.ClientID+":"+claims.Subject)
+	}
+	for _, key := range markers {
+		if entry, ok := s.kv.get(key); ok && issuedBefore(claims, entry.count) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
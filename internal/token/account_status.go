@@ -0,0 +1,87 @@
+package token
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// AccountBlock is why an account may not be used. Until is empty for bans
+// and suspensions without an end.
+type AccountBlock struct {
+	Status string     `json:"status"`
+	Reason string     `json:"reason"`
+	Until  *time.Time `json:"until,omitempty"`
+}
+
+// AccountStatusStore lists suspended and banned accounts so access tokens can
+// be checked on every request without reading the database, which stays the
+// source of truth and is copied in again at startup and every minute. A
+// suspension's entry expires together with it.
+type AccountStatusStore interface {
+	Block(ctx context.Context, userID string, block AccountBlock) error
+	Unblock(ctx context.Context, userID string) error
+	Get(ctx context.Context, userID string) (*AccountBlock, error)
+}
+
+type accountStatusStore struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewAccountStatusStore(client *redis.Client) AccountStatusStore {
+	return &accountStatusStore{
+		client: client,
+		prefix: "account:blocked:",
+	}
+}
+
+func (s *accountStatusStore) Block(ctx context.Context, userID string, block AccountBlock) error {
+	if s.client == nil {
+		return nil
+	}
+	if userID == "" {
+		return errors.New("invalid user id")
+	}
+	var ttl time.Duration
+	if block.Until != nil {
+		ttl = time.Until(*block.Until)
+		if ttl <= 0 {
+			return s.Unblock(ctx, userID)
+		}
+	}
+	payload, err := json.Marshal(block)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, s.prefix+userID, payload, ttl).Err()
+}
+
+func (s *accountStatusStore) Unblock(ctx context.Context, userID string) error {
+	if s.client == nil {
+		return nil
+	}
+	return s.client.Del(ctx, s.prefix+userID).Err()
+}
+
+// Get returns the user's block, or nil when the account may be used.
+func (s *accountStatusStore) Get(ctx context.Context, userID string) (*AccountBlock, error) {
+	if s.client == nil || userID == "" {
+		return nil, nil
+	}
+	payload, err := s.client.Get(ctx, s.prefix+userID).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var block AccountBlock
+	if err := json.Unmarshal(payload, &block); err != nil {
+		return nil, err
+	}
+	return &block, nil
+}
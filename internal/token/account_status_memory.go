@@ -0,0 +1,61 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+type memoryAccountStatusStore struct {
+	mu     sync.Mutex
+	blocks map[string]AccountBlock
+	now    func() time.Time
+}
+
+// NewMemoryAccountStatusStore keeps blocked accounts in process memory, for a
+// single instance and tests.
+func NewMemoryAccountStatusStore() AccountStatusStore {
+	return &memoryAccountStatusStore{
+		blocks: map[string]AccountBlock{},
+		now:    time.Now,
+	}
+}
+
+func (s *memoryAccountStatusStore) Block(ctx context.Context, userID string, block AccountBlock) error {
+	if userID == "" {
+		return errors.New("invalid user id")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if block.Until != nil && !block.Until.After(s.now()) {
+		delete(s.blocks, userID)
+		return nil
+	}
+	s.blocks[userID] = block
+	return nil
+}
+
+func (s *memoryAccountStatusStore) Unblock(ctx context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.blocks, userID)
+	return nil
+}
+
+func (s *memoryAccountStatusStore) Get(ctx context.Context, userID string) (*AccountBlock, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	block, ok := s.blocks[userID]
+	if !ok {
+		return nil, nil
+	}
+	if block.Until != nil && !block.Until.After(s.now()) {
+		delete(s.blocks, userID)
+		return nil, nil
+	}
+	return &block, nil
+}
@@ -0,0 +1,61 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DataExportLimiter allows one personal data export per user per cooldown.
+// Allow claims the slot when it is free; otherwise it reports how long until
+// it is. Release frees the slot again, for exports that failed.
+type DataExportLimiter interface {
+	Allow(ctx context.Context, userID string) (allowed bool, retryAfter time.Duration, err error)
+	Release(ctx context.Context, userID string) error
+}
+
+type dataExportLimiter struct {
+	client   *redis.Client
+	cooldown time.Duration
+	prefix   string
+}
+
+func NewDataExportLimiter(client *redis.Client, cooldown time.Duration) DataExportLimiter {
+	return &dataExportLimiter{
+		client:   client,
+		cooldown: cooldown,
+		prefix:   "export:cooldown:",
+	}
+}
+
+func (l *dataExportLimiter) Allow(ctx context.Context, userID string) (bool, time.Duration, error) {
+	if l.client == nil || l.cooldown <= 0 {
+		return true, 0, nil
+	}
+	if userID == "" {
+		return false, 0, errors.New("invalid user id")
+	}
+
+	key := l.prefix + userID
+	set, err := l.client.SetNX(ctx, key, "1", l.cooldown).Result()
+	if err != nil {
+		return false, 0, err
+	}
+	if set {
+		return true, 0, nil
+	}
+	ttl, err := l.client.TTL(ctx, key).Result()
+	if err != nil || ttl <= 0 {
+		ttl = l.cooldown
+	}
+	return false, ttl, nil
+}
+
+func (l *dataExportLimiter) Release(ctx context.Context, userID string) error {
+	if l.client == nil {
+		return nil
+	}
+	return l.client.Del(ctx, l.prefix+userID).Err()
+}
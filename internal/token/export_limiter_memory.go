@@ -0,0 +1,42 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+type memoryDataExportLimiter struct {
+	kv       *memoryKV
+	cooldown time.Duration
+}
+
+// NewMemoryDataExportLimiter mirrors the Redis export cooldown in process
+// memory, for a single instance and tests.
+func NewMemoryDataExportLimiter(cooldown time.Duration) DataExportLimiter {
+	return &memoryDataExportLimiter{kv: newMemoryKV(), cooldown: cooldown}
+}
+
+func (l *memoryDataExportLimiter) Allow(ctx context.Context, userID string) (bool, time.Duration, error) {
+	if l.cooldown <= 0 {
+		return true, 0, nil
+	}
+	if userID == "" {
+		return false, 0, errors.New("invalid user id")
+	}
+	l.kv.mu.Lock()
+	defer l.kv.mu.Unlock()
+
+	if l.kv.setNX(userID, "1", l.cooldown) {
+		return true, 0, nil
+	}
+	return false, l.kv.ttl(userID), nil
+}
+
+func (l *memoryDataExportLimiter) Release(ctx context.Context, userID string) error {
+	l.kv.mu.Lock()
+	defer l.kv.mu.Unlock()
+
+	l.kv.del(userID)
+	return nil
+}
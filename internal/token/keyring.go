@@ -0,0 +1,231 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"wavefy-be/config"
+)
+
+// KeyRing holds the keys used to sign and verify access tokens. One key is
+// active and signs new tokens; the others only verify tokens issued before a
+// rotation. Keys are selected by the kid header.
+type KeyRing struct {
+	active *jwtKey
+	keys   map[string]*jwtKey
+	// hmacSecret verifies HS256 tokens. Without a key directory it also signs
+	// them; with one it only verifies tokens issued before the move to
+	// asymmetric keys, and only until hmacUntil.
+	hmacSecret []byte
+	hmacUntil  time.Time
+	now        func() time.Time
+}
+
+type jwtKey struct {
+	kid     string
+	method  jwt.SigningMethod
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// LoadKeyRing reads every <kid>.pem file from AUTH_JWT_KEYS_DIR. The key named
+// by AUTH_JWT_ACTIVE_KID must be a private key; the others may be public keys.
+// Without a key directory tokens are signed with HS256 and AUTH_JWT_SECRET.
+// With one, HS256 tokens are accepted only until AUTH_JWT_LEGACY_HS256_UNTIL,
+// and not at all when it is unset.
+func LoadKeyRing(cfg config.AuthConfig) (*KeyRing, error) {
+	ring := &KeyRing{keys: map[string]*jwtKey{}, now: time.Now}
+
+	if strings.TrimSpace(cfg.JWTKeysDir) == "" {
+		if cfg.JWTSecret == "" {
+			return nil, errors.New("jwt: either AUTH_JWT_KEYS_DIR or AUTH_JWT_SECRET is required")
+		}
+		ring.hmacSecret = []byte(cfg.JWTSecret)
+		return ring, nil
+	}
+	if cfg.JWTSecret != "" && !cfg.JWTLegacyHS256Until.IsZero() {
+		ring.hmacSecret = []byte(cfg.JWTSecret)
+		ring.hmacUntil = cfg.JWTLegacyHS256Until
+	}
+
+	paths, err := filepath.Glob(filepath.Join(cfg.JWTKeysDir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		kid := strings.TrimSuffix(filepath.Base(path), ".pem")
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		key, err := parseJWTKey(kid, data)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: key %s: %w", kid, err)
+		}
+		ring.keys[kid] = key
+	}
+
+	active, ok := ring.keys[cfg.JWTActiveKID]
+	if !ok {
+		return nil, fmt.Errorf("jwt: active key %q not found in %s", cfg.JWTActiveKID, cfg.JWTKeysDir)
+	}
+	if active.private == nil {
+		return nil, fmt.Errorf("jwt: active key %q has no private key", cfg.JWTActiveKID)
+	}
+	ring.active = active
+	return ring, nil
+}
+
+// Sign signs claims with the active key, or with the shared secret when no
+// asymmetric key is configured. A legacy secret kept for verification never
+// signs.
+func (r *KeyRing) Sign(claims jwt.Claims) (string, error) {
+	if r.active != nil {
+		t := jwt.NewWithClaims(r.active.method, claims)
+		t.Header["kid"] = r.active.kid
+		return t.SignedString(r.active.private)
+	}
+	if len(r.keys) > 0 || r.hmacSecret == nil {
+		return "", errors.New("jwt: no signing key")
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(r.hmacSecret)
+}
+
+// Keyfunc resolves the verification key of a parsed token.
+func (r *KeyRing) Keyfunc(t *jwt.Token) (interface{}, error) {
+	if _, ok := t.Method.(*jwt.SigningMethodHMAC); ok {
+		if !r.acceptsHMAC() {
+			return nil, errors.New("invalid signing method")
+		}
+		return r.hmacSecret, nil
+	}
+
+	kid, _ := t.Header["kid"].(string)
+	key, ok := r.keys[kid]
+	if !ok {
+		return nil, errors.New("unknown signing key")
+	}
+	if t.Method.Alg() != key.method.Alg() {
+		return nil, errors.New("invalid signing method")
+	}
+	return key.public, nil
+}
+
+// ValidMethods lists the algorithms the ring can verify.
+func (r *KeyRing) ValidMethods() []string {
+	methods := []string{}
+	seen := map[string]bool{}
+	for _, key := range r.keys {
+		if !seen[key.method.Alg()] {
+			seen[key.method.Alg()] = true
+			methods = append(methods, key.method.Alg())
+		}
+	}
+	if r.acceptsHMAC() {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	return methods
+}
+
+// acceptsHMAC reports whether HS256 tokens are still verified: always in
+// shared-secret mode, and before the legacy deadline once keys are configured.
+func (r *KeyRing) acceptsHMAC() bool {
+	if r.hmacSecret == nil {
+		return false
+	}
+	return r.hmacUntil.IsZero() || r.now().Before(r.hmacUntil)
+}
+
+// JWKS returns the public part of every asymmetric key in the ring.
+func (r *KeyRing) JWKS() JWKSet {
+	kids := make([]string, 0, len(r.keys))
+	for kid := range r.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	set := JWKSet{Keys: make([]JWK, 0, len(kids))}
+	for _, kid := range kids {
+		key := r.keys[kid]
+		jwk := JWK{Kid: kid, Use: "sig", Alg: key.method.Alg()}
+		switch pub := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+func parseJWTKey(kid string, data []byte) (*jwtKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("invalid pem")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported pem type %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		return &jwtKey{kid: kid, method: jwt.SigningMethodRS256, private: k, public: &k.PublicKey}, nil
+	case *rsa.PublicKey:
+		return &jwtKey{kid: kid, method: jwt.SigningMethodRS256, public: k}, nil
+	case ed25519.PrivateKey:
+		return &jwtKey{kid: kid, method: jwt.SigningMethodEdDSA, private: k, public: k.Public()}, nil
+	case ed25519.PublicKey:
+		return &jwtKey{kid: kid, method: jwt.SigningMethodEdDSA, public: k}, nil
+	default:
+		return nil, errors.New("unsupported key type, expected RSA or Ed25519")
+	}
+}
@@ -0,0 +1,107 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"wavefy-be/config"
+)
+
+func writeEd25519Key(t *testing.T, dir, kid string) {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, kid+".pem"), data, 0o600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+}
+
+func legacyHS256Token(t *testing.T, cfg config.AuthConfig) string {
+	t.Helper()
+	claims := AccessTokenClaims{
+		Role: "user",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "user-1",
+			Issuer:    cfg.AccessTokenIss,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(cfg.JWTSecret))
+	if err != nil {
+		t.Fatalf("sign legacy token: %v", err)
+	}
+	return signed
+}
+
+func TestKeyRingLegacyHS256(t *testing.T) {
+	dir := t.TempDir()
+	writeEd25519Key(t, dir, "k1")
+	deadline := time.Now().Add(time.Hour)
+	base := config.AuthConfig{
+		JWTSecret:      "legacy-secret",
+		JWTKeysDir:     dir,
+		JWTActiveKID:   "k1",
+		AccessTokenIss: "wavefy-test",
+		AccessTokenTTL: time.Minute,
+	}
+
+	tests := []struct {
+		name   string
+		until  time.Time
+		now    time.Time
+		accept bool
+	}{
+		{name: "no deadline", accept: false},
+		{name: "before deadline", until: deadline, now: deadline.Add(-time.Minute), accept: true},
+		{name: "after deadline", until: deadline, now: deadline.Add(time.Minute), accept: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base
+			cfg.JWTLegacyHS256Until = tt.until
+			ring, err := LoadKeyRing(cfg)
+			if err != nil {
+				t.Fatalf("load key ring: %v", err)
+			}
+			if !tt.now.IsZero() {
+				now := tt.now
+				ring.now = func() time.Time { return now }
+			}
+
+			_, err = ParseAccessToken(cfg, ring, legacyHS256Token(t, cfg))
+			if tt.accept && err != nil {
+				t.Fatalf("legacy token refused: %v", err)
+			}
+			if !tt.accept && err == nil {
+				t.Fatal("legacy token accepted")
+			}
+
+			signed, _, err := IssueAccessToken(cfg, ring, "user-1", "user", nil)
+			if err != nil {
+				t.Fatalf("issue: %v", err)
+			}
+			parsed, _, err := jwt.NewParser().ParseUnverified(signed, &AccessTokenClaims{})
+			if err != nil {
+				t.Fatalf("parse issued token: %v", err)
+			}
+			if parsed.Method.Alg() != jwt.SigningMethodEdDSA.Alg() {
+				t.Fatalf("issued token signed with %s, want EdDSA", parsed.Method.Alg())
+			}
+		})
+	}
+}
@@ -17,6 +17,7 @@ const (
 
 type LoginAttemptStore interface {
 	IsLocked(ctx context.Context, email string) (bool, error)
+	Failures(ctx context.Context, email string) (int64, error)
 	RecordFailure(ctx context.Context, email string) (count int64, locked bool, err error)
 	Reset(ctx context.Context, email string) error
 }
@@ -31,6 +32,18 @@ type loginAttemptStore struct {
 }
 
 func NewLoginAttemptStore(client *redis.Client, attemptTTL, lockTTL time.Duration, maxAttempts int64) LoginAttemptStore {
+	attemptTTL, lockTTL, maxAttempts = loginAttemptLimits(attemptTTL, lockTTL, maxAttempts)
+	return &loginAttemptStore{
+		client:        client,
+		attemptTTL:    attemptTTL,
+		lockTTL:       lockTTL,
+		maxAttempts:   maxAttempts,
+		attemptPrefix: "login:mail:attempt:",
+		lockPrefix:    "login:mail:lock:",
+	}
+}
+
+func loginAttemptLimits(attemptTTL, lockTTL time.Duration, maxAttempts int64) (time.Duration, time.Duration, int64) {
 	if attemptTTL <= 0 {
 		attemptTTL = defaultLoginAttemptTTL
 	}
@@ -40,15 +53,7 @@ func NewLoginAttemptStore(client *redis.Client, attemptTTL, lockTTL time.Duratio
 	if maxAttempts <= 0 {
 		maxAttempts = defaultLoginMaxAttempt
 	}
-
-	return &loginAttemptStore{
-		client:        client,
-		attemptTTL:    attemptTTL,
-		lockTTL:       lockTTL,
-		maxAttempts:   maxAttempts,
-		attemptPrefix: "login:mail:attempt:",
-		lockPrefix:    "login:mail:lock:",
-	}
+	return attemptTTL, lockTTL, maxAttempts
 }
 
 func (s *loginAttemptStore) IsLocked(ctx context.Context, email string) (bool, error) {
@@ -67,6 +72,26 @@ func (s *loginAttemptStore) IsLocked(ctx context.Context, email string) (bool, e
 	return exists > 0, nil
 }
 
+// Failures returns the failed attempts counted in the current window.
+func (s *loginAttemptStore) Failures(ctx context.Context, email string) (int64, error) {
+	if s.client == nil {
+		return 0, nil
+	}
+	email = normalizeEmail(email)
+	if email == "" {
+		return 0, errors.New("invalid email")
+	}
+
+	count, err := s.client.Get(ctx, s.attemptKey(email)).Int64()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return count, nil
+}
+
 func (s *loginAttemptStore) RecordFailure(ctx context.Context, email string) (int64, bool, error) {
 	if s.client == nil {
 		return 0, false, nil
@@ -0,0 +1,82 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+type memoryLoginAttemptStore struct {
+	kv          *memoryKV
+	attemptTTL  time.Duration
+	lockTTL     time.Duration
+	maxAttempts int64
+}
+
+// NewMemoryLoginAttemptStore mirrors the Redis login attempt counters in
+// process memory, for a single instance and tests.
+func NewMemoryLoginAttemptStore(attemptTTL, lockTTL time.Duration, maxAttempts int64) LoginAttemptStore {
+	attemptTTL, lockTTL, maxAttempts = loginAttemptLimits(attemptTTL, lockTTL, maxAttempts)
+	return &memoryLoginAttemptStore{
+		kv:          newMemoryKV(),
+		attemptTTL:  attemptTTL,
+		lockTTL:     lockTTL,
+		maxAttempts: maxAttempts,
+	}
+}
+
+func (s *memoryLoginAttemptStore) IsLocked(ctx context.Context, email string) (bool, error) {
+	email = normalizeEmail(email)
+	if email == "" {
+		return false, errors.New("invalid email")
+	}
+	s.kv.mu.Lock()
+	defer s.kv.mu.Unlock()
+
+	_, locked := s.kv.get("lock:" + email)
+	return locked, nil
+}
+
+func (s *memoryLoginAttemptStore) Failures(ctx context.Context, email string) (int64, error) {
+	email = normalizeEmail(email)
+	if email == "" {
+		return 0, errors.New("invalid email")
+	}
+	s.kv.mu.Lock()
+	defer s.kv.mu.Unlock()
+
+	entry, ok := s.kv.get("attempt:" + email)
+	if !ok {
+		return 0, nil
+	}
+	return entry.count, nil
+}
+
+func (s *memoryLoginAttemptStore) RecordFailure(ctx context.Context, email string) (int64, bool, error) {
+	email = normalizeEmail(email)
+	if email == "" {
+		return 0, false, errors.New("invalid email")
+	}
+	s.kv.mu.Lock()
+	defer s.kv.mu.Unlock()
+
+	count := s.kv.incr("attempt:"+email, s.attemptTTL)
+	if count >= s.maxAttempts {
+		s.kv.set("lock:"+email, "1", s.lockTTL)
+		s.kv.del("attempt:" + email)
+		return count, true, nil
+	}
+	return count, false, nil
+}
+
+func (s *memoryLoginAttemptStore) Reset(ctx context.Context, email string) error {
+	email = normalizeEmail(email)
+	if email == "" {
+		return errors.New("invalid email")
+	}
+	s.kv.mu.Lock()
+	defer s.kv.mu.Unlock()
+
+	s.kv.del("attempt:"+email, "lock:"+email)
+	return nil
+}
@@ -0,0 +1,118 @@
+package token
+
+import (
+	"sync"
+	"time"
+)
+
+// memoryKVSweepEvery is how many writes pass between sweeps of expired
+// entries.
+const memoryKVSweepEvery = 256
+
+type memoryKVEntry struct {
+	value     string
+	count     int64
+	token     StoredToken
+	expiresAt time.Time
+}
+
+// memoryKV is a map whose entries expire, the building block of the
+// in-memory stores. Callers hold mu around every call so that compound
+// updates stay atomic, as the Redis stores get from scripts and SETNX.
+type memoryKV struct {
+	mu      sync.Mutex
+	entries map[string]*memoryKVEntry
+	writes  int
+	now     func() time.Time
+}
+
+func newMemoryKV() *memoryKV {
+	return &memoryKV{
+		entries: map[string]*memoryKVEntry{},
+		now:     time.Now,
+	}
+}
+
+// get returns a live entry, dropping it if it has expired.
+func (m *memoryKV) get(key string) (*memoryKVEntry, bool) {
+	entry, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !entry.expiresAt.IsZero() && !m.now().Before(entry.expiresAt) {
+		delete(m.entries, key)
+		return nil, false
+	}
+	return entry, true
+}
+
+// set stores value for ttl; a ttl of zero or less never expires.
+func (m *memoryKV) set(key, value string, ttl time.Duration) {
+	m.put(key, &memoryKVEntry{value: value, expiresAt: m.expiry(ttl)})
+}
+
+// put stores entry as is, replacing whatever key held.
+func (m *memoryKV) put(key string, entry *memoryKVEntry) {
+	m.write()
+	m.entries[key] = entry
+}
+
+// setNX stores value only when key is absent and reports whether it did.
+func (m *memoryKV) setNX(key, value string, ttl time.Duration) bool {
+	if _, ok := m.get(key); ok {
+		return false
+	}
+	m.set(key, value, ttl)
+	return true
+}
+
+// incr adds one to the counter at key. The first increment starts a window
+// of ttl that later increments do not extend.
+func (m *memoryKV) incr(key string, ttl time.Duration) int64 {
+	entry, ok := m.get(key)
+	if !ok {
+		entry = &memoryKVEntry{expiresAt: m.expiry(ttl)}
+		m.put(key, entry)
+	}
+	entry.count++
+	return entry.count
+}
+
+// ttl returns the time left on key, or zero when it is missing or does not
+// expire.
+func (m *memoryKV) ttl(key string) time.Duration {
+	entry, ok := m.get(key)
+	if !ok || entry.expiresAt.IsZero() {
+		return 0
+	}
+	return entry.expiresAt.Sub(m.now())
+}
+
+func (m *memoryKV) del(keys ...string) {
+	for _, key := range keys {
+		delete(m.entries, key)
+	}
+}
+
+func (m *memoryKV) expiry(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return m.now().Add(ttl)
+}
+
+// write sweeps expired entries every memoryKVSweepEvery writes so keys that
+// are never read again do not pile up.
+func (m *memoryKV) write() {
+	m.writes++
+	if m.writes < memoryKVSweepEvery {
+		return
+	}
+	m.writes = 0
+	now := m.now()
+	for key, entry := range m.entries {
+		if !entry.expiresAt.IsZero() && !now.Before(entry.expiresAt) {
+			delete(m.entries, key)
+		}
+	}
+}
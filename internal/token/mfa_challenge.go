@@ -0,0 +1,139 @@
+package token
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const defaultMFAChallengeMaxAttempts = int64(5)
+
+var errInvalidMFAChallenge = errors.New("invalid token")
+
+// MFAChallengeStore holds the short-lived token handed out by a password
+// login when the account has a second factor, plus the time steps of TOTP
+// codes already used so a code cannot be replayed.
+type MFAChallengeStore interface {
+	Create(ctx context.Context, userID string) (string, time.Time, error)
+	Verify(ctx context.Context, token string) (string, error)
+	RecordFailure(ctx context.Context, token string) (locked bool, err error)
+	Revoke(ctx context.Context, token string) error
+	MarkStepUsed(ctx context.Context, userID string, step int64) (bool, error)
+}
+
+type mfaChallengeStore struct {
+	client        *redis.Client
+	secret        []byte
+	ttl           time.Duration
+	maxAttempts   int64
+	prefix        string
+	attemptPrefix string
+	stepPrefix    string
+}
+
+func NewMFAChallengeStore(client *redis.Client, secret string, ttl time.Duration) MFAChallengeStore {
+	return &mfaChallengeStore{
+		client:        client,
+		secret:        []byte(secret),
+		ttl:           ttl,
+		maxAttempts:   defaultMFAChallengeMaxAttempts,
+		prefix:        "mfa_challenge:",
+		attemptPrefix: "mfa_challenge_attempt:",
+		stepPrefix:    "mfa:step:",
+	}
+}
+
+func (s *mfaChallengeStore) Create(ctx context.Context, userID string) (string, time.Time, error) {
+	if userID == "" {
+		return "", time.Time{}, errors.New("invalid user id")
+	}
+	token := uuid.NewString()
+	hash := s.sign(token, userID)
+	value := userID + ":" + hash
+
+	if err := s.client.Set(ctx, s.key(token), value, s.ttl).Err(); err != nil {
+		return "", time.Time{}, err
+	}
+	return token, time.Now().UTC().Add(s.ttl), nil
+}
+
+func (s *mfaChallengeStore) Verify(ctx context.Context, token string) (string, error) {
+	if !validTokenID(token) {
+		return "", errInvalidMFAChallenge
+	}
+	value, err := s.client.Get(ctx, s.key(token)).Result()
+	if err != nil {
+		return "", err
+	}
+	parts := strings.SplitN(value, ":", 2)
+	if len(parts) != 2 {
+		return "", errInvalidMFAChallenge
+	}
+	userID := parts[0]
+	hash := parts[1]
+	if !hmac.Equal([]byte(hash), []byte(s.sign(token, userID))) {
+		return "", errInvalidMFAChallenge
+	}
+	return userID, nil
+}
+
+func (s *mfaChallengeStore) RecordFailure(ctx context.Context, token string) (bool, error) {
+	if !validTokenID(token) {
+		return false, errInvalidMFAChallenge
+	}
+	key := s.attemptKey(token)
+	count, err := s.client.Incr(ctx, key).Result()
+	if err != nil {
+		return false, err
+	}
+	if count == 1 {
+		if err := s.client.Expire(ctx, key, s.ttl).Err(); err != nil {
+			return false, err
+		}
+	}
+	if count >= s.maxAttempts {
+		_ = s.Revoke(ctx, token)
+		return true, nil
+	}
+	return false, nil
+}
+
+func (s *mfaChallengeStore) Revoke(ctx context.Context, token string) error {
+	if !validTokenID(token) {
+		return nil
+	}
+	return s.client.Del(ctx, s.key(token), s.attemptKey(token)).Err()
+}
+
+func (s *mfaChallengeStore) MarkStepUsed(ctx context.Context, userID string, step int64) (bool, error) {
+	if userID == "" {
+		return false, errors.New("invalid user id")
+	}
+	key := s.stepPrefix + userID + ":" + strconv.FormatInt(step, 10)
+	// A step stays acceptable for at most three periods because of clock skew.
+	return s.client.SetNX(ctx, key, "1", 2*time.Minute).Result()
+}
+
+func (s *mfaChallengeStore) key(token string) string {
+	return s.prefix + token
+}
+
+func (s *mfaChallengeStore) attemptKey(token string) string {
+	return s.attemptPrefix + token
+}
+
+func (s *mfaChallengeStore) sign(token, userID string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(token))
+	mac.Write([]byte(":"))
+	mac.Write([]byte(userID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
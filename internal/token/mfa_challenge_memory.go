@@ -0,0 +1,88 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type memoryMFAChallengeStore struct {
+	kv          *memoryKV
+	ttl         time.Duration
+	maxAttempts int64
+}
+
+// NewMemoryMFAChallengeStore mirrors the Redis MFA challenges in process
+// memory, for a single instance and tests. Nothing leaves the process, so
+// challenges are not signed.
+func NewMemoryMFAChallengeStore(ttl time.Duration) MFAChallengeStore {
+	return &memoryMFAChallengeStore{
+		kv:          newMemoryKV(),
+		ttl:         ttl,
+		maxAttempts: defaultMFAChallengeMaxAttempts,
+	}
+}
+
+func (s *memoryMFAChallengeStore) Create(ctx context.Context, userID string) (string, time.Time, error) {
+	if userID == "" {
+		return "", time.Time{}, errors.New("invalid user id")
+	}
+	token := uuid.NewString()
+	s.kv.mu.Lock()
+	defer s.kv.mu.Unlock()
+
+	s.kv.set("challenge:"+token, userID, s.ttl)
+	return token, time.Now().UTC().Add(s.ttl), nil
+}
+
+func (s *memoryMFAChallengeStore) Verify(ctx context.Context, token string) (string, error) {
+	if !validTokenID(token) {
+		return "", errInvalidMFAChallenge
+	}
+	s.kv.mu.Lock()
+	defer s.kv.mu.Unlock()
+
+	entry, ok := s.kv.get("challenge:" + token)
+	if !ok {
+		return "", errInvalidMFAChallenge
+	}
+	return entry.value, nil
+}
+
+func (s *memoryMFAChallengeStore) RecordFailure(ctx context.Context, token string) (bool, error) {
+	if !validTokenID(token) {
+		return false, errInvalidMFAChallenge
+	}
+	s.kv.mu.Lock()
+	defer s.kv.mu.Unlock()
+
+	if s.kv.incr("attempt:"+token, s.ttl) >= s.maxAttempts {
+		s.kv.del("challenge:"+token, "attempt:"+token)
+		return true, nil
+	}
+	return false, nil
+}
+
+func (s *memoryMFAChallengeStore) Revoke(ctx context.Context, token string) error {
+	if !validTokenID(token) {
+		return nil
+	}
+	s.kv.mu.Lock()
+	defer s.kv.mu.Unlock()
+
+	s.kv.del("challenge:"+token, "attempt:"+token)
+	return nil
+}
+
+func (s *memoryMFAChallengeStore) MarkStepUsed(ctx context.Context, userID string, step int64) (bool, error) {
+	if userID == "" {
+		return false, errors.New("invalid user id")
+	}
+	s.kv.mu.Lock()
+	defer s.kv.mu.Unlock()
+
+	return s.kv.setNX("step:"+userID+":"+strconv.FormatInt(step, 10), "1", 2*time.Minute), nil
+}
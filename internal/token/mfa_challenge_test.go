@@ -0,0 +1,50 @@
+package token
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestMFAChallengeRejectsForeignTokens(t *testing.T) {
+	stores := map[string]MFAChallengeStore{
+		"memory": NewMemoryMFAChallengeStore(time.Minute),
+	}
+	if addr := os.Getenv("REDIS_TEST_ADDR"); addr != "" {
+		client := redis.NewClient(&redis.Options{Addr: addr})
+		t.Cleanup(func() { _ = client.Close() })
+		if err := client.FlushDB(context.Background()).Err(); err != nil {
+			t.Fatalf("flush redis: %v", err)
+		}
+		stores["redis"] = NewMFAChallengeStore(client, "test-secret", time.Minute)
+	}
+
+	ctx := context.Background()
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			challenge, _, err := store.Create(ctx, uuid.NewString())
+			if err != nil {
+				t.Fatalf("create: %v", err)
+			}
+			if _, err := store.RecordFailure(ctx, challenge); err != nil {
+				t.Fatalf("record failure: %v", err)
+			}
+
+			for _, forged := range []string{"", "attempt:" + challenge, "challenge:" + challenge, challenge + ":x", "  " + challenge} {
+				if _, err := store.Verify(ctx, forged); err == nil {
+					t.Errorf("verify accepted %q", forged)
+				}
+				if _, err := store.RecordFailure(ctx, forged); err == nil {
+					t.Errorf("record failure accepted %q", forged)
+				}
+			}
+			if _, err := store.Verify(ctx, challenge); err != nil {
+				t.Fatalf("verify: %v", err)
+			}
+		})
+	}
+}
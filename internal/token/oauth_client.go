@@ -0,0 +1,50 @@
+package token
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// OAuthClientSecretPrefix starts every partner app secret so secret scanners
+// can find leaked ones.
+const OAuthClientSecretPrefix = "wfs_"
+
+// GenerateOAuthClientID returns a random public identifier for a partner app.
+func GenerateOAuthClientID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// GenerateOAuthClientSecret returns a new client secret and the hash to store.
+func GenerateOAuthClientSecret() (secret, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	secret = OAuthClientSecretPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return secret, HashOAuthClientSecret(secret), nil
+}
+
+// HashOAuthClientSecret hashes a client secret. Secrets carry 256 random
+// bits, so a fast hash is enough.
+func HashOAuthClientSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyPKCE checks a code_verifier against the S256 code_challenge sent with
+// the authorization request (RFC 7636).
+func VerifyPKCE(verifier, challenge string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	computed := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) == 1
+}
@@ -0,0 +1,36 @@
+package token
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVerifyPKCE(t *testing.T) {
+	// The example of RFC 7636 appendix B.
+	const (
+		verifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+		challenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+	)
+
+	tests := []struct {
+		name      string
+		verifier  string
+		challenge string
+		want      bool
+	}{
+		{"matching verifier", verifier, challenge, true},
+		{"other verifier", strings.Repeat("a", 43), challenge, false},
+		{"plain challenge", verifier, verifier, false},
+		{"empty challenge", verifier, "", false},
+		{"verifier too short", verifier[:42], challenge, false},
+		{"verifier too long", strings.Repeat("a", 129), challenge, false},
+		{"empty verifier", "", challenge, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifyPKCE(tt.verifier, tt.challenge); got != tt.want {
+				t.Fatalf("VerifyPKCE = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package token
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultRateLimitWindow     = time.Minute
+	defaultRateLimitBackoff    = 30 * time.Second
+	defaultRateLimitMaxBackoff = 15 * time.Minute
+)
+
+// RateLimitKey is one counter checked by RateLimiter.Allow, e.g. the client
+// IP or the email a request targets.
+type RateLimitKey struct {
+	Name  string
+	Limit int
+}
+
+// RateLimitResult describes the most constrained key of a check. When the
+// request is refused RetryAfter says when to try again; otherwise Remaining
+// and Reset describe the key closest to its limit.
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// RateLimiter counts requests in a sliding window per key. A key that goes
+// over its limit is blocked for a backoff that doubles on every block, up to
+// the maximum, and decays once the key stays quiet. All keys of a request
+// are checked and counted atomically; a refused request is not counted.
+type RateLimiter interface {
+	Allow(ctx context.Context, keys ...RateLimitKey) (RateLimitResult, error)
+}
+
+type rateLimiter struct {
+	client     *redis.Client
+	prefix     string
+	window     time.Duration
+	backoff    time.Duration
+	maxBackoff time.Duration
+}
+
+func NewRateLimiter(client *redis.Client, prefix string, window, backoff, maxBackoff time.Duration) RateLimiter {
+	window, backoff, maxBackoff = rateLimitDurations(window, backoff, maxBackoff)
+	return &rateLimiter{
+		client:     client,
+		prefix:     prefix,
+		window:     window,
+		backoff:    backoff,
+		maxBackoff: maxBackoff,
+	}
+}
+
+func rateLimitDurations(window, backoff, maxBackoff time.Duration) (time.Duration, time.Duration, time.Duration) {
+	if window <= 0 {
+		window = defaultRateLimitWindow
+	}
+	if backoff <= 0 {
+		backoff = defaultRateLimitBackoff
+	}
+	if maxBackoff < backoff {
+		maxBackoff = defaultRateLimitMaxBackoff
+		if maxBackoff < backoff {
+			maxBackoff = backoff
+		}
+	}
+	return window, backoff, maxBackoff
+}
+
+// slidingWindowScript checks every key before counting any of them. Each key
+// has a sorted set of request times, a block marker and a strike counter.
+// KEYS: window, block, strikes for each key.
+// ARGV: now, window, backoff, max backoff (ms), member, then one limit per key.
+// Returns allowed, retry after, limit, remaining, reset (ms).
+var slidingWindowScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local backoff = tonumber(ARGV[3])
+local maxBackoff = tonumber(ARGV[4])
+local member = ARGV[5]
+local n = #KEYS / 3
+
+local limitOut, remainingOut, resetOut = 0, -1, 0
+for i = 1, n do
+  local windowKey, blockKey, strikeKey = KEYS[3*i-2], KEYS[3*i-1], KEYS[3*i]
+  local limit = tonumber(ARGV[5+i])
+
+  local blocked = redis.call("PTTL", blockKey)
+  if blocked > 0 then
+    return {0, blocked, limit, 0, blocked}
+  end
+
+  redis.call("ZREMRANGEBYSCORE", windowKey, "-inf", now - window)
+  local count = redis.call("ZCARD", windowKey)
+  local oldest = redis.call("ZRANGE", windowKey, 0, 0, "WITHSCORES")
+  local reset = window
+  if oldest[2] then
+    reset = tonumber(oldest[2]) + window - now
+  end
+
+  if count >= limit then
+    local strikes = redis.call("INCR", strikeKey)
+    redis.call("PEXPIRE", strikeKey, maxBackoff * 2)
+    local wait = backoff * math.pow(2, strikes - 1)
+    if wait > maxBackoff then
+      wait = maxBackoff
+    end
+    if wait < reset then
+      wait = reset
+    end
+    wait = math.floor(wait)
+    redis.call("SET", blockKey, "1", "PX", wait)
+    return {0, wait, limit, 0, wait}
+  end
+
+  local remaining = limit - count - 1
+  if remainingOut < 0 or remaining < remainingOut then
+    limitOut, remainingOut, resetOut = limit, remaining, reset
+  end
+end
+
+for i = 1, n do
+  redis.call("ZADD", KEYS[3*i-2], now, member)
+  redis.call("PEXPIRE", KEYS[3*i-2], window)
+end
+return {1, 0, limitOut, remainingOut, resetOut}
+`)
+
+func (l *rateLimiter) Allow(ctx context.Context, keys ...RateLimitKey) (RateLimitResult, error) {
+	if l.client == nil || len(keys) == 0 {
+		return RateLimitResult{Allowed: true}, nil
+	}
+
+	redisKeys := make([]string, 0, len(keys)*3)
+	args := []interface{}{
+		time.Now().UnixMilli(),
+		l.window.Milliseconds(),
+		l.backoff.Milliseconds(),
+		l.maxBackoff.Milliseconds(),
+		uuid.NewString(),
+	}
+	for _, key := range keys {
+		base := l.prefix + key.Name
+		redisKeys = append(redisKeys, base+":window", base+":block", base+":strikes")
+		args = append(args, strconv.Itoa(key.Limit))
+	}
+
+	values, err := slidingWindowScript.Run(ctx, l.client, redisKeys, args...).Int64Slice()
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	return RateLimitResult{
+		Allowed:    values[0] == 1,
+		RetryAfter: time.Duration(values[1]) * time.Millisecond,
+		Limit:      int(values[2]),
+		Remaining:  int(values[3]),
+		Reset:      time.Duration(values[4]) * time.Millisecond,
+	}, nil
+}
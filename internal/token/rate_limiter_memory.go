@@ -0,0 +1,124 @@
+package token
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryRateLimitState struct {
+	hits         []time.Time
+	blockedUntil time.Time
+	strikes      int
+	strikesUntil time.Time
+}
+
+type memoryRateLimiter struct {
+	mu         sync.Mutex
+	states     map[string]*memoryRateLimitState
+	calls      int
+	window     time.Duration
+	backoff    time.Duration
+	maxBackoff time.Duration
+	now        func() time.Time
+}
+
+// NewMemoryRateLimiter applies the same sliding window and backoff as the
+// Redis limiter in process memory, for a single instance and tests.
+func NewMemoryRateLimiter(window, backoff, maxBackoff time.Duration) RateLimiter {
+	window, backoff, maxBackoff = rateLimitDurations(window, backoff, maxBackoff)
+	return &memoryRateLimiter{
+		states:     map[string]*memoryRateLimitState{},
+		window:     window,
+		backoff:    backoff,
+		maxBackoff: maxBackoff,
+		now:        time.Now,
+	}
+}
+
+func (l *memoryRateLimiter) Allow(ctx context.Context, keys ...RateLimitKey) (RateLimitResult, error) {
+	if len(keys) == 0 {
+		return RateLimitResult{Allowed: true}, nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+	result := RateLimitResult{Allowed: true, Remaining: -1}
+	states := make([]*memoryRateLimitState, len(keys))
+	for i, key := range keys {
+		state, ok := l.states[key.Name]
+		if !ok {
+			state = &memoryRateLimitState{}
+			l.states[key.Name] = state
+		}
+		states[i] = state
+
+		if now.Before(state.blockedUntil) {
+			wait := state.blockedUntil.Sub(now)
+			return RateLimitResult{Limit: key.Limit, RetryAfter: wait, Reset: wait}, nil
+		}
+
+		state.hits = liveHits(state.hits, now.Add(-l.window))
+		reset := l.window
+		if len(state.hits) > 0 {
+			reset = state.hits[0].Add(l.window).Sub(now)
+		}
+
+		if len(state.hits) >= key.Limit {
+			if !now.Before(state.strikesUntil) {
+				state.strikes = 0
+			}
+			state.strikes++
+			state.strikesUntil = now.Add(2 * l.maxBackoff)
+			wait := l.backoff
+			for i := 1; i < state.strikes && wait < l.maxBackoff; i++ {
+				wait *= 2
+			}
+			if wait > l.maxBackoff {
+				wait = l.maxBackoff
+			}
+			if wait < reset {
+				wait = reset
+			}
+			state.blockedUntil = now.Add(wait)
+			return RateLimitResult{Limit: key.Limit, RetryAfter: wait, Reset: wait}, nil
+		}
+
+		remaining := key.Limit - len(state.hits) - 1
+		if result.Remaining < 0 || remaining < result.Remaining {
+			result.Limit, result.Remaining, result.Reset = key.Limit, remaining, reset
+		}
+	}
+
+	for _, state := range states {
+		state.hits = append(state.hits, now)
+	}
+	return result, nil
+}
+
+// sweep drops idle keys every memoryKVSweepEvery calls. The caller holds the
+// lock.
+func (l *memoryRateLimiter) sweep(now time.Time) {
+	l.calls++
+	if l.calls < memoryKVSweepEvery {
+		return
+	}
+	l.calls = 0
+	for name, state := range l.states {
+		state.hits = liveHits(state.hits, now.Add(-l.window))
+		if len(state.hits) == 0 && !now.Before(state.blockedUntil) && !now.Before(state.strikesUntil) {
+			delete(l.states, name)
+		}
+	}
+}
+
+// liveHits drops the hits at or before since; hits are in time order.
+func liveHits(hits []time.Time, since time.Time) []time.Time {
+	i := 0
+	for i < len(hits) && !hits[i].After(since) {
+		i++
+	}
+	return hits[i:]
+}
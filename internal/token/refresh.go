@@ -6,6 +6,8 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -13,77 +15,346 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
+var (
+	// ErrRefreshTokenReused is returned by Rotate when a token that was already
+	// rotated is presented again. The whole family is revoked before returning
+	// and the owning user id is still reported so the caller can log it.
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+	ErrSessionNotFound    = errors.New("session not found")
+
+	errInvalidTokenID = errors.New("invalid token")
+)
+
+// validTokenID reports whether token is a canonical UUID, the shape of the
+// refresh tokens and MFA challenges this package hands out. It is checked
+// before any lookup so a client cannot reach another key by sending a token
+// with a prefix of its own.
+func validTokenID(token string) bool {
+	parsed, err := uuid.Parse(token)
+	return err == nil && parsed.String() == token
+}
+
+// SessionMeta describes the client a refresh token family was issued to.
+type SessionMeta struct {
+	DeviceName string
+	UserAgent  string
+	IP         string
+}
+
+// Session is one refresh token family as seen by its owner.
+type Session struct {
+	ID         string
+	UserID     string
+	DeviceName string
+	UserAgent  string
+	IP         string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+}
+
 type RefreshTokenStore interface {
-	Create(ctx context.Context, userID string) (string, error)
+	Create(ctx context.Context, userID string, meta SessionMeta) (string, error)
 	Verify(ctx context.Context, token string) (string, error)
+	Rotate(ctx context.Context, token string, meta SessionMeta) (userID string, newToken string, err error)
 	Revoke(ctx context.Context, token string) error
+	ListSessions(ctx context.Context, userID string) ([]Session, error)
+	RevokeSession(ctx context.Context, userID, sessionID string) error
+	RevokeAll(ctx context.Context, userID string) error
 }
 
+// refreshStore keeps refresh tokens grouped into families. Every login starts
+// a new family, which is what the user sees as a session; rotating a token
+// keeps the family and marks the old token as used so a replay of it can be
+// detected and the family revoked.
+//
+// Keys:
+//
+//	refresh:<token>          -> userID:familyID:hmac
+//	refresh_used:<token>     -> userID:familyID (kept for the token lifetime)
+//	refresh_family:<family>  -> hash with the current token and session metadata
+//	refresh_user:<userID>    -> set of family ids owned by the user
 type refreshStore struct {
-	client *redis.Client
-	secret []byte
-	ttl    time.Duration
-	prefix string
+	client       *redis.Client
+	secret       []byte
+	ttl          time.Duration
+	prefix       string
+	usedPrefix   string
+	familyPrefix string
+	userPrefix   string
 }
 
 func NewRefreshTokenStore(client *redis.Client, secret string, ttl time.Duration) RefreshTokenStore {
 	return &refreshStore{
-		client: client,
-		secret: []byte(secret),
-		ttl:    ttl,
-		prefix: "refresh:",
+		client:       client,
+		secret:       []byte(secret),
+		ttl:          ttl,
+		prefix:       "refresh:",
+		usedPrefix:   "refresh_used:",
+		familyPrefix: "refresh_family:",
+		userPrefix:   "refresh_user:",
 	}
 }
 
-func (s *refreshStore) Create(ctx context.Context, userID string) (string, error) {
+func (s *refreshStore) Create(ctx context.Context, userID string, meta SessionMeta) (string, error) {
 	if userID == "" {
 		return "", errors.New("invalid user id")
 	}
-	token := uuid.NewString()
-	hash := s.sign(token, userID)
-	value := userID + ":" + hash
-
-	if err := s.client.Set(ctx, s.key(token), value, s.ttl).Err(); err != nil {
-		return "", err
+	familyID := uuid.NewString()
+	now := strconv.FormatInt(time.Now().UTC().Unix(), 10)
+	fields := map[string]interface{}{
+		"user_id":      userID,
+		"device_name":  meta.DeviceName,
+		"user_agent":   meta.UserAgent,
+		"ip":           meta.IP,
+		"created_at":   now,
+		"last_used_at": now,
 	}
-	return token, nil
+	return s.issue(ctx, userID, familyID, fields)
 }
 
 func (s *refreshStore) Verify(ctx context.Context, token string) (string, error) {
-	if token == "" {
-		return "", errors.New("invalid token")
+	if !validTokenID(token) {
+		return "", errInvalidTokenID
 	}
 	value, err := s.client.Get(ctx, s.key(token)).Result()
 	if err != nil {
 		return "", err
 	}
-	parts := strings.SplitN(value, ":", 2)
-	if len(parts) != 2 {
-		return "", errors.New("invalid token")
-	}
-	userID := parts[0]
-	hash := parts[1]
-	if !hmac.Equal([]byte(hash), []byte(s.sign(token, userID))) {
-		return "", errors.New("invalid token")
+	userID, _, err := s.parse(token, value)
+	if err != nil {
+		return "", err
 	}
 	return userID, nil
 }
 
+func (s *refreshStore) Rotate(ctx context.Context, token string, meta SessionMeta) (string, string, error) {
+	if !validTokenID(token) {
+		return "", "", errInvalidTokenID
+	}
+
+	// GETDEL makes the rotation atomic: two concurrent requests with the same
+	// token cannot both obtain a successor.
+	value, err := s.client.GetDel(ctx, s.key(token)).Result()
+	if errors.Is(err, redis.Nil) {
+		used, usedErr := s.client.Get(ctx, s.usedKey(token)).Result()
+		if usedErr != nil {
+			if errors.Is(usedErr, redis.Nil) {
+				return "", "", errInvalidTokenID
+			}
+			return "", "", usedErr
+		}
+		userID, familyID, ok := strings.Cut(used, ":")
+		if !ok {
+			return "", "", errInvalidTokenID
+		}
+		if err := s.revokeFamily(ctx, userID, familyID); err != nil {
+			return "", "", err
+		}
+		return userID, "", ErrRefreshTokenReused
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	userID, familyID, err := s.parse(token, value)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := s.client.Set(ctx, s.usedKey(token), userID+":"+familyID, s.ttl).Err(); err != nil {
+		return "", "", err
+	}
+
+	fields := map[string]interface{}{
+		"last_used_at": strconv.FormatInt(time.Now().UTC().Unix(), 10),
+	}
+	if meta.UserAgent != "" {
+		fields["user_agent"] = meta.UserAgent
+	}
+	if meta.IP != "" {
+		fields["ip"] = meta.IP
+	}
+	newToken, err := s.issue(ctx, userID, familyID, fields)
+	if err != nil {
+		return "", "", err
+	}
+	return userID, newToken, nil
+}
+
 func (s *refreshStore) Revoke(ctx context.Context, token string) error {
-	if token == "" {
+	if !validTokenID(token) {
+		return nil
+	}
+	value, err := s.client.Get(ctx, s.key(token)).Result()
+	if errors.Is(err, redis.Nil) {
 		return nil
 	}
+	if err != nil {
+		return err
+	}
+	if userID, familyID, parseErr := s.parse(token, value); parseErr == nil {
+		return s.revokeFamily(ctx, userID, familyID)
+	}
 	return s.client.Del(ctx, s.key(token)).Err()
 }
 
+func (s *refreshStore) ListSessions(ctx context.Context, userID string) ([]Session, error) {
+	if userID == "" {
+		return nil, errors.New("invalid user id")
+	}
+	familyIDs, err := s.client.SMembers(ctx, s.userKey(userID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]Session, 0, len(familyIDs))
+	var stale []interface{}
+	for _, familyID := range familyIDs {
+		fields, err := s.client.HGetAll(ctx, s.familyKey(familyID)).Result()
+		if err != nil {
+			return nil, err
+		}
+		if len(fields) == 0 || fields["user_id"] != userID {
+			stale = append(stale, familyID)
+			continue
+		}
+		sessions = append(sessions, Session{
+			ID:         familyID,
+			UserID:     userID,
+			DeviceName: fields["device_name"],
+			UserAgent:  fields["user_agent"],
+			IP:         fields["ip"],
+			CreatedAt:  parseUnix(fields["created_at"]),
+			LastUsedAt: parseUnix(fields["last_used_at"]),
+		})
+	}
+	if len(stale) > 0 {
+		_ = s.client.SRem(ctx, s.userKey(userID), stale...).Err()
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt)
+	})
+	return sessions, nil
+}
+
+func (s *refreshStore) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	if userID == "" || sessionID == "" {
+		return ErrSessionNotFound
+	}
+	owner, err := s.client.HGet(ctx, s.familyKey(sessionID), "user_id").Result()
+	if errors.Is(err, redis.Nil) {
+		return ErrSessionNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if owner != userID {
+		return ErrSessionNotFound
+	}
+	return s.revokeFamily(ctx, userID, sessionID)
+}
+
+func (s *refreshStore) RevokeAll(ctx context.Context, userID string) error {
+	if userID == "" {
+		return errors.New("invalid user id")
+	}
+	familyIDs, err := s.client.SMembers(ctx, s.userKey(userID)).Result()
+	if err != nil {
+		return err
+	}
+	for _, familyID := range familyIDs {
+		if err := s.revokeFamily(ctx, userID, familyID); err != nil {
+			return err
+		}
+	}
+	return s.client.Del(ctx, s.userKey(userID)).Err()
+}
+
+func (s *refreshStore) revokeFamily(ctx context.Context, userID, familyID string) error {
+	if familyID == "" {
+		return nil
+	}
+	current, err := s.client.HGet(ctx, s.familyKey(familyID), "token").Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+	keys := []string{s.familyKey(familyID)}
+	if current != "" {
+		keys = append(keys, s.key(current))
+	}
+
+	pipe := s.client.TxPipeline()
+	pipe.Del(ctx, keys...)
+	if userID != "" {
+		pipe.SRem(ctx, s.userKey(userID), familyID)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (s *refreshStore) issue(ctx context.Context, userID, familyID string, fields map[string]interface{}) (string, error) {
+	token := uuid.NewString()
+	hash := s.sign(token, userID, familyID)
+	value := userID + ":" + familyID + ":" + hash
+	fields["token"] = token
+
+	pipe := s.client.TxPipeline()
+	pipe.Set(ctx, s.key(token), value, s.ttl)
+	pipe.HSet(ctx, s.familyKey(familyID), fields)
+	pipe.Expire(ctx, s.familyKey(familyID), s.ttl)
+	pipe.SAdd(ctx, s.userKey(userID), familyID)
+	pipe.Expire(ctx, s.userKey(userID), s.ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (s *refreshStore) parse(token, value string) (string, string, error) {
+	parts := strings.SplitN(value, ":", 3)
+	if len(parts) != 3 {
+		return "", "", errInvalidTokenID
+	}
+	userID := parts[0]
+	familyID := parts[1]
+	hash := parts[2]
+	if !hmac.Equal([]byte(hash), []byte(s.sign(token, userID, familyID))) {
+		return "", "", errInvalidTokenID
+	}
+	return userID, familyID, nil
+}
+
 func (s *refreshStore) key(token string) string {
 	return s.prefix + token
 }
 
-func (s *refreshStore) sign(token, userID string) string {
+func (s *refreshStore) usedKey(token string) string {
+	return s.usedPrefix + token
+}
+
+func (s *refreshStore) familyKey(familyID string) string {
+	return s.familyPrefix + familyID
+}
+
+func (s *refreshStore) userKey(userID string) string {
+	return s.userPrefix + userID
+}
+
+func (s *refreshStore) sign(token, userID, familyID string) string {
 	mac := hmac.New(sha256.New, s.secret)
 	mac.Write([]byte(token))
 	mac.Write([]byte(":"))
 	mac.Write([]byte(userID))
+	mac.Write([]byte(":"))
+	mac.Write([]byte(familyID))
 	return hex.EncodeToString(mac.Sum(nil))
 }
+
+func parseUnix(value string) time.Time {
+	sec, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0).UTC()
+}
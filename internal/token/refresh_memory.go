@@ -0,0 +1,211 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type memoryRefreshToken struct {
+	userID    string
+	familyID  string
+	expiresAt time.Time
+}
+
+type memoryRefreshFamily struct {
+	session   Session
+	token     string
+	expiresAt time.Time
+}
+
+// memoryRefreshStore mirrors refreshStore in process memory, including reuse
+// detection, for local development and tests.
+type memoryRefreshStore struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	tokens   map[string]memoryRefreshToken
+	used     map[string]memoryRefreshToken
+	families map[string]*memoryRefreshFamily
+	now      func() time.Time
+}
+
+func NewMemoryRefreshTokenStore(ttl time.Duration) RefreshTokenStore {
+	return &memoryRefreshStore{
+		ttl:      ttl,
+		tokens:   map[string]memoryRefreshToken{},
+		used:     map[string]memoryRefreshToken{},
+		families: map[string]*memoryRefreshFamily{},
+		now:      time.Now,
+	}
+}
+
+func (s *memoryRefreshStore) Create(ctx context.Context, userID string, meta SessionMeta) (string, error) {
+	if userID == "" {
+		return "", errors.New("invalid user id")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep()
+	now := s.now().UTC().Truncate(time.Second)
+	family := &memoryRefreshFamily{session: Session{
+		ID:         uuid.NewString(),
+		UserID:     userID,
+		DeviceName: meta.DeviceName,
+		UserAgent:  meta.UserAgent,
+		IP:         meta.IP,
+		CreatedAt:  now,
+		LastUsedAt: now,
+	}}
+	s.families[family.session.ID] = family
+	return s.issue(family), nil
+}
+
+func (s *memoryRefreshStore) Verify(ctx context.Context, token string) (string, error) {
+	if !validTokenID(token) {
+		return "", errInvalidTokenID
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.tokens[token]
+	if !ok || !s.now().Before(entry.expiresAt) {
+		return "", errInvalidTokenID
+	}
+	return entry.userID, nil
+}
+
+func (s *memoryRefreshStore) Rotate(ctx context.Context, token string, meta SessionMeta) (string, string, error) {
+	if !validTokenID(token) {
+		return "", "", errInvalidTokenID
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	entry, ok := s.tokens[token]
+	if !ok || !now.Before(entry.expiresAt) {
+		used, wasUsed := s.used[token]
+		if !wasUsed || !now.Before(used.expiresAt) {
+			return "", "", errInvalidTokenID
+		}
+		s.revokeFamily(used.familyID)
+		return used.userID, "", ErrRefreshTokenReused
+	}
+
+	delete(s.tokens, token)
+	s.used[token] = memoryRefreshToken{userID: entry.userID, familyID: entry.familyID, expiresAt: now.Add(s.ttl)}
+
+	family, ok := s.families[entry.familyID]
+	if !ok {
+		return "", "", errInvalidTokenID
+	}
+	family.session.LastUsedAt = now.UTC().Truncate(time.Second)
+	if meta.UserAgent != "" {
+		family.session.UserAgent = meta.UserAgent
+	}
+	if meta.IP != "" {
+		family.session.IP = meta.IP
+	}
+	return entry.userID, s.issue(family), nil
+}
+
+func (s *memoryRefreshStore) Revoke(ctx context.Context, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.tokens[token]; ok {
+		s.revokeFamily(entry.familyID)
+	}
+	return nil
+}
+
+func (s *memoryRefreshStore) ListSessions(ctx context.Context, userID string) ([]Session, error) {
+	if userID == "" {
+		return nil, errors.New("invalid user id")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep()
+	var sessions []Session
+	for _, family := range s.families {
+		if family.session.UserID == userID {
+			sessions = append(sessions, family.session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt)
+	})
+	return sessions, nil
+}
+
+func (s *memoryRefreshStore) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	family, ok := s.families[sessionID]
+	if !ok || userID == "" || family.session.UserID != userID {
+		return ErrSessionNotFound
+	}
+	s.revokeFamily(sessionID)
+	return nil
+}
+
+func (s *memoryRefreshStore) RevokeAll(ctx context.Context, userID string) error {
+	if userID == "" {
+		return errors.New("invalid user id")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for familyID, family := range s.families {
+		if family.session.UserID == userID {
+			s.revokeFamily(familyID)
+		}
+	}
+	return nil
+}
+
+// issue replaces the current token of a family. The caller holds the lock.
+func (s *memoryRefreshStore) issue(family *memoryRefreshFamily) string {
+	token := uuid.NewString()
+	family.token = token
+	family.expiresAt = s.now().Add(s.ttl)
+	s.tokens[token] = memoryRefreshToken{
+		userID:    family.session.UserID,
+		familyID:  family.session.ID,
+		expiresAt: family.expiresAt,
+	}
+	return token
+}
+
+func (s *memoryRefreshStore) revokeFamily(familyID string) {
+	if family, ok := s.families[familyID]; ok {
+		delete(s.tokens, family.token)
+		delete(s.families, familyID)
+	}
+}
+
+func (s *memoryRefreshStore) sweep() {
+	now := s.now()
+	for token, entry := range s.tokens {
+		if !now.Before(entry.expiresAt) {
+			delete(s.tokens, token)
+		}
+	}
+	for token, entry := range s.used {
+		if !now.Before(entry.expiresAt) {
+			delete(s.used, token)
+		}
+	}
+	for familyID, family := range s.families {
+		if !now.Before(family.expiresAt) {
+			delete(s.families, familyID)
+		}
+	}
+}
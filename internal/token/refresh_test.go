@@ -0,0 +1,109 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// refreshStores returns the stores to run the shared refresh tests against.
+// The Redis store is included when REDIS_TEST_ADDR points at a server that
+// may be flushed.
+func refreshStores(t *testing.T) map[string]RefreshTokenStore {
+	t.Helper()
+	stores := map[string]RefreshTokenStore{
+		"memory": NewMemoryRefreshTokenStore(time.Hour),
+	}
+	if addr := os.Getenv("REDIS_TEST_ADDR"); addr != "" {
+		client := redis.NewClient(&redis.Options{Addr: addr})
+		t.Cleanup(func() { _ = client.Close() })
+		if err := client.FlushDB(context.Background()).Err(); err != nil {
+			t.Fatalf("flush redis: %v", err)
+		}
+		stores["redis"] = NewRefreshTokenStore(client, "test-secret", time.Hour)
+	}
+	return stores
+}
+
+func TestRefreshTokenReplayRevokesFamily(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range refreshStores(t) {
+		t.Run(name, func(t *testing.T) {
+			userID := uuid.NewString()
+			first, err := store.Create(ctx, userID, SessionMeta{DeviceName: "laptop"})
+			if err != nil {
+				t.Fatalf("create: %v", err)
+			}
+			other, err := store.Create(ctx, userID, SessionMeta{DeviceName: "phone"})
+			if err != nil {
+				t.Fatalf("create other: %v", err)
+			}
+
+			gotUser, second, err := store.Rotate(ctx, first, SessionMeta{})
+			if err != nil {
+				t.Fatalf("rotate: %v", err)
+			}
+			if gotUser != userID || second == "" || second == first {
+				t.Fatalf("rotate returned user %q token %q", gotUser, second)
+			}
+
+			gotUser, _, err = store.Rotate(ctx, first, SessionMeta{})
+			if !errors.Is(err, ErrRefreshTokenReused) {
+				t.Fatalf("replay: got %v, want ErrRefreshTokenReused", err)
+			}
+			if gotUser != userID {
+				t.Fatalf("replay reported user %q, want %q", gotUser, userID)
+			}
+
+			if _, err := store.Verify(ctx, second); err == nil {
+				t.Fatal("successor token still valid after replay")
+			}
+			if _, _, err := store.Rotate(ctx, second, SessionMeta{}); err == nil {
+				t.Fatal("successor token still rotates after replay")
+			}
+			if _, err := store.Verify(ctx, other); err != nil {
+				t.Fatalf("other session revoked by replay: %v", err)
+			}
+			sessions, err := store.ListSessions(ctx, userID)
+			if err != nil {
+				t.Fatalf("list sessions: %v", err)
+			}
+			if len(sessions) != 1 || sessions[0].DeviceName != "phone" {
+				t.Fatalf("sessions after replay = %+v, want only the phone", sessions)
+			}
+		})
+	}
+}
+
+// A token that is not a UUID must be refused before Redis is reached, so a
+// client cannot address the reuse markers or session keys.
+func TestRefreshTokenRejectsMalformedToken(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	defer client.Close()
+	store := NewRefreshTokenStore(client, "test-secret", time.Hour)
+	ctx := context.Background()
+
+	for _, token := range []string{
+		"",
+		"used:" + uuid.NewString(),
+		"family:" + uuid.NewString(),
+		"user:" + uuid.NewString(),
+		uuid.NewString() + ":x",
+		"urn:uuid:" + uuid.NewString(),
+	} {
+		if _, err := store.Verify(ctx, token); !errors.Is(err, errInvalidTokenID) {
+			t.Errorf("Verify(%q) = %v, want invalid token", token, err)
+		}
+		if _, _, err := store.Rotate(ctx, token, SessionMeta{}); !errors.Is(err, errInvalidTokenID) {
+			t.Errorf("Rotate(%q) = %v, want invalid token", token, err)
+		}
+		if err := store.Revoke(ctx, token); err != nil {
+			t.Errorf("Revoke(%q) = %v, want nil", token, err)
+		}
+	}
+}
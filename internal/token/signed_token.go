@@ -0,0 +1,208 @@
+package token
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	PurposePasswordReset = "pwdreset"
+	PurposeVerifyEmail   = "verify"
+	PurposeMagicLink     = "magiclink"
+	PurposeEmailChange   = "email_change"
+	PurposeEmailRevert   = "email_revert"
+	PurposeDeviceReport  = "device_report"
+	PurposeOAuthCode     = "oauth_code"
+	PurposeOAuthRefresh  = "oauth_refresh"
+	PurposeReauth        = "reauth"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+// TokenPolicy bounds how a signed token can be used. MaxUses is the number of
+// successful Consume calls before the token is deleted, zero meaning it only
+// expires. MaxAttempts is the number of wrong secrets presented for a token
+// id before it is burned, zero meaning no limit.
+type TokenPolicy struct {
+	TTL         time.Duration
+	MaxUses     int64
+	MaxAttempts int64
+}
+
+// SignedToken is what a valid token resolves to.
+type SignedToken struct {
+	Subject   string
+	Metadata  map[string]string
+	Uses      int64
+	ExpiresAt time.Time
+}
+
+// SignedTokenStore hands out opaque tokens for one purpose, such as password
+// reset links. A token is "<id>.<secret>": the id locates the record and only
+// an HMAC of the secret is stored, so the backend contents cannot be replayed.
+type SignedTokenStore interface {
+	Issue(ctx context.Context, subject string, metadata map[string]string) (string, time.Time, error)
+	Verify(ctx context.Context, token string) (*SignedToken, error)
+	Consume(ctx context.Context, token string) (*SignedToken, error)
+	Revoke(ctx context.Context, token string) error
+}
+
+type signedTokenStore struct {
+	backend TokenBackend
+	purpose string
+	secret  []byte
+	policy  TokenPolicy
+	prefix  string
+}
+
+func NewSignedTokenStore(backend TokenBackend, purpose, secret string, policy TokenPolicy) SignedTokenStore {
+	return &signedTokenStore{
+		backend: backend,
+		purpose: purpose,
+		secret:  []byte(secret),
+		policy:  policy,
+		prefix:  "token:" + purpose + ":",
+	}
+}
+
+func (s *signedTokenStore) Issue(ctx context.Context, subject string, metadata map[string]string) (string, time.Time, error) {
+	if subject == "" {
+		return "", time.Time{}, errors.New("invalid subject")
+	}
+	id := uuid.NewString()
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", time.Time{}, err
+	}
+	secret := base64.RawURLEncoding.EncodeToString(raw)
+
+	expiresAt := time.Now().UTC().Add(s.policy.TTL)
+	record := StoredToken{
+		Subject:   subject,
+		MAC:       s.sign(id, secret, subject, metadata),
+		Metadata:  metadata,
+		ExpiresAt: expiresAt,
+	}
+	if err := s.backend.Save(ctx, s.key(id), record, s.policy.TTL); err != nil {
+		return "", time.Time{}, err
+	}
+	return id + "." + secret, expiresAt, nil
+}
+
+func (s *signedTokenStore) Verify(ctx context.Context, token string) (*SignedToken, error) {
+	_, record, err := s.load(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	return toSignedToken(record, record.Uses), nil
+}
+
+func (s *signedTokenStore) Consume(ctx context.Context, token string) (*SignedToken, error) {
+	id, record, err := s.load(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	uses, err := s.backend.Increment(ctx, s.key(id), CounterUses)
+	if err != nil {
+		if errors.Is(err, ErrTokenNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+	if s.policy.MaxUses > 0 {
+		if uses > s.policy.MaxUses {
+			return nil, ErrInvalidToken
+		}
+		if uses == s.policy.MaxUses {
+			if err := s.backend.Delete(ctx, s.key(id)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return toSignedToken(record, uses), nil
+}
+
+func (s *signedTokenStore) Revoke(ctx context.Context, token string) error {
+	id, _, ok := strings.Cut(token, ".")
+	if !ok || id == "" {
+		return nil
+	}
+	return s.backend.Delete(ctx, s.key(id))
+}
+
+// load resolves a token and checks its secret. A wrong secret for an existing
+// id counts as an attempt against that token.
+func (s *signedTokenStore) load(ctx context.Context, token string) (string, *StoredToken, error) {
+	id, secret, ok := strings.Cut(strings.TrimSpace(token), ".")
+	if !ok || id == "" || secret == "" {
+		return "", nil, ErrInvalidToken
+	}
+	record, err := s.backend.Load(ctx, s.key(id))
+	if err != nil {
+		if errors.Is(err, ErrTokenNotFound) {
+			return "", nil, ErrInvalidToken
+		}
+		return "", nil, err
+	}
+	if !hmac.Equal([]byte(record.MAC), []byte(s.sign(id, secret, record.Subject, record.Metadata))) {
+		if s.policy.MaxAttempts > 0 {
+			attempts, err := s.backend.Increment(ctx, s.key(id), CounterAttempts)
+			if err == nil && attempts >= s.policy.MaxAttempts {
+				_ = s.backend.Delete(ctx, s.key(id))
+			}
+		}
+		return "", nil, ErrInvalidToken
+	}
+	if s.policy.MaxUses > 0 && record.Uses >= s.policy.MaxUses {
+		return "", nil, ErrInvalidToken
+	}
+	return id, record, nil
+}
+
+func (s *signedTokenStore) key(id string) string {
+	return s.prefix + id
+}
+
+// sign binds the secret to the purpose, id, subject and metadata so none of
+// them can be swapped in the backend.
+func (s *signedTokenStore) sign(id, secret, subject string, metadata map[string]string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(s.purpose))
+	mac.Write([]byte(":"))
+	mac.Write([]byte(id))
+	mac.Write([]byte(":"))
+	mac.Write([]byte(secret))
+	mac.Write([]byte(":"))
+	mac.Write([]byte(subject))
+	keys := make([]string, 0, len(metadata))
+	for k := range metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		mac.Write([]byte("\x00"))
+		mac.Write([]byte(k))
+		mac.Write([]byte("="))
+		mac.Write([]byte(metadata[k]))
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func toSignedToken(record *StoredToken, uses int64) *SignedToken {
+	return &SignedToken{
+		Subject:   record.Subject,
+		Metadata:  record.Metadata,
+		Uses:      uses,
+		ExpiresAt: record.ExpiresAt,
+	}
+}
@@ -0,0 +1,74 @@
+package token
+
+import (
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"wavefy-be/config"
+)
+
+// A login email is locked for loginLockTTL after loginMaxAttempts failures
+// within loginAttemptTTL.
+const (
+	loginAttemptTTL  = 10 * time.Minute
+	loginLockTTL     = 15 * time.Minute
+	loginMaxAttempts = 10
+)
+
+// Stores holds the short-lived auth state: sessions, one-time tokens,
+// revocations and the limiters. One set is built per process and shared by
+// the HTTP server and the background jobs, so they all see the same data
+// whichever backend holds it.
+type Stores struct {
+	Refresh          RefreshTokenStore
+	Tokens           TokenBackend
+	Revocations      AccessTokenRevocationStore
+	Statuses         AccountStatusStore
+	LoginAttempts    LoginAttemptStore
+	VerifyResend     VerifyEmailResendLimiter
+	MFAChallenges    MFAChallengeStore
+	WebAuthnSessions WebAuthnSessionStore
+	DataExports      DataExportLimiter
+	LoginRateLimit   RateLimiter
+	Requests         WindowCounter
+	ClientAttempts   WindowCounter
+}
+
+// NewRedisStores keeps the auth state in Redis, shared by every instance.
+func NewRedisStores(client *redis.Client, cfg config.AuthConfig) *Stores {
+	return &Stores{
+		Refresh:          NewRefreshTokenStore(client, cfg.RefreshTokenSecret, cfg.RefreshTokenTTL),
+		Tokens:           NewRedisTokenBackend(client),
+		Revocations:      NewAccessTokenRevocationStore(client, MaxAccessTokenTTL(cfg)),
+		Statuses:         NewAccountStatusStore(client),
+		LoginAttempts:    NewLoginAttemptStore(client, loginAttemptTTL, loginLockTTL, loginMaxAttempts),
+		VerifyResend:     NewVerifyEmailResendLimiter(client, cfg.VerifyCooldown, int64(cfg.VerifyDailyCap)),
+		MFAChallenges:    NewMFAChallengeStore(client, cfg.MFASecret, cfg.MFAChallengeTTL),
+		WebAuthnSessions: NewWebAuthnSessionStore(client, cfg.WebAuthnTimeout),
+		DataExports:      NewDataExportLimiter(client, cfg.ExportCooldown),
+		LoginRateLimit:   NewRateLimiter(client, "login:rate:", cfg.LoginRateLimit.Window, cfg.LoginRateLimit.Backoff, cfg.LoginRateLimit.MaxBackoff),
+		Requests:         NewWindowCounter(client, "rate:ip:"),
+		ClientAttempts:   NewWindowCounter(client, "captcha:ip:"),
+	}
+}
+
+// NewMemoryStores keeps the auth state in process memory. It is for local
+// development and tests: everything is lost on restart and not shared
+// between instances.
+func NewMemoryStores(cfg config.AuthConfig) *Stores {
+	return &Stores{
+		Refresh:          NewMemoryRefreshTokenStore(cfg.RefreshTokenTTL),
+		Tokens:           NewMemoryTokenBackend(),
+		Revocations:      NewMemoryAccessTokenRevocationStore(MaxAccessTokenTTL(cfg)),
+		Statuses:         NewMemoryAccountStatusStore(),
+		LoginAttempts:    NewMemoryLoginAttemptStore(loginAttemptTTL, loginLockTTL, loginMaxAttempts),
+		VerifyResend:     NewMemoryVerifyEmailResendLimiter(cfg.VerifyCooldown, int64(cfg.VerifyDailyCap)),
+		MFAChallenges:    NewMemoryMFAChallengeStore(cfg.MFAChallengeTTL),
+		WebAuthnSessions: NewMemoryWebAuthnSessionStore(cfg.WebAuthnTimeout),
+		DataExports:      NewMemoryDataExportLimiter(cfg.ExportCooldown),
+		LoginRateLimit:   NewMemoryRateLimiter(cfg.LoginRateLimit.Window, cfg.LoginRateLimit.Backoff, cfg.LoginRateLimit.MaxBackoff),
+		Requests:         NewMemoryWindowCounter(),
+		ClientAttempts:   NewMemoryWindowCounter(),
+	}
+}
@@ -0,0 +1,110 @@
+package token
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	CounterUses     = "uses"
+	CounterAttempts = "attempts"
+)
+
+var ErrTokenNotFound = errors.New("token not found")
+
+// StoredToken is the record a SignedTokenStore keeps per token id.
+type StoredToken struct {
+	Subject   string
+	MAC       string
+	Metadata  map[string]string
+	Uses      int64
+	Attempts  int64
+	ExpiresAt time.Time
+}
+
+// TokenBackend persists signed token records. Increment must be atomic and
+// must fail with ErrTokenNotFound instead of recreating a deleted record.
+type TokenBackend interface {
+	Save(ctx context.Context, key string, record StoredToken, ttl time.Duration) error
+	Load(ctx context.Context, key string) (*StoredToken, error)
+	Increment(ctx context.Context, key, counter string) (int64, error)
+	Delete(ctx context.Context, key string) error
+}
+
+var incrementIfExistsScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return -1
+end
+return redis.call('HINCRBY', KEYS[1], ARGV[1], 1)
+`)
+
+type redisTokenBackend struct {
+	client *redis.Client
+}
+
+// NewRedisTokenBackend stores each token as a hash that expires with it.
+func NewRedisTokenBackend(client *redis.Client) TokenBackend {
+	return &redisTokenBackend{client: client}
+}
+
+func (b *redisTokenBackend) Save(ctx context.Context, key string, record StoredToken, ttl time.Duration) error {
+	metadata, err := json.Marshal(record.Metadata)
+	if err != nil {
+		return err
+	}
+	pipe := b.client.TxPipeline()
+	pipe.HSet(ctx, key, map[string]interface{}{
+		"subject":       record.Subject,
+		"mac":           record.MAC,
+		"metadata":      string(metadata),
+		CounterUses:     record.Uses,
+		CounterAttempts: record.Attempts,
+		"expires_at":    record.ExpiresAt.Unix(),
+	})
+	pipe.Expire(ctx, key, ttl)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (b *redisTokenBackend) Load(ctx context.Context, key string) (*StoredToken, error) {
+	fields, err := b.client.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 || fields["mac"] == "" {
+		return nil, ErrTokenNotFound
+	}
+	record := &StoredToken{
+		Subject:   fields["subject"],
+		MAC:       fields["mac"],
+		ExpiresAt: parseUnix(fields["expires_at"]),
+	}
+	record.Uses, _ = strconv.ParseInt(fields[CounterUses], 10, 64)
+	record.Attempts, _ = strconv.ParseInt(fields[CounterAttempts], 10, 64)
+	if raw := fields["metadata"]; raw != "" && raw != "null" {
+		if err := json.Unmarshal([]byte(raw), &record.Metadata); err != nil {
+			return nil, err
+		}
+	}
+	return record, nil
+}
+
+func (b *redisTokenBackend) Increment(ctx context.Context, key, counter string) (int64, error) {
+	count, err := incrementIfExistsScript.Run(ctx, b.client, []string{key}, counter).Int64()
+	if err != nil {
+		return 0, err
+	}
+	if count < 0 {
+		return 0, ErrTokenNotFound
+	}
+	return count, nil
+}
+
+func (b *redisTokenBackend) Delete(ctx context.Context, key string) error {
+	return b.client.Del(ctx, key).Err()
+}
@@ -0,0 +1,78 @@
+package token
+
+import (
+	"context"
+	"time"
+)
+
+type memoryTokenBackend struct {
+	kv *memoryKV
+}
+
+// NewMemoryTokenBackend keeps tokens in process memory. It is meant for local
+// development and tests: tokens are lost on restart and not shared between
+// instances.
+func NewMemoryTokenBackend() TokenBackend {
+	return &memoryTokenBackend{kv: newMemoryKV()}
+}
+
+func (b *memoryTokenBackend) Save(ctx context.Context, key string, record StoredToken, ttl time.Duration) error {
+	b.kv.mu.Lock()
+	defer b.kv.mu.Unlock()
+
+	record.Metadata = copyMetadata(record.Metadata)
+	b.kv.put(key, &memoryKVEntry{token: record, expiresAt: b.kv.expiry(ttl)})
+	return nil
+}
+
+func (b *memoryTokenBackend) Load(ctx context.Context, key string) (*StoredToken, error) {
+	b.kv.mu.Lock()
+	defer b.kv.mu.Unlock()
+
+	entry, ok := b.kv.get(key)
+	if !ok {
+		return nil, ErrTokenNotFound
+	}
+	record := entry.token
+	record.Metadata = copyMetadata(record.Metadata)
+	return &record, nil
+}
+
+func (b *memoryTokenBackend) Increment(ctx context.Context, key, counter string) (int64, error) {
+	b.kv.mu.Lock()
+	defer b.kv.mu.Unlock()
+
+	entry, ok := b.kv.get(key)
+	if !ok {
+		return 0, ErrTokenNotFound
+	}
+	switch counter {
+	case CounterUses:
+		entry.token.Uses++
+		return entry.token.Uses, nil
+	case CounterAttempts:
+		entry.token.Attempts++
+		return entry.token.Attempts, nil
+	default:
+		return 0, ErrTokenNotFound
+	}
+}
+
+func (b *memoryTokenBackend) Delete(ctx context.Context, key string) error {
+	b.kv.mu.Lock()
+	defer b.kv.mu.Unlock()
+
+	b.kv.del(key)
+	return nil
+}
+
+func copyMetadata(metadata map[string]string) map[string]string {
+	if metadata == nil {
+		return nil
+	}
+	out := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,83 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultVerifyResendCooldown = time.Minute
+	defaultVerifyResendDailyCap = int64(5)
+	verifyResendDailyWindow     = 24 * time.Hour
+)
+
+// VerifyEmailResendLimiter throttles verification emails per address: one per
+// cooldown and at most dailyCap per 24 hours, counted in a fixed window that
+// starts with the first send. Allow records the send when it is allowed;
+// otherwise it reports how long until the next one is.
+type VerifyEmailResendLimiter interface {
+	Allow(ctx context.Context, email string) (allowed bool, retryAfter time.Duration, err error)
+}
+
+type verifyResendLimiter struct {
+	client         *redis.Client
+	cooldown       time.Duration
+	dailyCap       int64
+	cooldownPrefix string
+	dailyPrefix    string
+}
+
+func NewVerifyEmailResendLimiter(client *redis.Client, cooldown time.Duration, dailyCap int64) VerifyEmailResendLimiter {
+	if cooldown <= 0 {
+		cooldown = defaultVerifyResendCooldown
+	}
+	if dailyCap <= 0 {
+		dailyCap = defaultVerifyResendDailyCap
+	}
+	return &verifyResendLimiter{
+		client:         client,
+		cooldown:       cooldown,
+		dailyCap:       dailyCap,
+		cooldownPrefix: "verify:resend:cooldown:",
+		dailyPrefix:    "verify:resend:daily:",
+	}
+}
+
+func (l *verifyResendLimiter) Allow(ctx context.Context, email string) (bool, time.Duration, error) {
+	if l.client == nil {
+		return true, 0, nil
+	}
+	email = normalizeEmail(email)
+	if email == "" {
+		return false, 0, errors.New("invalid email")
+	}
+
+	cooldownKey := l.cooldownPrefix + email
+	set, err := l.client.SetNX(ctx, cooldownKey, "1", l.cooldown).Result()
+	if err != nil {
+		return false, 0, err
+	}
+	if !set {
+		return false, l.ttl(ctx, cooldownKey, l.cooldown), nil
+	}
+
+	count, ttl, err := incrementWindow(ctx, l.client, l.dailyPrefix+email, verifyResendDailyWindow)
+	if err != nil {
+		return false, 0, err
+	}
+	if count > l.dailyCap {
+		return false, ttl, nil
+	}
+	return true, 0, nil
+}
+
+func (l *verifyResendLimiter) ttl(ctx context.Context, key string, fallback time.Duration) time.Duration {
+	ttl, err := l.client.TTL(ctx, key).Result()
+	if err != nil || ttl <= 0 {
+		return fallback
+	}
+	return ttl
+}
@@ -0,0 +1,42 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+type memoryVerifyResendLimiter struct {
+	kv       *memoryKV
+	cooldown time.Duration
+	dailyCap int64
+}
+
+// NewMemoryVerifyEmailResendLimiter mirrors the Redis resend limits in
+// process memory, for a single instance and tests.
+func NewMemoryVerifyEmailResendLimiter(cooldown time.Duration, dailyCap int64) VerifyEmailResendLimiter {
+	if cooldown <= 0 {
+		cooldown = defaultVerifyResendCooldown
+	}
+	if dailyCap <= 0 {
+		dailyCap = defaultVerifyResendDailyCap
+	}
+	return &memoryVerifyResendLimiter{kv: newMemoryKV(), cooldown: cooldown, dailyCap: dailyCap}
+}
+
+func (l *memoryVerifyResendLimiter) Allow(ctx context.Context, email string) (bool, time.Duration, error) {
+	email = normalizeEmail(email)
+	if email == "" {
+		return false, 0, errors.New("invalid email")
+	}
+	l.kv.mu.Lock()
+	defer l.kv.mu.Unlock()
+
+	if !l.kv.setNX("cooldown:"+email, "1", l.cooldown) {
+		return false, l.kv.ttl("cooldown:" + email), nil
+	}
+	if l.kv.incr("daily:"+email, verifyResendDailyWindow) > l.dailyCap {
+		return false, l.kv.ttl("daily:" + email), nil
+	}
+	return true, 0, nil
+}
@@ -0,0 +1,52 @@
+package token
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestVerifyResendDailyCap(t *testing.T) {
+	const cooldown = 5 * time.Millisecond
+	limiters := map[string]VerifyEmailResendLimiter{
+		"memory": NewMemoryVerifyEmailResendLimiter(cooldown, 2),
+	}
+	if addr := os.Getenv("REDIS_TEST_ADDR"); addr != "" {
+		client := redis.NewClient(&redis.Options{Addr: addr})
+		t.Cleanup(func() { _ = client.Close() })
+		if err := client.FlushDB(context.Background()).Err(); err != nil {
+			t.Fatalf("flush redis: %v", err)
+		}
+		limiters["redis"] = NewVerifyEmailResendLimiter(client, cooldown, 2)
+	}
+
+	ctx := context.Background()
+	for name, limiter := range limiters {
+		t.Run(name, func(t *testing.T) {
+			allowed, _, err := limiter.Allow(ctx, "Listener@Example.com")
+			if err != nil || !allowed {
+				t.Fatalf("first send: allowed=%v err=%v", allowed, err)
+			}
+			if allowed, retryAfter, _ := limiter.Allow(ctx, "listener@example.com"); allowed || retryAfter <= 0 {
+				t.Fatalf("send inside cooldown: allowed=%v retryAfter=%v", allowed, retryAfter)
+			}
+
+			time.Sleep(2 * cooldown)
+			if allowed, _, err := limiter.Allow(ctx, "listener@example.com"); err != nil || !allowed {
+				t.Fatalf("second send: allowed=%v err=%v", allowed, err)
+			}
+
+			time.Sleep(2 * cooldown)
+			allowed, retryAfter, err := limiter.Allow(ctx, "listener@example.com")
+			if err != nil || allowed {
+				t.Fatalf("send over the cap: allowed=%v err=%v", allowed, err)
+			}
+			if retryAfter <= 23*time.Hour || retryAfter > verifyResendDailyWindow {
+				t.Fatalf("retry after %v, want the rest of the 24h window", retryAfter)
+			}
+		})
+	}
+}
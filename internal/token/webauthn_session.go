@@ -0,0 +1,75 @@
+package token
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrWebAuthnSessionNotFound = errors.New("webauthn session not found")
+
+// WebAuthnSessionStore keeps the server side state of a passkey ceremony
+// between its begin and finish requests. A session can be consumed once.
+type WebAuthnSessionStore interface {
+	Create(ctx context.Context, userID string, data []byte) (string, time.Time, error)
+	Consume(ctx context.Context, sessionID string) (userID string, data []byte, err error)
+}
+
+type webAuthnSessionStore struct {
+	client *redis.Client
+	ttl    time.Duration
+	prefix string
+}
+
+type webAuthnSession struct {
+	UserID string          `json:"user_id"`
+	Data   json.RawMessage `json:"data"`
+}
+
+func NewWebAuthnSessionStore(client *redis.Client, ttl time.Duration) WebAuthnSessionStore {
+	return &webAuthnSessionStore{
+		client: client,
+		ttl:    ttl,
+		prefix: "webauthn:session:",
+	}
+}
+
+// Create stores the ceremony data. userID is empty for a discoverable login,
+// where the user is only known once the authenticator answers.
+func (s *webAuthnSessionStore) Create(ctx context.Context, userID string, data []byte) (string, time.Time, error) {
+	value, err := json.Marshal(webAuthnSession{UserID: userID, Data: data})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	sessionID := uuid.NewString()
+	if err := s.client.Set(ctx, s.key(sessionID), value, s.ttl).Err(); err != nil {
+		return "", time.Time{}, err
+	}
+	return sessionID, time.Now().UTC().Add(s.ttl), nil
+}
+
+func (s *webAuthnSessionStore) Consume(ctx context.Context, sessionID string) (string, []byte, error) {
+	if sessionID == "" {
+		return "", nil, ErrWebAuthnSessionNotFound
+	}
+	value, err := s.client.GetDel(ctx, s.key(sessionID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return "", nil, ErrWebAuthnSessionNotFound
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	var session webAuthnSession
+	if err := json.Unmarshal(value, &session); err != nil {
+		return "", nil, ErrWebAuthnSessionNotFound
+	}
+	return session.UserID, session.Data, nil
+}
+
+func (s *webAuthnSessionStore) key(sessionID string) string {
+	return s.prefix + sessionID
+}
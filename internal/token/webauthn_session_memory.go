@@ -0,0 +1,49 @@
+package token
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type memoryWebAuthnSessionStore struct {
+	kv  *memoryKV
+	ttl time.Duration
+}
+
+// NewMemoryWebAuthnSessionStore keeps passkey ceremonies in process memory,
+// for a single instance and tests.
+func NewMemoryWebAuthnSessionStore(ttl time.Duration) WebAuthnSessionStore {
+	return &memoryWebAuthnSessionStore{kv: newMemoryKV(), ttl: ttl}
+}
+
+func (s *memoryWebAuthnSessionStore) Create(ctx context.Context, userID string, data []byte) (string, time.Time, error) {
+	value, err := json.Marshal(webAuthnSession{UserID: userID, Data: data})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	sessionID := uuid.NewString()
+	s.kv.mu.Lock()
+	defer s.kv.mu.Unlock()
+
+	s.kv.set(sessionID, string(value), s.ttl)
+	return sessionID, time.Now().UTC().Add(s.ttl), nil
+}
+
+func (s *memoryWebAuthnSessionStore) Consume(ctx context.Context, sessionID string) (string, []byte, error) {
+	s.kv.mu.Lock()
+	defer s.kv.mu.Unlock()
+
+	entry, ok := s.kv.get(sessionID)
+	if !ok {
+		return "", nil, ErrWebAuthnSessionNotFound
+	}
+	s.kv.del(sessionID)
+	var session webAuthnSession
+	if err := json.Unmarshal([]byte(entry.value), &session); err != nil {
+		return "", nil, ErrWebAuthnSessionNotFound
+	}
+	return session.UserID, session.Data, nil
+}
@@ -0,0 +1,59 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// WindowCounter counts events per key in fixed windows: the first event
+// starts a window of the given length and later events in it only add to the
+// count. Increment returns the count so far and the time left in the window;
+// Count reads it without adding to it.
+type WindowCounter interface {
+	Increment(ctx context.Context, key string, window time.Duration) (count int64, ttl time.Duration, err error)
+	Count(ctx context.Context, key string) (int64, error)
+}
+
+// incrementWindowScript counts and sets the expiry in one step, so a counter
+// can never be left without one. A key found without an expiry gets one.
+var incrementWindowScript = redis.NewScript(`
+local count = redis.call("INCR", KEYS[1])
+local ttl = redis.call("PTTL", KEYS[1])
+if ttl < 0 then
+  redis.call("PEXPIRE", KEYS[1], ARGV[1])
+  ttl = tonumber(ARGV[1])
+end
+return {count, ttl}
+`)
+
+type redisWindowCounter struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewWindowCounter(client *redis.Client, prefix string) WindowCounter {
+	return &redisWindowCounter{client: client, prefix: prefix}
+}
+
+func (c *redisWindowCounter) Increment(ctx context.Context, key string, window time.Duration) (int64, time.Duration, error) {
+	return incrementWindow(ctx, c.client, c.prefix+key, window)
+}
+
+func (c *redisWindowCounter) Count(ctx context.Context, key string) (int64, error) {
+	count, err := c.client.Get(ctx, c.prefix+key).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return count, err
+}
+
+func incrementWindow(ctx context.Context, client *redis.Client, key string, window time.Duration) (int64, time.Duration, error) {
+	values, err := incrementWindowScript.Run(ctx, client, []string{key}, window.Milliseconds()).Int64Slice()
+	if err != nil {
+		return 0, 0, err
+	}
+	return values[0], time.Duration(values[1]) * time.Millisecond, nil
+}
@@ -0,0 +1,35 @@
+package token
+
+import (
+	"context"
+	"time"
+)
+
+type memoryWindowCounter struct {
+	kv *memoryKV
+}
+
+// NewMemoryWindowCounter counts in process memory, for a single instance and
+// tests.
+func NewMemoryWindowCounter() WindowCounter {
+	return &memoryWindowCounter{kv: newMemoryKV()}
+}
+
+func (c *memoryWindowCounter) Increment(ctx context.Context, key string, window time.Duration) (int64, time.Duration, error) {
+	c.kv.mu.Lock()
+	defer c.kv.mu.Unlock()
+
+	count := c.kv.incr(key, window)
+	return count, c.kv.ttl(key), nil
+}
+
+func (c *memoryWindowCounter) Count(ctx context.Context, key string) (int64, error) {
+	c.kv.mu.Lock()
+	defer c.kv.mu.Unlock()
+
+	entry, ok := c.kv.get(key)
+	if !ok {
+		return 0, nil
+	}
+	return entry.count, nil
+}